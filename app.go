@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/notblessy/bikinota-core/config"
+	"github.com/notblessy/bikinota-core/db"
+	"github.com/notblessy/bikinota-core/handler"
+	"github.com/notblessy/bikinota-core/logging"
+	"github.com/notblessy/bikinota-core/metrics"
+	"github.com/notblessy/bikinota-core/repository"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// app holds the configuration, database connection and repositories shared
+// by the commands
+type app struct {
+	cfg          *config.Config
+	db           *gorm.DB
+	replica      *gorm.DB // Nil without a read replica
+	userRepo     repository.UserRepository
+	companyRepo  repository.CompanyRepository
+	memberRepo   repository.MemberRepository
+	apiKeyRepo   repository.APIKeyRepository
+	planRepo     repository.PlanRepository
+	invoiceRepo  repository.InvoiceRepository
+	settingsRepo repository.InvoiceSettingsRepository
+	webhookRepo  repository.WebhookRepository
+	assetRepo    repository.AssetRepository
+	auditRepo    repository.AuditRepository
+	transactor   repository.Transactor // Runs changes spanning several repositories atomically
+}
+
+// newApp connects to the database and initializes the repositories
+func newApp(cfg *config.Config) *app {
+	ctx := context.Background()
+
+	conn, err := db.Open(ctx, cfg.Database)
+	if err != nil {
+		logrus.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	replica, err := db.OpenReplica(ctx, cfg.Database)
+	if err != nil {
+		logrus.Fatalf("Failed to connect to read replica: %v", err)
+	}
+
+	// Listings fall back to the primary without a replica
+	reader := conn
+	if replica != nil {
+		reader = replica
+	}
+
+	return &app{
+		cfg:          cfg,
+		db:           conn,
+		replica:      replica,
+		userRepo:     repository.NewUserRepository(conn),
+		companyRepo:  repository.NewCompanyRepository(conn),
+		memberRepo:   repository.NewMemberRepository(conn),
+		apiKeyRepo:   repository.NewAPIKeyRepository(conn),
+		planRepo:     repository.NewPlanRepository(conn),
+		invoiceRepo:  repository.NewInvoiceRepository(conn, reader),
+		settingsRepo: repository.NewInvoiceSettingsRepository(conn),
+		webhookRepo:  repository.NewWebhookRepository(conn, reader),
+		assetRepo:    repository.NewAssetRepository(conn),
+		auditRepo:    repository.NewAuditRepository(conn, reader),
+		transactor:   repository.NewTransactor(conn),
+	}
+}
+
+// readinessChecks lists the dependencies reported by /readyz
+func (a *app) readinessChecks() []handler.ReadinessCheck {
+	checks := []handler.ReadinessCheck{{
+		Name:  "database",
+		Check: func(ctx context.Context) error { return db.Ping(ctx, a.db) },
+	}}
+	if a.replica != nil {
+		checks = append(checks, handler.ReadinessCheck{
+			Name:  "database_replica",
+			Check: func(ctx context.Context) error { return db.Ping(ctx, a.replica) },
+		})
+	}
+	return checks
+}
+
+// registerMetrics exports the connection pool statistics of the databases
+func (a *app) registerMetrics() error {
+	pools := map[string]*gorm.DB{"primary": a.db}
+	if a.replica != nil {
+		pools["replica"] = a.replica
+	}
+
+	for name, conn := range pools {
+		sqlDB, err := conn.DB()
+		if err != nil {
+			return err
+		}
+		if err := metrics.RegisterDB(name, sqlDB); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadConfig loads the configuration from the environment and the given flags,
+// exiting when it is invalid, and sets up logging
+func loadConfig(args []string) *config.Config {
+	cfg, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		logrus.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := logging.Setup(cfg.Log); err != nil {
+		logrus.Fatalf("Invalid log configuration: %v", err)
+	}
+	return cfg
+}
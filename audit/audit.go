@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+// Recorder appends entries to the audit log of a company
+type Recorder interface {
+	// Transaction runs fn in a database transaction. Changes and entries
+	// recorded with the context passed to fn are stored together or not at
+	// all, so no change escapes the audit log.
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+	Record(ctx context.Context, entry *model.AuditEntry, before, after interface{}) error
+}
+
+type Trail struct {
+	auditRepo  repository.AuditRepository
+	transactor repository.Transactor
+}
+
+func NewTrail(auditRepo repository.AuditRepository, transactor repository.Transactor) *Trail {
+	return &Trail{auditRepo: auditRepo, transactor: transactor}
+}
+
+func (t *Trail) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.transactor.Transaction(ctx, fn)
+}
+
+// Record stores entry with the differences between the before and after
+// snapshots of the record. Before is nil for created records and after for
+// deleted ones. Updates that change nothing are not recorded. Called with the
+// context of a Transaction, the entry is part of it.
+func (t *Trail) Record(ctx context.Context, entry *model.AuditEntry, before, after interface{}) error {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 && entry.Action == model.AuditActionUpdate {
+		return nil
+	}
+
+	entry.Changes = changes
+	if err := t.auditRepo.Create(ctx, entry); err != nil {
+		return fmt.Errorf("failed to store audit entry: %w", err)
+	}
+	return nil
+}
+
+// fingerprintKey is generated at startup and never stored
+var fingerprintKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("audit: failed to generate fingerprint key: %v", err))
+	}
+	return key
+}()
+
+// Fingerprint stands for a secret value in snapshots, such as an account
+// number that responses mask. The before and after snapshots of a change are
+// taken by the same process, so their fingerprints differ exactly when the
+// value changed. Being keyed, stored fingerprints cannot be matched against
+// guessed values.
+func Fingerprint(value string) string {
+	mac := hmac.New(sha256.New, fingerprintKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Diff compares the JSON encodings of two snapshots field by field. Nested
+// fields are keyed by their dotted path; elements of lists of objects with an
+// "id" are keyed by that ID so reordering a list is not reported as a change
+// of every element.
+func Diff(before, after interface{}) (map[string]model.AuditChange, error) {
+	oldFields, err := flatten(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := flatten(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]model.AuditChange)
+	for path, oldValue := range oldFields {
+		if newValue := newFields[path]; !reflect.DeepEqual(oldValue, newValue) {
+			changes[path] = model.AuditChange{Before: oldValue, After: newValue}
+		}
+	}
+	for path, newValue := range newFields {
+		if _, ok := oldFields[path]; !ok && newValue != nil {
+			changes[path] = model.AuditChange{After: newValue}
+		}
+	}
+	return changes, nil
+}
+
+// flatten returns the leaf values of the JSON encoding of v by path
+func flatten(v interface{}) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if v == nil {
+		return fields, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode audit snapshot: %w", err)
+	}
+
+	flattenValue(fields, "", decoded)
+	return fields, nil
+}
+
+func flattenValue(fields map[string]interface{}, path string, v interface{}) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			flattenValue(fields, join(path, key), child)
+		}
+	case []interface{}:
+		for i, child := range value {
+			key := strconv.Itoa(i)
+			if object, ok := child.(map[string]interface{}); ok {
+				if id, ok := object["id"].(string); ok && id != "" {
+					key = id
+				}
+			}
+			flattenValue(fields, join(path, key), child)
+		}
+	default:
+		fields[path] = value
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
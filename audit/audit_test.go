@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+type item struct {
+	ID    string  `json:"id"`
+	Price float64 `json:"price"`
+}
+
+type invoice struct {
+	Status   string  `json:"status"`
+	BankName *string `json:"bank_name"`
+	Items    []item  `json:"items"`
+}
+
+func TestDiff(t *testing.T) {
+	bank := "BCA"
+
+	tests := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+		want   map[string]model.AuditChange
+	}{
+		{
+			name:  "created",
+			after: invoice{Status: "draft", Items: []item{{ID: "1", Price: 10}}},
+			want: map[string]model.AuditChange{
+				"status":        {After: "draft"},
+				"items.1.id":    {After: "1"},
+				"items.1.price": {After: 10.0},
+			},
+		},
+		{
+			name:   "deleted",
+			before: invoice{Status: "paid"},
+			want: map[string]model.AuditChange{
+				"status": {Before: "paid"},
+			},
+		},
+		{
+			name:   "changed fields only",
+			before: invoice{Status: "draft", Items: []item{{ID: "1", Price: 10}, {ID: "2", Price: 5}}},
+			after:  invoice{Status: "sent", BankName: &bank, Items: []item{{ID: "2", Price: 5}, {ID: "1", Price: 12}}},
+			want: map[string]model.AuditChange{
+				"status":        {Before: "draft", After: "sent"},
+				"bank_name":     {After: "BCA"},
+				"items.1.price": {Before: 10.0, After: 12.0},
+			},
+		},
+		{
+			name:   "item removed",
+			before: invoice{Items: []item{{ID: "1", Price: 10}}},
+			after:  invoice{Items: []item{}},
+			want: map[string]model.AuditChange{
+				"items.1.id":    {Before: "1"},
+				"items.1.price": {Before: 10.0},
+			},
+		},
+		{
+			name:   "unchanged",
+			before: invoice{Status: "draft"},
+			after:  invoice{Status: "draft"},
+			want:   map[string]model.AuditChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Diff(tt.before, tt.after)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type memoryRepository struct {
+	entries []model.AuditEntry
+}
+
+func (r *memoryRepository) Create(ctx context.Context, entry *model.AuditEntry) error {
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *memoryRepository) List(ctx context.Context, filter repository.AuditFilter) ([]model.AuditEntry, error) {
+	return r.entries, nil
+}
+
+func TestRecordSkipsEmptyUpdates(t *testing.T) {
+	repo := &memoryRepository{}
+	trail := NewTrail(repo, nil)
+	ctx := context.Background()
+
+	snapshot := invoice{Status: "draft"}
+	if err := trail.Record(ctx, &model.AuditEntry{Action: model.AuditActionUpdate}, snapshot, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.entries) != 0 {
+		t.Fatalf("recorded %d entries for an unchanged record, want 0", len(repo.entries))
+	}
+
+	if err := trail.Record(ctx, &model.AuditEntry{Action: model.AuditActionDelete}, snapshot, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.entries) != 1 || repo.entries[0].Changes["status"].Before != "draft" {
+		t.Fatalf("entries = %+v, want one delete entry", repo.entries)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a, b := Fingerprint("1234567890"), Fingerprint("9999567890")
+	if a != Fingerprint("1234567890") {
+		t.Error("fingerprints of the same value differ")
+	}
+	if a == b {
+		t.Error("fingerprints of different values are equal")
+	}
+	if strings.Contains(a, "1234567890") {
+		t.Errorf("fingerprint %s contains the value", a)
+	}
+}
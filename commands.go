@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/sirupsen/logrus"
+)
+
+const userUsage = `usage: user <command> [flags]
+
+commands:
+  create           -email <email> -name <name> [-password <password>]
+  reset-password   -email <email> [-password <password>]
+
+A random password is generated and printed when -password is omitted.`
+
+// runUser creates users and resets their passwords
+func runUser(args []string) {
+	if len(args) == 0 {
+		usageExit(userUsage)
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("user create", flag.ExitOnError)
+		email := fs.String("email", "", "email address to sign in with")
+		name := fs.String("name", "", "display name")
+		password := fs.String("password", "", "password, generated when empty")
+		fs.Parse(args[1:])
+
+		if *email == "" || *name == "" {
+			usageExit(userUsage)
+		}
+		generated := *password == ""
+		if generated {
+			*password = randomPassword()
+		}
+
+		a := newApp(loadConfig(nil))
+		user := &model.User{Email: *email, Name: *name, Password: *password}
+		if err := a.userRepo.Create(context.Background(), user); err != nil {
+			logrus.Fatalf("Failed to create user: %v", err)
+		}
+
+		fmt.Printf("created user %d <%s>\n", user.ID, user.Email)
+		if generated {
+			fmt.Printf("password: %s\n", *password)
+		}
+
+	case "reset-password":
+		fs := flag.NewFlagSet("user reset-password", flag.ExitOnError)
+		email := fs.String("email", "", "email address of the user")
+		password := fs.String("password", "", "new password, generated when empty")
+		fs.Parse(args[1:])
+
+		if *email == "" {
+			usageExit(userUsage)
+		}
+		generated := *password == ""
+		if generated {
+			*password = randomPassword()
+		}
+
+		ctx := context.Background()
+		a := newApp(loadConfig(nil))
+		user, err := a.userRepo.FindByEmail(ctx, *email)
+		if err != nil {
+			logrus.Fatalf("Failed to find user: %v", err)
+		}
+		if err := a.userRepo.SetPassword(ctx, user.ID, *password); err != nil {
+			logrus.Fatalf("Failed to reset password: %v", err)
+		}
+
+		fmt.Printf("reset password of user %d <%s>\n", user.ID, user.Email)
+		if generated {
+			fmt.Printf("password: %s\n", *password)
+		}
+
+	default:
+		usageExit(userUsage)
+	}
+}
+
+const planUsage = `usage: plan set -company <id> -plan <free|unlimited>`
+
+// runPlan changes the plan of a company
+func runPlan(args []string) {
+	if len(args) == 0 || args[0] != "set" {
+		usageExit(planUsage)
+	}
+
+	fs := flag.NewFlagSet("plan set", flag.ExitOnError)
+	companyID := fs.Uint("company", 0, "company ID")
+	planType := fs.String("plan", "", "plan type: free or unlimited")
+	fs.Parse(args[1:])
+
+	if *companyID == 0 {
+		usageExit(planUsage)
+	}
+	if model.PlanType(*planType) != model.PlanFree && model.PlanType(*planType) != model.PlanUnlimited {
+		usageExit(planUsage)
+	}
+
+	ctx := context.Background()
+	a := newApp(loadConfig(nil))
+	company, err := a.companyRepo.FindByID(ctx, *companyID)
+	if err != nil {
+		logrus.Fatalf("Failed to find company: %v", err)
+	}
+
+	plan, err := a.planRepo.FindByCompanyID(ctx, company.ID)
+	if err != nil {
+		logrus.Fatalf("Failed to retrieve plan: %v", err)
+	}
+
+	// Plans changed from the command line are attributed to the company's creator
+	if plan == nil {
+		plan = &model.Plan{CompanyID: company.ID}
+	}
+	plan.UserID = company.UserID
+	plan.PlanType = model.PlanType(*planType)
+
+	if plan.ID == 0 {
+		err = a.planRepo.Create(ctx, plan)
+	} else {
+		err = a.planRepo.Update(ctx, plan)
+	}
+	if err != nil {
+		logrus.Fatalf("Failed to save plan: %v", err)
+	}
+
+	fmt.Printf("company %d (%s) is now on the %s plan\n", company.ID, company.Name, plan.PlanType)
+}
+
+const invoiceUsage = `usage: invoice renumber -company <id>
+
+Gives the company's invoices consecutive numbers per month in creation order.`
+
+// runInvoice maintains invoices
+func runInvoice(args []string) {
+	if len(args) == 0 || args[0] != "renumber" {
+		usageExit(invoiceUsage)
+	}
+
+	fs := flag.NewFlagSet("invoice renumber", flag.ExitOnError)
+	companyID := fs.Uint("company", 0, "company ID")
+	fs.Parse(args[1:])
+
+	if *companyID == 0 {
+		usageExit(invoiceUsage)
+	}
+
+	a := newApp(loadConfig(nil))
+	renumbered, err := a.renumberInvoices(context.Background(), *companyID)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	fmt.Printf("renumbered %d invoices of company %d\n", renumbered, *companyID)
+}
+
+// renumberInvoices renumbers the invoices of an existing company and returns
+// how many got a new number. Running it again changes nothing.
+func (a *app) renumberInvoices(ctx context.Context, companyID uint) (int, error) {
+	if _, err := a.companyRepo.FindByID(ctx, companyID); err != nil {
+		return 0, fmt.Errorf("failed to find company: %w", err)
+	}
+
+	renumbered, err := a.invoiceRepo.Renumber(ctx, companyID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to renumber invoices: %w", err)
+	}
+	return renumbered, nil
+}
+
+func usageExit(usage string) {
+	fmt.Fprintln(os.Stderr, usage)
+	os.Exit(2)
+}
+
+// randomPassword returns a password for accounts created from the command line
+func randomPassword() string {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		logrus.Fatalf("Failed to generate password: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/notblessy/bikinota-core/config"
+	"github.com/notblessy/bikinota-core/db"
+	"github.com/notblessy/bikinota-core/model"
+)
+
+// newTestApp connects the commands to a migrated in-memory database
+func newTestApp(t *testing.T) *app {
+	t.Helper()
+
+	a := newApp(&config.Config{Database: config.DatabaseConfig{Driver: db.DriverSQLite, URL: ":memory:"}})
+	t.Cleanup(func() {
+		if sqlDB, err := a.db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	migrator, err := db.NewMigrator(a.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestRenumberInvoices(t *testing.T) {
+	a := newTestApp(t)
+	ctx := context.Background()
+
+	user := &model.User{Email: "owner@example.com", Name: "Owner", Password: "secret123"}
+	if err := a.userRepo.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	company := &model.Company{UserID: user.ID, Name: "Acme"}
+	if err := a.companyRepo.Create(ctx, company); err != nil {
+		t.Fatal(err)
+	}
+
+	// Invoices whose creation order differs from their ID order, across two
+	// months, one of them deleted
+	created := []time.Time{
+		time.Date(2024, 2, 10, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 20, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC),
+	}
+	invoices := make([]*model.Invoice, len(created))
+	for i, at := range created {
+		invoices[i] = &model.Invoice{UserID: user.ID, CompanyID: company.ID, CustomerName: "Customer", Status: "draft"}
+		if err := a.invoiceRepo.Create(ctx, invoices[i]); err != nil {
+			t.Fatal(err)
+		}
+		if err := a.db.Model(invoices[i]).Update("created_at", at).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.invoiceRepo.Delete(ctx, invoices[1].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[uint]string{
+		invoices[2].ID: "INV-202401-001",
+		invoices[1].ID: "INV-202401-002",
+		invoices[3].ID: "INV-202402-001",
+		invoices[0].ID: "INV-202402-002",
+	}
+	check := func(t *testing.T) {
+		t.Helper()
+
+		var stored []model.Invoice
+		if err := a.db.Unscoped().Where("company_id = ?", company.ID).Find(&stored).Error; err != nil {
+			t.Fatal(err)
+		}
+		for _, invoice := range stored {
+			if invoice.InvoiceNumber != want[invoice.ID] {
+				t.Errorf("invoice %d is numbered %s, want %s", invoice.ID, invoice.InvoiceNumber, want[invoice.ID])
+			}
+		}
+	}
+
+	renumbered, err := a.renumberInvoices(ctx, company.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renumbered != len(invoices) {
+		t.Errorf("renumbered %d invoices, want %d", renumbered, len(invoices))
+	}
+	check(t)
+
+	// A second run finds every invoice in place
+	renumbered, err = a.renumberInvoices(ctx, company.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renumbered != 0 {
+		t.Errorf("second run renumbered %d invoices, want none", renumbered)
+	}
+	check(t)
+
+	if _, err := a.renumberInvoices(ctx, company.ID+1); err == nil {
+		t.Error("renumbering the invoices of an unknown company succeeded")
+	}
+}
@@ -0,0 +1,363 @@
+// Package config loads the settings of the server and the commands. Every
+// setting has a default and can be overridden, in increasing precedence, by a
+// KEY=VALUE file named by CONFIG_FILE or -config, by environment variables and
+// by command line flags such as -http-addr for HTTP_ADDR.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Storage drivers accepted in STORAGE_DRIVER, empty selects one automatically
+const (
+	StorageCloudinary = "cloudinary"
+	StorageLocal      = "local"
+	StorageS3         = "s3"
+)
+
+type Config struct {
+	HTTP     HTTPConfig
+	Database DatabaseConfig
+	JWT      JWTConfig
+	Storage  StorageConfig
+	Webhook  WebhookConfig
+	Log      LogConfig
+	Tracing  TracingConfig
+}
+
+type HTTPConfig struct {
+	Addr            string        // Listen address, e.g. :8080
+	ReadTimeout     time.Duration // Reading the whole request, including the body
+	WriteTimeout    time.Duration // From the end of the request headers to the end of the response
+	IdleTimeout     time.Duration // Keep-alive connections
+	ShutdownTimeout time.Duration // Waiting for in-flight requests on shutdown
+	CORSOrigins     []string      // Origins allowed to call the API, * allows any
+	MetricsAddr     string        // Internal listen address of /metrics, empty disables it
+	TrustedProxies  []string      // CIDR ranges of proxies whose X-Forwarded-For is believed
+}
+
+type DatabaseConfig struct {
+	Driver           string // postgres, or sqlite for local development and tests
+	URL              string // Connection string, or the database file for sqlite
+	ReplicaURL       string // Optional read replica serving list and report queries
+	MaxOpenConns     int    // 0 means unlimited
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration
+	ConnMaxIdleTime  time.Duration
+	StatementTimeout time.Duration // 0 disables the timeout
+	ConnectAttempts  int           // Attempts to reach the database at startup
+	ConnectBackoff   time.Duration // Wait after the first failed attempt, doubled after each one
+	SlowQuery        time.Duration // Queries taking longer are logged as warnings, 0 disables it
+}
+
+type JWTConfig struct {
+	Secret     string
+	SessionTTL time.Duration
+}
+
+type StorageConfig struct {
+	Driver           string
+	CloudinaryURL    string
+	CloudinaryFolder string
+	LocalDir         string
+	PublicURL        string // Base URL of files served by the local driver
+	S3Endpoint       string
+	S3Region         string
+	S3Bucket         string
+	S3AccessKeyID    string
+	S3SecretKey      string
+	S3UseSSL         bool
+	S3PublicURL      string
+}
+
+type WebhookConfig struct {
+	PollInterval         time.Duration // How often the worker looks for due deliveries
+	AllowPrivateNetworks bool          // Allow endpoints on loopback and private addresses, for local development
+}
+
+type TracingConfig struct {
+	Exporter     string  // none, otlp or stdout
+	OTLPEndpoint string  // host:port of the collector's OTLP/HTTP receiver
+	OTLPInsecure bool    // Send to the collector without TLS
+	SampleRatio  float64 // Share of new traces that are recorded, from 0 to 1
+	ServiceName  string
+}
+
+type LogConfig struct {
+	Level  string // Minimum level, e.g. info or debug
+	Format string // json or text
+}
+
+// setting binds an environment variable to a field of Config
+type setting struct {
+	key   string
+	def   string
+	usage string
+	set   func(value string) error
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"HTTP_ADDR", ":8080", "listen address", setString(&c.HTTP.Addr)},
+		{"HTTP_READ_TIMEOUT", "15s", "timeout for reading a request", setDuration(&c.HTTP.ReadTimeout)},
+		{"HTTP_WRITE_TIMEOUT", "30s", "timeout for writing a response", setDuration(&c.HTTP.WriteTimeout)},
+		{"HTTP_IDLE_TIMEOUT", "60s", "timeout for idle keep-alive connections", setDuration(&c.HTTP.IdleTimeout)},
+		{"HTTP_SHUTDOWN_TIMEOUT", "10s", "time given to in-flight requests on shutdown", setDuration(&c.HTTP.ShutdownTimeout)},
+		{"CORS_ALLOWED_ORIGINS", "*", "comma separated origins allowed to call the API", setList(&c.HTTP.CORSOrigins)},
+		{"TRUSTED_PROXIES", "", "comma separated CIDR ranges of proxies setting X-Forwarded-For, empty to use the peer address", setList(&c.HTTP.TrustedProxies)},
+		{"METRICS_ADDR", ":9090", "internal listen address of /metrics, not to be exposed publicly, empty to disable", setString(&c.HTTP.MetricsAddr)},
+
+		{"DATABASE_DRIVER", "postgres", "database: postgres, or sqlite for local development", setString(&c.Database.Driver)},
+		{"DATABASE_URL", "", "Postgres connection string or SQLite database file", setString(&c.Database.URL)},
+		{"DB_REPLICA_URL", "", "connection string of a read replica for list and report queries", setString(&c.Database.ReplicaURL)},
+		{"DB_MAX_OPEN_CONNS", "25", "maximum open database connections, 0 for unlimited", setInt(&c.Database.MaxOpenConns)},
+		{"DB_MAX_IDLE_CONNS", "5", "maximum idle database connections", setInt(&c.Database.MaxIdleConns)},
+		{"DB_CONN_MAX_LIFETIME", "30m", "maximum lifetime of a database connection", setDuration(&c.Database.ConnMaxLifetime)},
+		{"DB_CONN_MAX_IDLE_TIME", "5m", "maximum idle time of a database connection", setDuration(&c.Database.ConnMaxIdleTime)},
+		{"DB_STATEMENT_TIMEOUT", "30s", "cancel statements running longer than this, 0 to disable", setDuration(&c.Database.StatementTimeout)},
+		{"DB_CONNECT_ATTEMPTS", "10", "attempts to reach the database at startup", setInt(&c.Database.ConnectAttempts)},
+		{"DB_CONNECT_BACKOFF", "500ms", "wait after the first failed connection attempt, doubled after each one", setDuration(&c.Database.ConnectBackoff)},
+		{"DB_SLOW_QUERY", "200ms", "log queries taking longer as warnings, 0 to disable", setDuration(&c.Database.SlowQuery)},
+
+		{"JWT_SECRET", "", "secret signing session tokens", setString(&c.JWT.Secret)},
+		{"JWT_SESSION_TTL", "168h", "lifetime of session tokens", setDuration(&c.JWT.SessionTTL)},
+
+		{"STORAGE_DRIVER", "", "blob storage: cloudinary, local or s3, chosen automatically when empty", setString(&c.Storage.Driver)},
+		{"CLOUDINARY_URL", "", "Cloudinary credentials URL", setString(&c.Storage.CloudinaryURL)},
+		{"STORAGE_CLOUDINARY_FOLDER", "bikinota", "Cloudinary folder", setString(&c.Storage.CloudinaryFolder)},
+		{"STORAGE_LOCAL_DIR", "./data/uploads", "directory of the local driver", setString(&c.Storage.LocalDir)},
+		{"STORAGE_PUBLIC_URL", "http://localhost:8080", "base URL of files served by the local driver", setString(&c.Storage.PublicURL)},
+		{"STORAGE_S3_ENDPOINT", "", "S3 endpoint", setString(&c.Storage.S3Endpoint)},
+		{"STORAGE_S3_REGION", "", "S3 region", setString(&c.Storage.S3Region)},
+		{"STORAGE_S3_BUCKET", "", "S3 bucket", setString(&c.Storage.S3Bucket)},
+		{"STORAGE_S3_ACCESS_KEY_ID", "", "S3 access key ID", setString(&c.Storage.S3AccessKeyID)},
+		{"STORAGE_S3_SECRET_ACCESS_KEY", "", "S3 secret access key", setString(&c.Storage.S3SecretKey)},
+		{"STORAGE_S3_USE_SSL", "true", "connect to S3 over TLS", setBool(&c.Storage.S3UseSSL)},
+		{"STORAGE_S3_PUBLIC_URL", "", "public base URL of the S3 bucket", setString(&c.Storage.S3PublicURL)},
+
+		{"WEBHOOK_POLL_INTERVAL", "5s", "how often due webhook deliveries are sent", setDuration(&c.Webhook.PollInterval)},
+		{"WEBHOOK_ALLOW_PRIVATE_NETWORKS", "false", "allow webhook endpoints on loopback, private and link-local addresses", setBool(&c.Webhook.AllowPrivateNetworks)},
+
+		{"LOG_LEVEL", "info", "minimum log level: debug, info, warn or error, debug also logs every query", setString(&c.Log.Level)},
+		{"LOG_FORMAT", "json", "log format: json or text", setString(&c.Log.Format)},
+
+		{"TRACING_EXPORTER", "none", "trace exporter: none, otlp or stdout", setString(&c.Tracing.Exporter)},
+		{"TRACING_OTLP_ENDPOINT", "localhost:4318", "host:port of the OTLP/HTTP collector", setString(&c.Tracing.OTLPEndpoint)},
+		{"TRACING_OTLP_INSECURE", "true", "send traces to the collector without TLS", setBool(&c.Tracing.OTLPInsecure)},
+		{"TRACING_SAMPLE_RATIO", "1", "share of new traces that are recorded, from 0 to 1", setFloat(&c.Tracing.SampleRatio)},
+		{"TRACING_SERVICE_NAME", "bikinota-core", "service name reported in traces", setString(&c.Tracing.ServiceName)},
+	}
+}
+
+// Load builds the configuration from the defaults, the config file, the
+// environment and the flags in args, then validates it
+func Load(args []string) (*Config, error) {
+	cfg := &Config{}
+	settings := cfg.settings()
+
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	file := fs.String("config", os.Getenv("CONFIG_FILE"), "file with KEY=VALUE settings")
+	flagValues := map[string]string{}
+	for _, s := range settings {
+		key := s.key
+		fs.Func(flagName(key), fmt.Sprintf("%s (%s)", s.usage, key), func(value string) error {
+			flagValues[key] = value
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	fileValues := map[string]string{}
+	if *file != "" {
+		var err error
+		if fileValues, err = godotenv.Read(*file); err != nil {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+	}
+
+	var errs []error
+	for _, s := range settings {
+		value := s.def
+		if v, ok := fileValues[s.key]; ok {
+			value = v
+		}
+		if v, ok := os.LookupEnv(s.key); ok {
+			value = v
+		}
+		if v, ok := flagValues[s.key]; ok {
+			value = v
+		}
+		if err := s.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.key, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate reports every invalid setting
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	_, _, err := net.SplitHostPort(c.HTTP.Addr)
+	check(err == nil, "HTTP_ADDR", "must be host:port or :port")
+	check(c.HTTP.ReadTimeout > 0, "HTTP_READ_TIMEOUT", "must be positive")
+	check(c.HTTP.WriteTimeout > 0, "HTTP_WRITE_TIMEOUT", "must be positive")
+	check(c.HTTP.IdleTimeout > 0, "HTTP_IDLE_TIMEOUT", "must be positive")
+	check(c.HTTP.ShutdownTimeout > 0, "HTTP_SHUTDOWN_TIMEOUT", "must be positive")
+	check(len(c.HTTP.CORSOrigins) > 0, "CORS_ALLOWED_ORIGINS", "must list at least one origin")
+	for _, cidr := range c.HTTP.TrustedProxies {
+		_, _, err := net.ParseCIDR(cidr)
+		check(err == nil, "TRUSTED_PROXIES", "%q is not a CIDR range", cidr)
+	}
+	if c.HTTP.MetricsAddr != "" {
+		_, _, err := net.SplitHostPort(c.HTTP.MetricsAddr)
+		check(err == nil, "METRICS_ADDR", "must be host:port or :port")
+		check(c.HTTP.MetricsAddr != c.HTTP.Addr, "METRICS_ADDR", "must differ from HTTP_ADDR, the metrics are not public")
+	}
+
+	check(c.Database.URL != "", "DATABASE_URL", "is required")
+	switch c.Database.Driver {
+	case "postgres":
+	case "sqlite":
+		check(c.Database.ReplicaURL == "", "DB_REPLICA_URL", "is only supported by postgres")
+	default:
+		check(false, "DATABASE_DRIVER", "must be postgres or sqlite")
+	}
+	check(c.Database.MaxOpenConns >= 0, "DB_MAX_OPEN_CONNS", "must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS", "must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"DB_MAX_IDLE_CONNS", "must not exceed DB_MAX_OPEN_CONNS (%d)", c.Database.MaxOpenConns)
+	check(c.Database.ConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME", "must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "DB_CONN_MAX_IDLE_TIME", "must not be negative")
+	check(c.Database.StatementTimeout >= 0, "DB_STATEMENT_TIMEOUT", "must not be negative")
+	check(c.Database.ConnectAttempts >= 1, "DB_CONNECT_ATTEMPTS", "must be at least 1")
+	check(c.Database.ConnectBackoff > 0, "DB_CONNECT_BACKOFF", "must be positive")
+	check(c.Database.SlowQuery >= 0, "DB_SLOW_QUERY", "must not be negative")
+
+	check(c.JWT.Secret != "", "JWT_SECRET", "is required")
+	check(c.JWT.SessionTTL > 0, "JWT_SESSION_TTL", "must be positive")
+
+	switch c.Storage.Driver {
+	case "", StorageLocal, StorageS3:
+	case StorageCloudinary:
+		check(c.Storage.CloudinaryURL != "", "CLOUDINARY_URL", "is required by the cloudinary driver")
+	default:
+		check(false, "STORAGE_DRIVER", "must be %s, %s or %s", StorageCloudinary, StorageLocal, StorageS3)
+	}
+
+	check(c.Webhook.PollInterval > 0, "WEBHOOK_POLL_INTERVAL", "must be positive")
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		check(false, "LOG_LEVEL", "must be debug, info, warn or error")
+	}
+	check(c.Log.Format == "json" || c.Log.Format == "text", "LOG_FORMAT", "must be json or text")
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		check(c.Tracing.OTLPEndpoint != "", "TRACING_OTLP_ENDPOINT", "is required by the otlp exporter")
+	default:
+		check(false, "TRACING_EXPORTER", "must be none, otlp or stdout")
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO", "must be between 0 and 1")
+	check(c.Tracing.ServiceName != "", "TRACING_SERVICE_NAME", "is required")
+
+	return errors.Join(errs...)
+}
+
+// flagName turns HTTP_ADDR into http-addr
+func flagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+func setString(p *string) func(string) error {
+	return func(value string) error {
+		*p = value
+		return nil
+	}
+}
+
+func setInt(p *int) func(string) error {
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*p = n
+		return nil
+	}
+}
+
+func setBool(p *bool) func(string) error {
+	return func(value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		*p = b
+		return nil
+	}
+}
+
+func setFloat(p *float64) func(string) error {
+	return func(value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*p = f
+		return nil
+	}
+}
+
+func setDuration(p *time.Duration) func(string) error {
+	return func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s or 5m", value)
+		}
+		*p = d
+		return nil
+	}
+}
+
+// setList splits comma separated values, ignoring empty entries
+func setList(p *[]string) func(string) error {
+	return func(value string) error {
+		*p = nil
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*p = append(*p, item)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bikinota.env")
+	contents := "DATABASE_URL=postgres://file\nJWT_SECRET=from-file\nHTTP_ADDR=:7000\nDB_MAX_OPEN_CONNS=40\n"
+	if err := os.WriteFile(file, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("JWT_SECRET", "from-env")
+	t.Setenv("HTTP_ADDR", ":7001")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.bikinota.com, https://bikinota.com")
+
+	cfg, err := Load([]string{"-http-addr", ":7002"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Database.URL != "postgres://file" {
+		t.Errorf("database url = %q, want the file value", cfg.Database.URL)
+	}
+	if cfg.JWT.Secret != "from-env" {
+		t.Errorf("jwt secret = %q, want the environment value", cfg.JWT.Secret)
+	}
+	if cfg.HTTP.Addr != ":7002" {
+		t.Errorf("addr = %q, want the flag value", cfg.HTTP.Addr)
+	}
+	if cfg.Database.MaxOpenConns != 40 || cfg.Database.MaxIdleConns != 5 {
+		t.Errorf("pool = %d/%d, want 40/5", cfg.Database.MaxOpenConns, cfg.Database.MaxIdleConns)
+	}
+	if cfg.JWT.SessionTTL != 7*24*time.Hour {
+		t.Errorf("session ttl = %s, want the default", cfg.JWT.SessionTTL)
+	}
+	if len(cfg.HTTP.CORSOrigins) != 2 || cfg.HTTP.CORSOrigins[1] != "https://bikinota.com" {
+		t.Errorf("cors origins = %q", cfg.HTTP.CORSOrigins)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{"missing jwt secret", map[string]string{"JWT_SECRET": ""}, "JWT_SECRET: is required"},
+		{"malformed duration", map[string]string{"HTTP_READ_TIMEOUT": "soon"}, "HTTP_READ_TIMEOUT"},
+		{"idle above open", map[string]string{"DB_MAX_OPEN_CONNS": "2", "DB_MAX_IDLE_CONNS": "3"}, "DB_MAX_IDLE_CONNS"},
+		{"unknown storage driver", map[string]string{"STORAGE_DRIVER": "ftp"}, "STORAGE_DRIVER"},
+		{"bad listen address", map[string]string{"HTTP_ADDR": "8080"}, "HTTP_ADDR"},
+		{"bad trusted proxy", map[string]string{"TRUSTED_PROXIES": "10.0.0.0/8, proxy"}, "TRUSTED_PROXIES"},
+		{"bad metrics address", map[string]string{"METRICS_ADDR": "9090"}, "METRICS_ADDR"},
+		{"public metrics", map[string]string{"HTTP_ADDR": ":8080", "METRICS_ADDR": ":8080"}, "METRICS_ADDR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_FILE", "")
+			t.Setenv("DATABASE_URL", "postgres://localhost/bikinota")
+			t.Setenv("JWT_SECRET", "secret")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := Load(nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one mentioning %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Package db connects to the database and migrates its schema. Postgres is
+// used in production, SQLite for local development and hermetic tests.
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/notblessy/bikinota-core/config"
+	"gorm.io/gorm"
+)
+
+// Supported values of DATABASE_DRIVER
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Open connects to the database selected by cfg.Driver
+func Open(ctx context.Context, cfg config.DatabaseConfig) (*gorm.DB, error) {
+	switch cfg.Driver {
+	case DriverPostgres:
+		return openPostgres(ctx, cfg.URL, cfg)
+	case DriverSQLite:
+		return openSQLite(cfg.URL, cfg)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
+
+// OpenReplica connects to the read replica like Open. It returns nil when no
+// replica is configured.
+func OpenReplica(ctx context.Context, cfg config.DatabaseConfig) (*gorm.DB, error) {
+	if cfg.ReplicaURL == "" {
+		return nil, nil
+	}
+	return openPostgres(ctx, cfg.ReplicaURL, cfg)
+}
+
+// Ping checks that the database accepts connections
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func gormConfig(cfg config.DatabaseConfig) *gorm.Config {
+	return &gorm.Config{
+		Logger:      newQueryLogger(cfg.SlowQuery),
+		PrepareStmt: false,
+		// Report unique violations as gorm.ErrDuplicatedKey
+		TranslateError: true,
+	}
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/notblessy/bikinota-core/logging"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// queryLogger writes GORM's logs with the logger of the query's context, so
+// queries carry the ID of the request that ran them. Failed and slow queries
+// are warnings, every other query is logged at debug level.
+type queryLogger struct {
+	slowQuery time.Duration
+}
+
+func newQueryLogger(slowQuery time.Duration) gormlogger.Interface {
+	return &queryLogger{slowQuery: slowQuery}
+}
+
+// LogMode is ignored, the level of the standard logger applies
+func (l *queryLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (l *queryLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	logging.FromContext(ctx).Infof(msg, args...)
+}
+
+func (l *queryLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	logging.FromContext(ctx).Warnf(msg, args...)
+}
+
+func (l *queryLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	logging.FromContext(ctx).Errorf(msg, args...)
+}
+
+func (l *queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	logger := logging.FromContext(ctx)
+
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	slow := l.slowQuery > 0 && elapsed > l.slowQuery
+	if !failed && !slow && !logger.Logger.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+
+	sql, rows := fc()
+	logger = logger.WithFields(logrus.Fields{
+		"sql":         sql,
+		"rows":        rows,
+		"duration_ms": elapsed.Milliseconds(),
+	})
+	switch {
+	case failed:
+		logger.WithError(err).Warn("Query failed")
+	case slow:
+		logger.Warn("Slow query")
+	default:
+		logger.Debug("Query")
+	}
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/notblessy/bikinota-core/config"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	postgres, err := loadMigrations(migrationFiles, "migrations/"+DriverPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postgres) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, m := range postgres {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %s has version %d, want %d", m.Name, m.Version, i+1)
+		}
+	}
+
+	// Every schema change must be written for both drivers
+	sqlite, err := loadMigrations(migrationFiles, "migrations/"+DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sqlite) != len(postgres) {
+		t.Fatalf("%d sqlite migrations, want %d like postgres", len(sqlite), len(postgres))
+	}
+	for i := range postgres {
+		if sqlite[i].Version != postgres[i].Version || sqlite[i].Name != postgres[i].Name {
+			t.Errorf("sqlite migration %d_%s, want %d_%s", sqlite[i].Version, sqlite[i].Name, postgres[i].Version, postgres[i].Name)
+		}
+	}
+}
+
+func TestMigratorSQLite(t *testing.T) {
+	ctx := context.Background()
+	conn, err := Open(ctx, config.DatabaseConfig{Driver: DriverSQLite, URL: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrator, err := NewMigrator(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := migrator.UpTo(ctx, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 5 || first[len(first)-1].Version != 5 {
+		t.Fatalf("UpTo 5 applied %d migrations", len(first))
+	}
+
+	rest, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied := append(first, rest...)
+	if len(applied) != len(migrator.migrations) {
+		t.Fatalf("applied %d migrations, want %d", len(applied), len(migrator.migrations))
+	}
+	if again, err := migrator.Up(ctx); err != nil || len(again) != 0 {
+		t.Fatalf("second Up applied %d migrations: %v", len(again), err)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			t.Errorf("migration %d_%s is pending", s.Version, s.Name)
+		}
+	}
+
+	// Reverting everything must leave only the bookkeeping table
+	if _, err := migrator.Down(ctx, len(applied)); err != nil {
+		t.Fatal(err)
+	}
+	var tables []string
+	err = conn.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name <> 'schema_migrations'").
+		Scan(&tables).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 0 {
+		t.Errorf("tables left after reverting all migrations: %v", tables)
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(sql string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(sql)}
+	}
+
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		wantErr bool
+	}{
+		{"sorted pairs", fstest.MapFS{
+			"m/0002_b.up.sql":   file("B"),
+			"m/0002_b.down.sql": file("-B"),
+			"m/0001_a.up.sql":   file("A"),
+			"m/0001_a.down.sql": file("-A"),
+		}, false},
+		{"missing down", fstest.MapFS{"m/0001_a.up.sql": file("A")}, true},
+		{"conflicting names", fstest.MapFS{
+			"m/0001_a.up.sql":   file("A"),
+			"m/0001_b.down.sql": file("-B"),
+		}, true},
+		{"unexpected file", fstest.MapFS{"m/README.md": file("")}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files, "m")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", migrations)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(migrations) != 2 || migrations[0].Name != "a" || migrations[1].Up != "B" || migrations[1].Down != "-B" {
+				t.Errorf("got %+v", migrations)
+			}
+		})
+	}
+}
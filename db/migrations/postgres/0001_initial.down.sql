@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS invoice_adjustments;
+DROP TABLE IF EXISTS invoice_items;
+DROP TABLE IF EXISTS invoices;
+DROP TABLE IF EXISTS plans;
+DROP TABLE IF EXISTS bank_accounts;
+DROP TABLE IF EXISTS companies;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,117 @@
+-- Schema of the first release, previously created by GORM AutoMigrate. Every
+-- statement is idempotent so databases created that way can adopt migrations.
+
+CREATE TABLE IF NOT EXISTS users (
+	id bigserial PRIMARY KEY,
+	email text NOT NULL,
+	name text NOT NULL,
+	password text NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS companies (
+	id bigserial PRIMARY KEY,
+	user_id bigint NOT NULL,
+	name text NOT NULL,
+	address text NOT NULL,
+	city text NOT NULL,
+	state text NOT NULL,
+	zip_code text NOT NULL,
+	country text NOT NULL,
+	email text NOT NULL,
+	phone text NOT NULL,
+	website text NOT NULL,
+	logo text,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_companies_user_id ON companies (user_id);
+CREATE INDEX IF NOT EXISTS idx_companies_deleted_at ON companies (deleted_at);
+
+CREATE TABLE IF NOT EXISTS bank_accounts (
+	id bigserial PRIMARY KEY,
+	company_id bigint NOT NULL,
+	bank_name text NOT NULL,
+	account_name text NOT NULL,
+	account_number text NOT NULL,
+	swift_code text,
+	routing_number text,
+	is_default boolean DEFAULT false,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	CONSTRAINT fk_companies_bank_accounts FOREIGN KEY (company_id) REFERENCES companies (id)
+);
+CREATE INDEX IF NOT EXISTS idx_bank_accounts_deleted_at ON bank_accounts (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_bank_accounts_company_id ON bank_accounts (company_id);
+
+CREATE TABLE IF NOT EXISTS plans (
+	id bigserial PRIMARY KEY,
+	user_id bigint NOT NULL,
+	plan_type varchar(20) NOT NULL DEFAULT 'free',
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_plans_deleted_at ON plans (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_plans_user_id ON plans (user_id);
+
+CREATE TABLE IF NOT EXISTS invoices (
+	id bigserial PRIMARY KEY,
+	user_id bigint NOT NULL,
+	invoice_number text NOT NULL,
+	customer_name text NOT NULL,
+	customer_email text NOT NULL,
+	due_date timestamptz,
+	tax_rate decimal NOT NULL DEFAULT 0,
+	status text NOT NULL DEFAULT 'draft',
+	subtotal bigint NOT NULL,
+	tax_amount bigint NOT NULL,
+	adjustments_total bigint NOT NULL,
+	total bigint NOT NULL,
+	bank_account_id bigint,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_invoices_deleted_at ON invoices (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_invoices_bank_account_id ON invoices (bank_account_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_invoice_number ON invoices (invoice_number);
+CREATE INDEX IF NOT EXISTS idx_invoices_user_id ON invoices (user_id);
+
+-- Early databases were created with a required due date
+ALTER TABLE invoices ALTER COLUMN due_date DROP NOT NULL;
+
+CREATE TABLE IF NOT EXISTS invoice_items (
+	id bigserial PRIMARY KEY,
+	invoice_id bigint NOT NULL,
+	name text NOT NULL,
+	description text,
+	quantity bigint NOT NULL,
+	price bigint NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	CONSTRAINT fk_invoices_items FOREIGN KEY (invoice_id) REFERENCES invoices (id)
+);
+CREATE INDEX IF NOT EXISTS idx_invoice_items_deleted_at ON invoice_items (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_invoice_items_invoice_id ON invoice_items (invoice_id);
+
+CREATE TABLE IF NOT EXISTS invoice_adjustments (
+	id bigserial PRIMARY KEY,
+	invoice_id bigint NOT NULL,
+	description text NOT NULL,
+	type text NOT NULL,
+	amount bigint NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	CONSTRAINT fk_invoices_adjustments FOREIGN KEY (invoice_id) REFERENCES invoices (id)
+);
+CREATE INDEX IF NOT EXISTS idx_invoice_adjustments_deleted_at ON invoice_adjustments (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_invoice_adjustments_invoice_id ON invoice_adjustments (invoice_id);
@@ -0,0 +1,17 @@
+-- Restoring the unique indexes fails once a user owns several companies or
+-- plans, or invoice numbers repeat across companies
+DROP INDEX IF EXISTS idx_plans_company_id;
+DROP INDEX IF EXISTS idx_plans_user_id;
+CREATE UNIQUE INDEX idx_plans_user_id ON plans (user_id);
+
+DROP INDEX IF EXISTS idx_invoices_company_number;
+CREATE UNIQUE INDEX idx_invoices_invoice_number ON invoices (invoice_number);
+
+ALTER TABLE plans DROP COLUMN IF EXISTS company_id;
+ALTER TABLE invoices DROP COLUMN IF EXISTS company_id;
+
+DROP INDEX IF EXISTS idx_companies_user_id;
+CREATE UNIQUE INDEX idx_companies_user_id ON companies (user_id);
+
+DROP TABLE IF EXISTS company_invitations;
+DROP TABLE IF EXISTS company_members;
@@ -0,0 +1,62 @@
+CREATE TABLE IF NOT EXISTS company_members (
+	id bigserial PRIMARY KEY,
+	company_id bigint NOT NULL,
+	user_id bigint NOT NULL,
+	role varchar(20) NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	CONSTRAINT fk_company_members_user FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT fk_company_members_company FOREIGN KEY (company_id) REFERENCES companies (id)
+);
+CREATE INDEX IF NOT EXISTS idx_company_members_user_id ON company_members (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_company_members_company_user ON company_members (company_id, user_id);
+
+CREATE TABLE IF NOT EXISTS company_invitations (
+	id bigserial PRIMARY KEY,
+	company_id bigint NOT NULL,
+	email text NOT NULL,
+	role varchar(20) NOT NULL,
+	token_hash text NOT NULL,
+	invited_by_id bigint NOT NULL,
+	expires_at timestamptz NOT NULL,
+	accepted_at timestamptz,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_company_invitations_email ON company_invitations (email);
+CREATE INDEX IF NOT EXISTS idx_company_invitations_company_id ON company_invitations (company_id);
+CREATE INDEX IF NOT EXISTS idx_company_invitations_deleted_at ON company_invitations (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_company_invitations_token_hash ON company_invitations (token_hash);
+
+-- Users may own several companies, plans belong to companies and invoice
+-- numbers are unique per company
+DROP INDEX IF EXISTS idx_companies_user_id;
+CREATE INDEX IF NOT EXISTS idx_companies_user_id ON companies (user_id);
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS company_id bigint;
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS company_id bigint;
+
+-- Existing companies get their creator as owner and existing invoices and
+-- plans are attached to their creator's oldest company
+INSERT INTO company_members (company_id, user_id, role, created_at, updated_at)
+	SELECT c.id, c.user_id, 'owner', NOW(), NOW() FROM companies c
+	WHERE c.deleted_at IS NULL
+	AND NOT EXISTS (SELECT 1 FROM company_members m WHERE m.company_id = c.id AND m.user_id = c.user_id);
+
+UPDATE invoices SET company_id = (
+		SELECT c.id FROM companies c WHERE c.user_id = invoices.user_id AND c.deleted_at IS NULL
+		ORDER BY c.created_at ASC LIMIT 1)
+	WHERE invoices.company_id IS NULL;
+
+UPDATE plans SET company_id = (
+		SELECT c.id FROM companies c WHERE c.user_id = plans.user_id AND c.deleted_at IS NULL
+		ORDER BY c.created_at ASC LIMIT 1)
+	WHERE plans.company_id IS NULL;
+
+DROP INDEX IF EXISTS idx_invoices_invoice_number;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_company_number ON invoices (company_id, invoice_number);
+
+DROP INDEX IF EXISTS idx_plans_user_id;
+CREATE INDEX IF NOT EXISTS idx_plans_user_id ON plans (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_plans_company_id ON plans (company_id);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+	id bigserial PRIMARY KEY,
+	user_id bigint NOT NULL,
+	company_id bigint,
+	name text NOT NULL,
+	prefix text NOT NULL,
+	key_hash text NOT NULL,
+	scopes text NOT NULL,
+	expires_at timestamptz,
+	last_used_at timestamptz,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_deleted_at ON api_keys (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys (prefix);
+CREATE INDEX IF NOT EXISTS idx_api_keys_company_id ON api_keys (company_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
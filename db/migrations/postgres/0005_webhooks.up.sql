@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+	id bigserial PRIMARY KEY,
+	company_id bigint NOT NULL,
+	url text NOT NULL,
+	secret text NOT NULL,
+	events text NOT NULL,
+	enabled boolean NOT NULL DEFAULT true,
+	failure_count bigint NOT NULL DEFAULT 0,
+	disabled_at timestamptz,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_company_id ON webhook_endpoints (company_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_deleted_at ON webhook_endpoints (deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id bigserial PRIMARY KEY,
+	endpoint_id bigint NOT NULL,
+	event_id text NOT NULL,
+	event varchar(50) NOT NULL,
+	payload text NOT NULL,
+	status varchar(20) NOT NULL DEFAULT 'pending',
+	attempts bigint NOT NULL DEFAULT 0,
+	next_attempt_at timestamptz NOT NULL,
+	last_attempt_at timestamptz,
+	response_status bigint,
+	response_body text,
+	last_error text,
+	created_at timestamptz,
+	updated_at timestamptz,
+	CONSTRAINT fk_webhook_deliveries_endpoint FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints (id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries (event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint_id ON webhook_deliveries (endpoint_id);
@@ -0,0 +1,6 @@
+ALTER TABLE companies DROP CONSTRAINT IF EXISTS fk_companies_logo_thumb_asset;
+ALTER TABLE companies DROP CONSTRAINT IF EXISTS fk_companies_logo_asset;
+ALTER TABLE companies DROP COLUMN IF EXISTS logo_thumb_asset_id;
+ALTER TABLE companies DROP COLUMN IF EXISTS logo_asset_id;
+
+DROP TABLE IF EXISTS assets;
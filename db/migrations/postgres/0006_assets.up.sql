@@ -0,0 +1,34 @@
+-- Logos are stored as assets. The legacy companies.logo column is moved into
+-- assets and dropped by the server, which needs the blob store to do so.
+CREATE TABLE IF NOT EXISTS assets (
+	id bigserial PRIMARY KEY,
+	company_id bigint NOT NULL,
+	user_id bigint NOT NULL,
+	key text NOT NULL,
+	url text NOT NULL,
+	content_type text NOT NULL,
+	size bigint NOT NULL DEFAULT 0,
+	checksum text NOT NULL DEFAULT '',
+	created_at timestamptz,
+	updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_assets_key ON assets (key);
+CREATE INDEX IF NOT EXISTS idx_assets_user_id ON assets (user_id);
+CREATE INDEX IF NOT EXISTS idx_assets_company_id ON assets (company_id);
+
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS logo_asset_id bigint;
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS logo_thumb_asset_id bigint;
+CREATE INDEX IF NOT EXISTS idx_companies_logo_asset_id ON companies (logo_asset_id);
+CREATE INDEX IF NOT EXISTS idx_companies_logo_thumb_asset_id ON companies (logo_thumb_asset_id);
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_companies_logo_asset') THEN
+		ALTER TABLE companies ADD CONSTRAINT fk_companies_logo_asset
+			FOREIGN KEY (logo_asset_id) REFERENCES assets (id);
+	END IF;
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_companies_logo_thumb_asset') THEN
+		ALTER TABLE companies ADD CONSTRAINT fk_companies_logo_thumb_asset
+			FOREIGN KEY (logo_thumb_asset_id) REFERENCES assets (id);
+	END IF;
+END $$;
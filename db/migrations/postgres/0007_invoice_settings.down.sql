@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS invoice_settings;
+
+ALTER TABLE companies DROP COLUMN IF EXISTS tax_id;
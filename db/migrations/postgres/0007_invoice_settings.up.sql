@@ -0,0 +1,11 @@
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS tax_id text NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS invoice_settings (
+	id bigserial PRIMARY KEY,
+	company_id bigint NOT NULL,
+	default_template text NOT NULL,
+	templates text NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoice_settings_company_id ON invoice_settings (company_id);
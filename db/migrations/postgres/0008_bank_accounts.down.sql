@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_bank_accounts_company_default;
+
+ALTER TABLE invoices DROP COLUMN IF EXISTS bank_details;
+ALTER TABLE bank_accounts DROP COLUMN IF EXISTS position;
+ALTER TABLE bank_accounts DROP COLUMN IF EXISTS bank_code;
+ALTER TABLE bank_accounts DROP COLUMN IF EXISTS country;
@@ -0,0 +1,36 @@
+ALTER TABLE bank_accounts ADD COLUMN IF NOT EXISTS country text NOT NULL DEFAULT '';
+ALTER TABLE bank_accounts ADD COLUMN IF NOT EXISTS bank_code text;
+ALTER TABLE bank_accounts ADD COLUMN IF NOT EXISTS position bigint NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS bank_details text;
+
+-- Invoices used to reference bank accounts without checking the owner and
+-- without keeping a copy of the details: drop references to other
+-- companies' accounts, then snapshot the remaining ones
+UPDATE invoices SET bank_account_id = NULL
+	WHERE bank_account_id IS NOT NULL AND NOT EXISTS (
+		SELECT 1 FROM bank_accounts b WHERE b.id = invoices.bank_account_id AND b.company_id = invoices.company_id);
+
+UPDATE invoices SET bank_details = json_build_object(
+		'bank_name', b.bank_name, 'account_name', b.account_name, 'account_number', b.account_number,
+		'swift_code', b.swift_code, 'routing_number', b.routing_number)::text
+	FROM bank_accounts b
+	WHERE invoices.bank_details IS NULL AND b.id = invoices.bank_account_id;
+
+-- Default bank accounts used to be changed without a transaction, which
+-- could leave several defaults per company. Keep the oldest one, then let a
+-- partial unique index guarantee at most one.
+UPDATE bank_accounts SET is_default = false
+	WHERE is_default AND (deleted_at IS NOT NULL OR id <> (
+		SELECT MIN(b.id) FROM bank_accounts b
+		WHERE b.company_id = bank_accounts.company_id AND b.is_default AND b.deleted_at IS NULL));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bank_accounts_company_default
+	ON bank_accounts (company_id) WHERE is_default AND deleted_at IS NULL;
+
+-- Number the accounts of companies that were never ordered by creation date
+UPDATE bank_accounts SET position = ordered.position
+	FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY company_id ORDER BY created_at, id) - 1 AS position
+		FROM bank_accounts WHERE deleted_at IS NULL) ordered
+	WHERE bank_accounts.id = ordered.id AND bank_accounts.company_id IN (
+		SELECT company_id FROM bank_accounts WHERE deleted_at IS NULL AND position = 0
+		GROUP BY company_id HAVING COUNT(*) > 1);
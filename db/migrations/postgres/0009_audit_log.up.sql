@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS audit_entries (
+	id bigserial PRIMARY KEY,
+	company_id bigint NOT NULL,
+	user_id bigint NOT NULL,
+	api_key_id bigint,
+	ip_address text NOT NULL,
+	request_id text,
+	entity_type varchar(30) NOT NULL,
+	entity_id bigint NOT NULL,
+	action varchar(10) NOT NULL,
+	changes text NOT NULL,
+	created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_company_id ON audit_entries (company_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_user_id ON audit_entries (user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_entity ON audit_entries (entity_type, entity_id);
+
+-- The audit log is append only
+CREATE OR REPLACE FUNCTION audit_entries_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit entries are append only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_entries_append_only ON audit_entries;
+CREATE TRIGGER audit_entries_append_only
+	BEFORE UPDATE OR DELETE ON audit_entries
+	FOR EACH ROW EXECUTE FUNCTION audit_entries_append_only();
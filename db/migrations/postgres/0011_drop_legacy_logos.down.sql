@@ -0,0 +1,2 @@
+-- The logos themselves stay in assets
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS logo text;
@@ -0,0 +1,19 @@
+-- Logos used to be stored as URLs or base64 data in companies.logo, and in
+-- companies.logo_thumb on databases created before migrations were versioned.
+-- They are moved into assets before the columns are dropped, by the server
+-- right before this migration or by the "logos migrate" command when the
+-- server has no storage configured.
+DO $$
+BEGIN
+	IF EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'companies' AND column_name = 'logo'
+	) THEN
+		IF EXISTS (SELECT 1 FROM companies WHERE logo IS NOT NULL AND logo <> '') THEN
+			RAISE EXCEPTION 'companies.logo still holds legacy logos, configure storage or run the "logos migrate" command first';
+		END IF;
+	END IF;
+END $$;
+
+ALTER TABLE companies DROP COLUMN IF EXISTS logo_thumb;
+ALTER TABLE companies DROP COLUMN IF EXISTS logo;
@@ -0,0 +1,7 @@
+DROP TABLE invoice_adjustments;
+DROP TABLE invoice_items;
+DROP TABLE invoices;
+DROP TABLE plans;
+DROP TABLE bank_accounts;
+DROP TABLE companies;
+DROP TABLE users;
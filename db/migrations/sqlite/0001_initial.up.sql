@@ -0,0 +1,114 @@
+-- SQLite counterparts of the Postgres migrations, for local development and
+-- tests. SQLite databases are always created from scratch, so unlike their
+-- Postgres versions these carry no backfills of legacy data.
+
+CREATE TABLE users (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	email text NOT NULL,
+	name text NOT NULL,
+	password text NOT NULL,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime
+);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+CREATE UNIQUE INDEX idx_users_email ON users (email);
+
+CREATE TABLE companies (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	user_id integer NOT NULL,
+	name text NOT NULL,
+	address text NOT NULL,
+	city text NOT NULL,
+	state text NOT NULL,
+	zip_code text NOT NULL,
+	country text NOT NULL,
+	email text NOT NULL,
+	phone text NOT NULL,
+	website text NOT NULL,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime
+);
+CREATE UNIQUE INDEX idx_companies_user_id ON companies (user_id);
+CREATE INDEX idx_companies_deleted_at ON companies (deleted_at);
+
+CREATE TABLE bank_accounts (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	company_id integer NOT NULL,
+	bank_name text NOT NULL,
+	account_name text NOT NULL,
+	account_number text NOT NULL,
+	swift_code text,
+	routing_number text,
+	is_default numeric DEFAULT false,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime,
+	CONSTRAINT fk_companies_bank_accounts FOREIGN KEY (company_id) REFERENCES companies (id)
+);
+CREATE INDEX idx_bank_accounts_deleted_at ON bank_accounts (deleted_at);
+CREATE INDEX idx_bank_accounts_company_id ON bank_accounts (company_id);
+
+CREATE TABLE plans (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	user_id integer NOT NULL,
+	plan_type varchar(20) NOT NULL DEFAULT 'free',
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime
+);
+CREATE INDEX idx_plans_deleted_at ON plans (deleted_at);
+CREATE UNIQUE INDEX idx_plans_user_id ON plans (user_id);
+
+CREATE TABLE invoices (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	user_id integer NOT NULL,
+	invoice_number text NOT NULL,
+	customer_name text NOT NULL,
+	customer_email text NOT NULL,
+	due_date datetime,
+	tax_rate real NOT NULL DEFAULT 0,
+	status text NOT NULL DEFAULT 'draft',
+	subtotal integer NOT NULL,
+	tax_amount integer NOT NULL,
+	adjustments_total integer NOT NULL,
+	total integer NOT NULL,
+	bank_account_id integer,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime
+);
+CREATE INDEX idx_invoices_deleted_at ON invoices (deleted_at);
+CREATE INDEX idx_invoices_bank_account_id ON invoices (bank_account_id);
+CREATE UNIQUE INDEX idx_invoices_invoice_number ON invoices (invoice_number);
+CREATE INDEX idx_invoices_user_id ON invoices (user_id);
+
+CREATE TABLE invoice_items (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	invoice_id integer NOT NULL,
+	name text NOT NULL,
+	description text,
+	quantity integer NOT NULL,
+	price integer NOT NULL,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime,
+	CONSTRAINT fk_invoices_items FOREIGN KEY (invoice_id) REFERENCES invoices (id)
+);
+CREATE INDEX idx_invoice_items_deleted_at ON invoice_items (deleted_at);
+CREATE INDEX idx_invoice_items_invoice_id ON invoice_items (invoice_id);
+
+CREATE TABLE invoice_adjustments (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	invoice_id integer NOT NULL,
+	description text NOT NULL,
+	type text NOT NULL,
+	amount integer NOT NULL,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime,
+	CONSTRAINT fk_invoices_adjustments FOREIGN KEY (invoice_id) REFERENCES invoices (id)
+);
+CREATE INDEX idx_invoice_adjustments_deleted_at ON invoice_adjustments (deleted_at);
+CREATE INDEX idx_invoice_adjustments_invoice_id ON invoice_adjustments (invoice_id);
@@ -0,0 +1,15 @@
+DROP INDEX idx_plans_company_id;
+DROP INDEX idx_plans_user_id;
+CREATE UNIQUE INDEX idx_plans_user_id ON plans (user_id);
+
+DROP INDEX idx_invoices_company_number;
+CREATE UNIQUE INDEX idx_invoices_invoice_number ON invoices (invoice_number);
+
+ALTER TABLE plans DROP COLUMN company_id;
+ALTER TABLE invoices DROP COLUMN company_id;
+
+DROP INDEX idx_companies_user_id;
+CREATE UNIQUE INDEX idx_companies_user_id ON companies (user_id);
+
+DROP TABLE company_invitations;
+DROP TABLE company_members;
@@ -0,0 +1,45 @@
+CREATE TABLE company_members (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	company_id integer NOT NULL,
+	user_id integer NOT NULL,
+	role varchar(20) NOT NULL,
+	created_at datetime,
+	updated_at datetime,
+	CONSTRAINT fk_company_members_user FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT fk_company_members_company FOREIGN KEY (company_id) REFERENCES companies (id)
+);
+CREATE INDEX idx_company_members_user_id ON company_members (user_id);
+CREATE UNIQUE INDEX idx_company_members_company_user ON company_members (company_id, user_id);
+
+CREATE TABLE company_invitations (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	company_id integer NOT NULL,
+	email text NOT NULL,
+	role varchar(20) NOT NULL,
+	token_hash text NOT NULL,
+	invited_by_id integer NOT NULL,
+	expires_at datetime NOT NULL,
+	accepted_at datetime,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime
+);
+CREATE INDEX idx_company_invitations_email ON company_invitations (email);
+CREATE INDEX idx_company_invitations_company_id ON company_invitations (company_id);
+CREATE INDEX idx_company_invitations_deleted_at ON company_invitations (deleted_at);
+CREATE UNIQUE INDEX idx_company_invitations_token_hash ON company_invitations (token_hash);
+
+-- Users may own several companies, plans belong to companies and invoice
+-- numbers are unique per company
+DROP INDEX idx_companies_user_id;
+CREATE INDEX idx_companies_user_id ON companies (user_id);
+
+ALTER TABLE invoices ADD COLUMN company_id integer;
+ALTER TABLE plans ADD COLUMN company_id integer;
+
+DROP INDEX idx_invoices_invoice_number;
+CREATE UNIQUE INDEX idx_invoices_company_number ON invoices (company_id, invoice_number);
+
+DROP INDEX idx_plans_user_id;
+CREATE INDEX idx_plans_user_id ON plans (user_id);
+CREATE UNIQUE INDEX idx_plans_company_id ON plans (company_id);
@@ -0,0 +1,18 @@
+CREATE TABLE api_keys (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	user_id integer NOT NULL,
+	company_id integer,
+	name text NOT NULL,
+	prefix text NOT NULL,
+	key_hash text NOT NULL,
+	scopes text NOT NULL,
+	expires_at datetime,
+	last_used_at datetime,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime
+);
+CREATE INDEX idx_api_keys_deleted_at ON api_keys (deleted_at);
+CREATE UNIQUE INDEX idx_api_keys_prefix ON api_keys (prefix);
+CREATE INDEX idx_api_keys_company_id ON api_keys (company_id);
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
@@ -0,0 +1,36 @@
+CREATE TABLE webhook_endpoints (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	company_id integer NOT NULL,
+	url text NOT NULL,
+	secret text NOT NULL,
+	events text NOT NULL,
+	enabled numeric NOT NULL DEFAULT true,
+	failure_count integer NOT NULL DEFAULT 0,
+	disabled_at datetime,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime
+);
+CREATE INDEX idx_webhook_endpoints_company_id ON webhook_endpoints (company_id);
+CREATE INDEX idx_webhook_endpoints_deleted_at ON webhook_endpoints (deleted_at);
+
+CREATE TABLE webhook_deliveries (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	endpoint_id integer NOT NULL,
+	event_id text NOT NULL,
+	event varchar(50) NOT NULL,
+	payload text NOT NULL,
+	status varchar(20) NOT NULL DEFAULT 'pending',
+	attempts integer NOT NULL DEFAULT 0,
+	next_attempt_at datetime NOT NULL,
+	last_attempt_at datetime,
+	response_status integer,
+	response_body text,
+	last_error text,
+	created_at datetime,
+	updated_at datetime,
+	CONSTRAINT fk_webhook_deliveries_endpoint FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints (id)
+);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX idx_webhook_deliveries_event_id ON webhook_deliveries (event_id);
+CREATE INDEX idx_webhook_deliveries_endpoint_id ON webhook_deliveries (endpoint_id);
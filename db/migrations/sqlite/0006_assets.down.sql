@@ -0,0 +1,6 @@
+DROP INDEX idx_companies_logo_thumb_asset_id;
+DROP INDEX idx_companies_logo_asset_id;
+ALTER TABLE companies DROP COLUMN logo_thumb_asset_id;
+ALTER TABLE companies DROP COLUMN logo_asset_id;
+
+DROP TABLE assets;
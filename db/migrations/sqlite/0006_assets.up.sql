@@ -0,0 +1,22 @@
+CREATE TABLE assets (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	company_id integer NOT NULL,
+	user_id integer NOT NULL,
+	key text NOT NULL,
+	url text NOT NULL,
+	content_type text NOT NULL,
+	size integer NOT NULL DEFAULT 0,
+	checksum text NOT NULL DEFAULT '',
+	created_at datetime,
+	updated_at datetime
+);
+CREATE UNIQUE INDEX idx_assets_key ON assets (key);
+CREATE INDEX idx_assets_user_id ON assets (user_id);
+CREATE INDEX idx_assets_company_id ON assets (company_id);
+
+-- SQLite cannot drop columns used by a foreign key, so unlike Postgres the
+-- logo columns are not constrained
+ALTER TABLE companies ADD COLUMN logo_asset_id integer;
+ALTER TABLE companies ADD COLUMN logo_thumb_asset_id integer;
+CREATE INDEX idx_companies_logo_asset_id ON companies (logo_asset_id);
+CREATE INDEX idx_companies_logo_thumb_asset_id ON companies (logo_thumb_asset_id);
@@ -0,0 +1,3 @@
+DROP TABLE invoice_settings;
+
+ALTER TABLE companies DROP COLUMN tax_id;
@@ -0,0 +1,11 @@
+ALTER TABLE companies ADD COLUMN tax_id text NOT NULL DEFAULT '';
+
+CREATE TABLE invoice_settings (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	company_id integer NOT NULL,
+	default_template text NOT NULL,
+	templates text NOT NULL,
+	created_at datetime,
+	updated_at datetime
+);
+CREATE UNIQUE INDEX idx_invoice_settings_company_id ON invoice_settings (company_id);
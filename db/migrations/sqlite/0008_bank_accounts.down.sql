@@ -0,0 +1,6 @@
+DROP INDEX idx_bank_accounts_company_default;
+
+ALTER TABLE invoices DROP COLUMN bank_details;
+ALTER TABLE bank_accounts DROP COLUMN position;
+ALTER TABLE bank_accounts DROP COLUMN bank_code;
+ALTER TABLE bank_accounts DROP COLUMN country;
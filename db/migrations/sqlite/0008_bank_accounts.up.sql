@@ -0,0 +1,7 @@
+ALTER TABLE bank_accounts ADD COLUMN country text NOT NULL DEFAULT '';
+ALTER TABLE bank_accounts ADD COLUMN bank_code text;
+ALTER TABLE bank_accounts ADD COLUMN position integer NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN bank_details text;
+
+CREATE UNIQUE INDEX idx_bank_accounts_company_default
+	ON bank_accounts (company_id) WHERE is_default AND deleted_at IS NULL;
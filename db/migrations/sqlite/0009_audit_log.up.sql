@@ -0,0 +1,26 @@
+CREATE TABLE audit_entries (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	company_id integer NOT NULL,
+	user_id integer NOT NULL,
+	api_key_id integer,
+	ip_address text NOT NULL,
+	request_id text,
+	entity_type varchar(30) NOT NULL,
+	entity_id integer NOT NULL,
+	action varchar(10) NOT NULL,
+	changes text NOT NULL,
+	created_at datetime
+);
+CREATE INDEX idx_audit_entries_company_id ON audit_entries (company_id);
+CREATE INDEX idx_audit_entries_user_id ON audit_entries (user_id);
+CREATE INDEX idx_audit_entries_entity ON audit_entries (entity_type, entity_id);
+
+-- The audit log is append only
+CREATE TRIGGER audit_entries_no_update BEFORE UPDATE ON audit_entries
+BEGIN
+	SELECT RAISE(ABORT, 'audit entries are append only');
+END;
+CREATE TRIGGER audit_entries_no_delete BEFORE DELETE ON audit_entries
+BEGIN
+	SELECT RAISE(ABORT, 'audit entries are append only');
+END;
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN two_factor_attempted_at;
+ALTER TABLE users DROP COLUMN two_factor_attempts;
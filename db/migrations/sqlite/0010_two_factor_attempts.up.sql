@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN two_factor_attempts integer NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN two_factor_attempted_at datetime;
@@ -0,0 +1,2 @@
+-- SQLite databases never had the legacy logo columns
+SELECT 1;
@@ -1,23 +1,72 @@
 package db
 
 import (
-	"log"
-	"os"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/notblessy/bikinota-core/config"
+	"github.com/sirupsen/logrus"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func NewPostgres() *gorm.DB {
-	dsn := os.Getenv("DATABASE_URL")
+// maxConnectBackoff caps the wait between connection attempts at startup
+const maxConnectBackoff = 30 * time.Second
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		PrepareStmt: false,
-	})
+// openPostgres connects to a Postgres server, retrying with exponential
+// backoff while it is unreachable, and configures the connection pool
+func openPostgres(ctx context.Context, dsn string, cfg config.DatabaseConfig) (*gorm.DB, error) {
+	connConfig, err := pgx.ParseConfig(dsn)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("invalid database url: %w", err)
 	}
 
-	return db
-}
+	// Applied by the server to every session, so runaway queries are
+	// cancelled even when the request context is not
+	if cfg.StatementTimeout > 0 {
+		connConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+
+	sqlDB := stdlib.OpenDB(*connConfig)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	backoff := cfg.ConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err := sqlDB.PingContext(ctx)
+		if err == nil {
+			break
+		}
+		if attempt >= cfg.ConnectAttempts {
+			sqlDB.Close()
+			return nil, fmt.Errorf("database %s unreachable after %d attempts: %w", connConfig.Host, attempt, err)
+		}
+
+		logrus.Warnf("Database %s unreachable (attempt %d of %d), retrying in %s: %v", connConfig.Host, attempt, cfg.ConnectAttempts, backoff, err)
+		select {
+		case <-ctx.Done():
+			sqlDB.Close()
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxConnectBackoff)
+	}
 
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), gormConfig(cfg))
+	if err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	if err := registerTracing(db); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
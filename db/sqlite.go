@@ -0,0 +1,41 @@
+package db
+
+import (
+	"github.com/glebarez/sqlite"
+	"github.com/notblessy/bikinota-core/config"
+	"gorm.io/gorm"
+)
+
+// openSQLite opens an embedded SQLite database, dsn being a file path or
+// :memory:. SQLite allows a single writer, so the pool holds one connection
+// that is never recycled, which also keeps in-memory databases alive.
+func openSQLite(dsn string, cfg config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(dsn), gormConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+	sqlDB.SetConnMaxIdleTime(0)
+
+	// Enforce foreign keys like Postgres, and wait for locks held by other
+	// processes instead of failing
+	for _, pragma := range []string{"PRAGMA foreign_keys = ON", "PRAGMA busy_timeout = 5000"} {
+		if err := db.Exec(pragma).Error; err != nil {
+			sqlDB.Close()
+			return nil, err
+		}
+	}
+	if err := registerTracing(db); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/notblessy/bikinota-core/config"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQuerySpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+
+	conn, err := Open(context.Background(), config.DatabaseConfig{Driver: DriverSQLite, URL: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Untraced queries get no span
+	var n int
+	if err := conn.Raw("SELECT 1").Scan(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	if spans := recorder.Ended(); len(spans) != 0 {
+		t.Fatalf("%d spans outside of a trace", len(spans))
+	}
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	if err := conn.WithContext(ctx).Raw("SELECT 1").Scan(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans, want the query's and the request's", len(spans))
+	}
+	query := spans[0]
+	if query.Name() != "db.row" || query.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span %q has parent %s, want db.row under the request", query.Name(), query.Parent().SpanID())
+	}
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+type apiKeyHandler struct {
+	apiKeyRepo repository.APIKeyRepository
+	validate   *validator.Validate
+}
+
+func NewAPIKeyHandler(apiKeyRepo repository.APIKeyRepository) *apiKeyHandler {
+	return &apiKeyHandler{
+		apiKeyRepo: apiKeyRepo,
+		validate:   newValidator(),
+	}
+}
+
+// ListAPIKeys lists the API keys created by the authenticated user
+func (h *apiKeyHandler) ListAPIKeys(c echo.Context) error {
+	// Get user from JWT middleware
+	userClaims, err := authSession(c)
+	if err != nil {
+		return errUnauthorized
+	}
+
+	apiKeys, err := h.apiKeyRepo.ListByUserID(c.Request().Context(), userClaims.ID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve api keys: %w", err)
+	}
+
+	apiKeyResponses := make([]model.APIKeyResponse, len(apiKeys))
+	for i, k := range apiKeys {
+		apiKeyResponses[i] = k.ToAPIKeyResponse()
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    apiKeyResponses,
+	})
+}
+
+// CreateAPIKey creates a personal API key, or a company API key bound to the
+// active company. The plaintext key is returned only once.
+func (h *apiKeyHandler) CreateAPIKey(c echo.Context) error {
+	logger := requestLogger(c).WithField("endpoint", "create_api_key")
+
+	// Get user from JWT middleware
+	userClaims, err := authSession(c)
+	if err != nil {
+		return errUnauthorized
+	}
+
+	var req model.CreateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return invalidRequest(c)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		logger.Errorf("Validation error: %v", err)
+		return validationFailed(c, err)
+	}
+
+	// Validate scopes
+	for i, scope := range req.Scopes {
+		if !isAPIKeyScope(scope) {
+			return invalidFields(c, newFieldError(c, fmt.Sprintf("/scopes/%d", i), "oneof", joinPermissions(model.APIKeyScopes)))
+		}
+	}
+
+	apiKey := &model.APIKey{
+		UserID: userClaims.ID,
+		Name:   req.Name,
+		Scopes: req.Scopes,
+	}
+
+	if req.ExpiresInDays != nil {
+		if *req.ExpiresInDays < 1 {
+			return invalidFields(c, newFieldError(c, "/expires_in_days", "min", "1"))
+		}
+		expiresAt := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	// Company keys need someone allowed to manage the company's access
+	if req.Company {
+		member, err := activeMembership(c)
+		if err != nil {
+			return err
+		}
+		if member == nil {
+			return repository.NotFound("company not found")
+		}
+
+		if !can(c, member, model.PermissionMemberManage) {
+			return errNoPermission
+		}
+
+		apiKey.CompanyID = &member.CompanyID
+	}
+
+	prefix, key, err := generateAPIKey()
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	apiKey.Prefix = prefix
+
+	if err := h.apiKeyRepo.Create(c.Request().Context(), apiKey, key); err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	apiKeyResponse := apiKey.ToAPIKeyResponse()
+	apiKeyResponse.Key = key
+
+	return c.JSON(http.StatusCreated, response{
+		Success: true,
+		Data:    apiKeyResponse,
+	})
+}
+
+// RevokeAPIKey revokes an API key of the authenticated user
+func (h *apiKeyHandler) RevokeAPIKey(c echo.Context) error {
+	// Get user from JWT middleware
+	userClaims, err := authSession(c)
+	if err != nil {
+		return errUnauthorized
+	}
+
+	apiKeyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: "invalid api key id",
+		})
+	}
+
+	if err := h.apiKeyRepo.Revoke(c.Request().Context(), uint(apiKeyID), userClaims.ID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Message: "api key revoked successfully",
+	})
+}
+
+func isAPIKeyScope(scope model.Permission) bool {
+	for _, s := range model.APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// joinPermissions lists permissions separated by spaces, as in oneof messages
+func joinPermissions(permissions []model.Permission) string {
+	names := make([]string, len(permissions))
+	for i, p := range permissions {
+		names[i] = string(p)
+	}
+	return strings.Join(names, " ")
+}
+
+// generateAPIKey returns the lookup prefix and the full bk_<prefix>_<secret> key
+func generateAPIKey() (string, string, error) {
+	prefixBytes := make([]byte, 6)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", err
+	}
+
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", err
+	}
+
+	prefix := hex.EncodeToString(prefixBytes)
+	return prefix, apiKeyPrefix + prefix + "_" + hex.EncodeToString(secretBytes), nil
+}
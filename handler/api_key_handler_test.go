@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository/repositorytest"
+)
+
+func TestParseAPIKey(t *testing.T) {
+	tests := []struct {
+		key        string
+		wantPrefix string
+		wantOK     bool
+	}{
+		{"bk_a1b2c3_secret", "a1b2c3", true},
+		{"bk_a1b2c3_secret_with_underscores", "a1b2c3", true},
+		{"bk_a1b2c3", "", false},
+		{"bk_a1b2c3_", "", false},
+		{"bk__secret", "", false},
+		{"xx_a1b2c3_secret", "", false},
+		{"eyJhbGciOiJIUzI1NiJ9.e30.sig", "", false},
+	}
+
+	for _, tt := range tests {
+		prefix, ok := parseAPIKey(tt.key)
+		if prefix != tt.wantPrefix || ok != tt.wantOK {
+			t.Errorf("parseAPIKey(%q) = %q, %v, want %q, %v", tt.key, prefix, ok, tt.wantPrefix, tt.wantOK)
+		}
+	}
+}
+
+// apiKeyFixture is a user with a fake API key store behind the middleware
+type apiKeyFixture struct {
+	handler    *apiKeyHandler
+	middleware *JWTMiddleware
+	apiKeys    *repositorytest.APIKeyRepository
+	user       *model.User
+}
+
+func newAPIKeyFixture(t *testing.T) apiKeyFixture {
+	t.Helper()
+
+	users := repositorytest.NewUserRepository()
+	f := apiKeyFixture{
+		apiKeys: repositorytest.NewAPIKeyRepository(),
+		user:    &model.User{Email: "user@example.com", Name: "User", Password: "secret123"},
+	}
+	if err := users.Create(context.Background(), f.user); err != nil {
+		t.Fatal(err)
+	}
+	f.handler = NewAPIKeyHandler(f.apiKeys)
+	f.middleware = NewJWTMiddleware(f.apiKeys, users, testTokens)
+	return f
+}
+
+// create creates an API key through the handler and returns it
+func (f apiKeyFixture) create(t *testing.T, body string) model.APIKeyResponse {
+	t.Helper()
+	status, resp := serve(t, f.handler.CreateAPIKey, testRequest{method: http.MethodPost, body: body, user: f.user.ID})
+	if status != http.StatusCreated {
+		t.Fatalf("create status = %d: %+v", status, resp)
+	}
+	var created model.APIKeyResponse
+	decodeData(t, resp, &created)
+	return created
+}
+
+// authenticate runs the middleware with the given header and returns the
+// status and the principal seen by the next handler
+func (f apiKeyFixture) authenticate(header, value string) (int, principal) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(header, value)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	var p principal
+	h := f.middleware.ValidateJWT(func(c echo.Context) error {
+		p, _ = authPrincipal(c)
+		return c.NoContent(http.StatusOK)
+	})
+	if err := h(c); err != nil {
+		HTTPErrorHandler(err, c)
+	}
+	return rec.Code, p
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	f := newAPIKeyFixture(t)
+	created := f.create(t, `{"name":"CI","scopes":["invoice:read"]}`)
+
+	status, p := f.authenticate(HeaderAPIKey, created.Key)
+	if status != http.StatusOK {
+		t.Fatalf("valid key: status = %d, want %d", status, http.StatusOK)
+	}
+	if !p.IsAPIKey() || p.UserID != f.user.ID || !p.HasScope(model.PermissionInvoiceRead) || p.HasScope(model.PermissionInvoiceWrite) {
+		t.Errorf("principal = %+v, want the user's key scoped to invoice:read", p)
+	}
+
+	if status, _ := f.authenticate("Authorization", "Bearer "+created.Key); status != http.StatusOK {
+		t.Errorf("key as bearer token: status = %d, want %d", status, http.StatusOK)
+	}
+
+	// The stored hash must match the whole key, not just the prefix
+	wrongSecret := "bk_" + created.Prefix + "_0000"
+	malformed := "bk_" + created.Prefix
+	for _, key := range []string{wrongSecret, malformed, "bk_unknown_secret"} {
+		if status, _ := f.authenticate(HeaderAPIKey, key); status != http.StatusUnauthorized {
+			t.Errorf("key %q: status = %d, want %d", key, status, http.StatusUnauthorized)
+		}
+	}
+
+	expired := time.Now().Add(-time.Minute)
+	if err := f.apiKeys.Create(context.Background(), &model.APIKey{
+		UserID:    f.user.ID,
+		Name:      "Expired",
+		Prefix:    "expired",
+		Scopes:    []model.Permission{model.PermissionInvoiceRead},
+		ExpiresAt: &expired,
+	}, "bk_expired_secret"); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := f.authenticate(HeaderAPIKey, "bk_expired_secret"); status != http.StatusUnauthorized {
+		t.Errorf("expired key: status = %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	status, resp := serve(t, f.handler.RevokeAPIKey, testRequest{method: http.MethodDelete, id: created.ID, user: f.user.ID})
+	if status != http.StatusOK {
+		t.Fatalf("revoke status = %d: %+v", status, resp)
+	}
+	if status, _ := f.authenticate(HeaderAPIKey, created.Key); status != http.StatusUnauthorized {
+		t.Errorf("revoked key: status = %d, want %d", status, http.StatusUnauthorized)
+	}
+}
+
+func TestCreateAPIKeyValidation(t *testing.T) {
+	f := newAPIKeyFixture(t)
+
+	tests := []struct {
+		name       string
+		body       string
+		member     *testMember
+		wantStatus int
+	}{
+		{"unknown scope", `{"name":"CI","scopes":["member:manage"]}`, nil, http.StatusBadRequest},
+		{"no scopes", `{"name":"CI","scopes":[]}`, nil, http.StatusBadRequest},
+		{"expiry in the past", `{"name":"CI","scopes":["invoice:read"],"expires_in_days":0}`, nil, http.StatusBadRequest},
+		{"company key by accountant", `{"name":"CI","scopes":["invoice:read"],"company":true}`, &testMember{companyID: 1, role: model.RoleAccountant}, http.StatusForbidden},
+		{"company key by admin", `{"name":"CI","scopes":["invoice:read"],"company":true}`, &testMember{companyID: 1, role: model.RoleAdmin}, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := serve(t, f.handler.CreateAPIKey, testRequest{method: http.MethodPost, body: tt.body, user: f.user.ID, member: tt.member})
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %+v", status, tt.wantStatus, resp)
+			}
+		})
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	f := newInvoiceFixture(t)
+	owner := &testMember{companyID: 1, role: model.RoleOwner}
+	readOnly := &model.APIKey{ID: 1, UserID: 1, Scopes: []model.Permission{model.PermissionInvoiceRead}}
+	invoice := f.createInvoice(t, 1)
+	id := strconv.FormatUint(uint64(invoice.ID), 10)
+
+	// A key never does more than its scopes, even for the company owner
+	if status, resp := serve(t, f.handler.CreateInvoice, testRequest{method: http.MethodPost, body: testInvoiceBody, user: 1, member: owner, apiKey: readOnly}); status != http.StatusForbidden {
+		t.Errorf("create with read-only key: status = %d, want %d: %+v", status, http.StatusForbidden, resp)
+	}
+	if status, resp := serve(t, f.handler.GetInvoice, testRequest{id: id, user: 1, member: owner, apiKey: readOnly}); status != http.StatusOK {
+		t.Errorf("get with read-only key: status = %d, want %d: %+v", status, http.StatusOK, resp)
+	}
+
+	// Nor more than the role of its creator
+	viewer := &testMember{companyID: 1, role: model.RoleViewer}
+	writer := &model.APIKey{ID: 2, UserID: 1, Scopes: []model.Permission{model.PermissionInvoiceRead, model.PermissionInvoiceDelete}}
+	if status, resp := serve(t, f.handler.DeleteInvoice, testRequest{method: http.MethodDelete, id: id, user: 1, member: viewer, apiKey: writer}); status != http.StatusForbidden {
+		t.Errorf("delete as viewer: status = %d, want %d: %+v", status, http.StatusForbidden, resp)
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	next := func(c echo.Context) error {
+		return c.JSON(http.StatusOK, response{Success: true})
+	}
+
+	tests := []struct {
+		name       string
+		apiKey     *model.APIKey
+		wantStatus int
+	}{
+		{"session", nil, http.StatusOK},
+		{"api key", &model.APIKey{ID: 1, UserID: 1, Scopes: model.APIKeyScopes}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := serve(t, RequireSession(next), testRequest{user: 1, apiKey: tt.apiKey})
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %+v", status, tt.wantStatus, resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/audit"
+	"github.com/notblessy/bikinota-core/logging"
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+const (
+	auditEntriesLimit    = 50
+	auditEntriesMaxLimit = 200
+)
+
+type auditHandler struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditHandler(auditRepo repository.AuditRepository) *auditHandler {
+	return &auditHandler{auditRepo: auditRepo}
+}
+
+// newAuditEntry describes a change of a company record made by the request
+func newAuditEntry(c echo.Context, companyID uint, entity model.AuditEntity, entityID uint, action model.AuditAction) *model.AuditEntry {
+	entry := &model.AuditEntry{
+		CompanyID:  companyID,
+		IPAddress:  c.RealIP(),
+		RequestID:  logging.RequestID(c.Request().Context()),
+		EntityType: entity,
+		EntityID:   entityID,
+		Action:     action,
+	}
+	if p, err := authPrincipal(c); err == nil {
+		entry.UserID = p.UserID
+		if p.APIKey != nil {
+			entry.APIKeyID = &p.APIKey.ID
+		}
+	}
+	return entry
+}
+
+// companyAuditSnapshot is the audited state of a company profile. Bank
+// accounts are audited on their own.
+func companyAuditSnapshot(company *model.Company) model.CompanyResponse {
+	snapshot := company.ToCompanyResponse()
+	snapshot.BankAccounts = nil
+	return snapshot
+}
+
+// bankAccountAuditSnapshot is the audited state of a bank account. The account
+// number of the response is masked, the fingerprint of the full number records
+// changes keeping the last digits.
+type bankAccountAuditSnapshot struct {
+	model.BankAccountResponse
+	AccountNumberFingerprint string `json:"account_number_fingerprint"`
+}
+
+func newBankAccountAuditSnapshot(ba *model.BankAccount) bankAccountAuditSnapshot {
+	return bankAccountAuditSnapshot{
+		BankAccountResponse:      ba.ToBankAccountResponse(),
+		AccountNumberFingerprint: audit.Fingerprint(ba.AccountNumber),
+	}
+}
+
+// recordBankAccountChanges audits the differences between two listings of a
+// company's bank accounts, such as the position changes of a reorder.
+// Accounts missing from after are recorded as deleted.
+func recordBankAccountChanges(ctx context.Context, c echo.Context, recorder audit.Recorder, companyID uint, before, after []model.BankAccount) error {
+	current := make(map[uint]bankAccountAuditSnapshot, len(after))
+	for i := range after {
+		current[after[i].ID] = newBankAccountAuditSnapshot(&after[i])
+	}
+
+	for i, ba := range before {
+		action := model.AuditActionUpdate
+		var snapshot interface{}
+		if s, ok := current[ba.ID]; ok {
+			snapshot = s
+		} else {
+			action = model.AuditActionDelete
+		}
+
+		entry := newAuditEntry(c, companyID, model.AuditEntityBankAccount, ba.ID, action)
+		if err := recorder.Record(ctx, entry, newBankAccountAuditSnapshot(&before[i]), snapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListEntries returns the audit log of the active company, newest first.
+// Entries can be filtered by entity_type, entity_id, user_id, action and a
+// from/to date range; before pages through older entries.
+func (h *auditHandler) ListEntries(c echo.Context) error {
+	member, err := activeMembership(c)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, member, model.PermissionAuditRead) {
+		return errNoPermission
+	}
+
+	filter, fields := parseAuditFilter(c)
+	if len(fields) > 0 {
+		return invalidFields(c, fields...)
+	}
+	filter.CompanyID = member.CompanyID
+
+	return h.listEntries(c, filter)
+}
+
+// InvoiceHistory returns the audit log of an invoice, newest first. The
+// history of deleted invoices stays available.
+func (h *auditHandler) InvoiceHistory(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: "invalid invoice id",
+		})
+	}
+
+	member, err := activeMembership(c)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, member, model.PermissionInvoiceRead) {
+		return errNoPermission
+	}
+
+	filter, fields := parseAuditFilter(c)
+	if len(fields) > 0 {
+		return invalidFields(c, fields...)
+	}
+	filter.CompanyID = member.CompanyID
+	filter.EntityType = model.AuditEntityInvoice
+	filter.EntityID = uint(id)
+
+	return h.listEntries(c, filter)
+}
+
+func (h *auditHandler) listEntries(c echo.Context, filter repository.AuditFilter) error {
+	entries, err := h.auditRepo.List(c.Request().Context(), filter)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve audit entries: %w", err)
+	}
+
+	entryResponses := make([]model.AuditEntryResponse, len(entries))
+	for i, e := range entries {
+		entryResponses[i] = e.ToAuditEntryResponse()
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    entryResponses,
+	})
+}
+
+// parseAuditFilter reads the audit log filters from the query string
+func parseAuditFilter(c echo.Context) (repository.AuditFilter, []fieldError) {
+	filter := repository.AuditFilter{Limit: auditEntriesLimit}
+	var fields []fieldError
+
+	parseID := func(name string) uint {
+		value := c.QueryParam(name)
+		if value == "" {
+			return 0
+		}
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			fields = append(fields, newFieldError(c, name, "numeric"))
+		}
+		return uint(id)
+	}
+
+	parseDate := func(name string) time.Time {
+		value := c.QueryParam(name)
+		if value == "" {
+			return time.Time{}
+		}
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			fields = append(fields, newFieldError(c, name, "date"))
+		}
+		return date
+	}
+
+	if entity := model.AuditEntity(c.QueryParam("entity_type")); entity != "" {
+		known := make([]string, len(model.AuditEntities))
+		valid := false
+		for i, e := range model.AuditEntities {
+			known[i] = string(e)
+			valid = valid || e == entity
+		}
+		if !valid {
+			fields = append(fields, newFieldError(c, "entity_type", "oneof", strings.Join(known, " ")))
+		}
+		filter.EntityType = entity
+	}
+
+	switch action := model.AuditAction(c.QueryParam("action")); action {
+	case "", model.AuditActionCreate, model.AuditActionUpdate, model.AuditActionDelete:
+		filter.Action = action
+	default:
+		fields = append(fields, newFieldError(c, "action", "oneof", "create update delete"))
+	}
+
+	filter.EntityID = parseID("entity_id")
+	filter.UserID = parseID("user_id")
+	filter.BeforeID = parseID("before")
+
+	// The range covers whole days, to is inclusive
+	filter.From = parseDate("from")
+	if to := parseDate("to"); !to.IsZero() {
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	if value := c.QueryParam("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		switch {
+		case err != nil:
+			fields = append(fields, newFieldError(c, "limit", "numeric"))
+		case limit < 1:
+			fields = append(fields, newFieldError(c, "limit", "min", "1"))
+		case limit > auditEntriesMaxLimit:
+			fields = append(fields, newFieldError(c, "limit", "max", strconv.Itoa(auditEntriesMaxLimit)))
+		default:
+			filter.Limit = limit
+		}
+	}
+
+	return filter, fields
+}
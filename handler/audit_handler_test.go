@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/notblessy/bikinota-core/audit"
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+	"github.com/notblessy/bikinota-core/repository/repositorytest"
+)
+
+func TestInvoiceHistory(t *testing.T) {
+	f := newInvoiceFixture(t)
+	admin := &testMember{companyID: 1, role: model.RoleAdmin}
+
+	status, resp := serve(t, f.handler.CreateInvoice, testRequest{method: http.MethodPost, body: testInvoiceBody, user: 1, member: admin})
+	if status != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d: %+v", status, http.StatusCreated, resp)
+	}
+	var created model.InvoiceResponse
+	decodeData(t, resp, &created)
+	itemID := created.Items[0].ID
+
+	update := `{"status": "sent", "items": [{"id": "` + itemID + `", "name": "Logo design", "quantity": 2, "price": 175000}]}`
+	for i := 0; i < 2; i++ { // The repeated update changes nothing and is not recorded
+		status, resp := serve(t, f.handler.UpdateInvoice, testRequest{method: http.MethodPut, id: created.ID, body: update, user: 1, member: admin})
+		if status != http.StatusOK {
+			t.Fatalf("update status = %d, want %d: %+v", status, http.StatusOK, resp)
+		}
+	}
+
+	status, resp = serve(t, f.handler.DeleteInvoice, testRequest{method: http.MethodDelete, id: created.ID, user: 1, member: admin})
+	if status != http.StatusOK {
+		t.Fatalf("delete status = %d, want %d: %+v", status, http.StatusOK, resp)
+	}
+
+	h := NewAuditHandler(f.audits)
+
+	tests := []struct {
+		name        string
+		member      *testMember
+		wantActions []model.AuditAction
+	}{
+		{
+			name:        "viewer of the company",
+			member:      &testMember{companyID: 1, role: model.RoleViewer},
+			wantActions: []model.AuditAction{model.AuditActionDelete, model.AuditActionUpdate, model.AuditActionCreate},
+		},
+		{
+			name:        "member of another company",
+			member:      &testMember{companyID: 2, role: model.RoleOwner},
+			wantActions: []model.AuditAction{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := serve(t, h.InvoiceHistory, testRequest{id: created.ID, user: 2, member: tt.member})
+			if status != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %+v", status, http.StatusOK, resp)
+			}
+
+			var entries []model.AuditEntryResponse
+			decodeData(t, resp, &entries)
+			if len(entries) != len(tt.wantActions) {
+				t.Fatalf("got %d entries, want %d", len(entries), len(tt.wantActions))
+			}
+			for i, e := range entries {
+				if e.Action != tt.wantActions[i] || e.EntityID != created.ID || e.Actor.ID != "1" {
+					t.Errorf("entry %d = %s of invoice %s by %s, want %s of invoice %s by 1",
+						i, e.Action, e.EntityID, e.Actor.ID, tt.wantActions[i], created.ID)
+				}
+			}
+		})
+	}
+
+	entries, err := f.audits.List(context.Background(), repository.AuditFilter{CompanyID: 1, Action: model.AuditActionUpdate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := entries[0].Changes
+	if c := changes["items."+itemID+".price"]; c.Before != 150000.0 || c.After != 175000.0 {
+		t.Errorf("price change = %+v, want 150000 -> 175000", c)
+	}
+	if c := changes["status"]; c.Before != "draft" || c.After != "sent" {
+		t.Errorf("status change = %+v, want draft -> sent", c)
+	}
+	if _, ok := changes["customer_name"]; ok {
+		t.Error("unchanged customer_name recorded")
+	}
+}
+
+func TestListAuditEntries(t *testing.T) {
+	f := newInvoiceFixture(t)
+	f.createInvoice(t, 1)
+	status, resp := serve(t, f.handler.CreateInvoice, testRequest{
+		method: http.MethodPost,
+		body:   testInvoiceBody,
+		user:   1,
+		member: &testMember{companyID: 1, role: model.RoleOwner},
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d: %+v", status, http.StatusCreated, resp)
+	}
+
+	h := NewAuditHandler(f.audits)
+
+	tests := []struct {
+		name        string
+		query       string
+		role        model.Role
+		wantStatus  int
+		wantEntries int
+	}{
+		{"accountant", "", model.RoleAccountant, http.StatusOK, 1},
+		{"filtered by entity", "entity_type=invoice&action=create", model.RoleOwner, http.StatusOK, 1},
+		{"filtered out", "entity_type=plan", model.RoleOwner, http.StatusOK, 0},
+		{"viewer", "", model.RoleViewer, http.StatusForbidden, 0},
+		{"unknown entity", "entity_type=member", model.RoleOwner, http.StatusBadRequest, 0},
+		{"invalid date", "from=yesterday", model.RoleOwner, http.StatusBadRequest, 0},
+		{"limit too large", "limit=1000", model.RoleOwner, http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := serve(t, h.ListEntries, testRequest{
+				query:  tt.query,
+				user:   1,
+				member: &testMember{companyID: 1, role: tt.role},
+			})
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %+v", status, tt.wantStatus, resp)
+			}
+			if status != http.StatusOK {
+				return
+			}
+
+			var entries []model.AuditEntryResponse
+			decodeData(t, resp, &entries)
+			if len(entries) != tt.wantEntries {
+				t.Errorf("got %d entries, want %d", len(entries), tt.wantEntries)
+			}
+		})
+	}
+}
+
+func TestBankAccountAuditTracksFullNumber(t *testing.T) {
+	f := newInvoiceFixture(t)
+	owner := &testMember{companyID: 1, role: model.RoleOwner}
+	bankAccount := &model.BankAccount{CompanyID: 1, Country: "ID", BankName: "BCA", AccountName: "Acme", AccountNumber: "1234567890"}
+	if err := f.companies.AddBankAccount(context.Background(), bankAccount); err != nil {
+		t.Fatal(err)
+	}
+
+	// The masked number stays the same, only the fingerprint shows the change
+	h := NewCompanyHandler(f.companies, nil, nil, audit.NewTrail(f.audits, repositorytest.Transactor{}))
+	status, resp := serve(t, h.UpdateBankAccount, testRequest{
+		method: http.MethodPut,
+		id:     strconv.FormatUint(uint64(bankAccount.ID), 10),
+		body:   `{"account_number": "9999567890"}`,
+		user:   1,
+		member: owner,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %+v", status, http.StatusOK, resp)
+	}
+
+	entries, err := f.audits.List(context.Background(), repository.AuditFilter{CompanyID: 1, EntityType: model.AuditEntityBankAccount})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != model.AuditActionUpdate {
+		t.Fatalf("entries = %+v, want one update", entries)
+	}
+	changes := entries[0].Changes
+	if _, ok := changes["account_number_fingerprint"]; !ok || len(changes) != 1 {
+		t.Errorf("changes = %+v, want only the account number fingerprint", changes)
+	}
+	for path, change := range changes {
+		for _, v := range []interface{}{change.Before, change.After} {
+			if s, _ := v.(string); strings.Contains(s, "1234567890") || strings.Contains(s, "9999567890") {
+				t.Errorf("%s records the full account number %q", path, s)
+			}
+		}
+	}
+}
@@ -1,61 +1,61 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/metrics"
 	"github.com/notblessy/bikinota-core/model"
 	"github.com/notblessy/bikinota-core/repository"
-	"github.com/sirupsen/logrus"
 )
 
 type response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
+	Success bool         `json:"success"`
+	Code    string       `json:"code,omitempty"` // Machine-readable error code
+	Message string       `json:"message,omitempty"`
+	Errors  []fieldError `json:"errors,omitempty"` // Invalid fields when Code is validation_failed
+	Data    interface{}  `json:"data,omitempty"`
 }
 
 type authHandler struct {
 	userRepo repository.UserRepository
+	tokens   *tokenSigner
 	validate *validator.Validate
 }
 
-func NewAuthHandler(userRepo repository.UserRepository) *authHandler {
+func NewAuthHandler(userRepo repository.UserRepository, tokens *tokenSigner) *authHandler {
 	return &authHandler{
 		userRepo: userRepo,
-		validate: validator.New(),
+		tokens:   tokens,
+		validate: newValidator(),
 	}
 }
 
 func (h *authHandler) Register(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "register")
+	logger := requestLogger(c).WithField("endpoint", "register")
 
 	var req model.RegisterRequest
 	if err := c.Bind(&req); err != nil {
 		logger.Errorf("Error parsing request: %v", err)
-		return c.JSON(http.StatusBadRequest, response{
-			Success: false,
-			Message: "invalid request body",
-		})
+		return invalidRequest(c)
 	}
 
 	if err := h.validate.Struct(req); err != nil {
 		logger.Errorf("Validation error: %v", err)
-		return c.JSON(http.StatusBadRequest, response{
-			Success: false,
-			Message: err.Error(),
-		})
+		return validationFailed(c, err)
 	}
 
 	// Check if user already exists
 	existingUser, err := h.userRepo.FindByEmail(c.Request().Context(), req.Email)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
 	if err == nil && existingUser != nil {
 		logger.Warnf("User with email %s already exists", req.Email)
-		return c.JSON(http.StatusConflict, response{
-			Success: false,
-			Message: "user with this email already exists",
-		})
+		return repository.Conflict("user with this email already exists")
 	}
 
 	// Create new user
@@ -66,21 +66,13 @@ func (h *authHandler) Register(c echo.Context) error {
 	}
 
 	if err := h.userRepo.Create(c.Request().Context(), user); err != nil {
-		logger.Errorf("Error creating user: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to create user",
-		})
+		return fmt.Errorf("failed to create user: %w", err)
 	}
 
 	// Generate JWT token
-	token, err := signJWTToken(user.ID, user.Email, user.Name)
+	token, err := h.tokens.signJWTToken(user.ID, user.Email, user.Name)
 	if err != nil {
-		logger.Errorf("Error generating token: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to generate token",
-		})
+		return fmt.Errorf("failed to generate token: %w", err)
 	}
 
 	// Remove password from response
@@ -97,29 +89,27 @@ func (h *authHandler) Register(c echo.Context) error {
 }
 
 func (h *authHandler) Login(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "login")
+	logger := requestLogger(c).WithField("endpoint", "login")
 
 	var req model.LoginRequest
 	if err := c.Bind(&req); err != nil {
 		logger.Errorf("Error parsing request: %v", err)
-		return c.JSON(http.StatusBadRequest, response{
-			Success: false,
-			Message: "invalid request body",
-		})
+		return invalidRequest(c)
 	}
 
 	if err := h.validate.Struct(req); err != nil {
 		logger.Errorf("Validation error: %v", err)
-		return c.JSON(http.StatusBadRequest, response{
-			Success: false,
-			Message: err.Error(),
-		})
+		return validationFailed(c, err)
 	}
 
 	// Find user by email
 	user, err := h.userRepo.FindByEmail(c.Request().Context(), req.Email)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
 	if err != nil {
 		logger.Warnf("User not found: %v", err)
+		metrics.Login(false)
 		return c.JSON(http.StatusUnauthorized, response{
 			Success: false,
 			Message: "invalid email or password",
@@ -129,22 +119,39 @@ func (h *authHandler) Login(c echo.Context) error {
 	// Verify password
 	if !repository.VerifyPassword(user.Password, req.Password) {
 		logger.Warnf("Invalid password for user: %s", req.Email)
+		metrics.Login(false)
 		return c.JSON(http.StatusUnauthorized, response{
 			Success: false,
 			Message: "invalid email or password",
 		})
 	}
 
+	// Users with 2FA enabled get a short-lived challenge instead of a session
+	if user.TOTPEnabled {
+		challengeToken, err := h.tokens.signChallengeToken(user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to generate token: %w", err)
+		}
+
+		return c.JSON(http.StatusOK, response{
+			Success: true,
+			Message: "two-factor authentication required",
+			Data: model.TwoFactorChallengeResponse{
+				ChallengeToken: challengeToken,
+				Type:           "2fa",
+				ExpiresIn:      int(challengeTokenTTL.Seconds()),
+			},
+		})
+	}
+
 	// Generate JWT token
-	token, err := signJWTToken(user.ID, user.Email, user.Name)
+	token, err := h.tokens.signJWTToken(user.ID, user.Email, user.Name)
 	if err != nil {
-		logger.Errorf("Error generating token: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to generate token",
-		})
+		return fmt.Errorf("failed to generate token: %w", err)
 	}
 
+	metrics.Login(true)
+
 	// Remove password from response
 	user.Password = ""
 
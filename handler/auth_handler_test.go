@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository/repositorytest"
+)
+
+func TestRegister(t *testing.T) {
+	users := repositorytest.NewUserRepository()
+	existing := &model.User{Email: "taken@example.com", Name: "Taken", Password: "secret123"}
+	if err := users.Create(context.Background(), existing); err != nil {
+		t.Fatal(err)
+	}
+	h := NewAuthHandler(users, testTokens)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"registers", `{"email":"new@example.com","name":"New","password":"secret123"}`, http.StatusCreated, ""},
+		{"duplicate email", `{"email":"taken@example.com","name":"Again","password":"secret123"}`, http.StatusConflict, codeConflict},
+		{"invalid email", `{"email":"not-an-email","name":"New","password":"secret123"}`, http.StatusBadRequest, codeValidationFailed},
+		{"short password", `{"email":"short@example.com","name":"New","password":"123"}`, http.StatusBadRequest, codeValidationFailed},
+		{"malformed body", `{"email":`, http.StatusBadRequest, codeInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := serve(t, h.Register, testRequest{method: http.MethodPost, body: tt.body})
+			if status != tt.status || resp.Code != tt.code {
+				t.Fatalf("got %d %q, want %d %q: %+v", status, resp.Code, tt.status, tt.code, resp)
+			}
+			if status != http.StatusCreated {
+				return
+			}
+
+			var auth model.AuthResponse
+			decodeData(t, resp, &auth)
+			claims, err := testTokens.validateToken(auth.Token)
+			if err != nil || claims.ID != auth.User.ID || claims.Email != "new@example.com" {
+				t.Errorf("token claims %+v for user %+v: %v", claims, auth.User, err)
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	users := repositorytest.NewUserRepository()
+	user := &model.User{Email: "user@example.com", Name: "User", Password: "secret123"}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	h := NewAuthHandler(users, testTokens)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"valid credentials", `{"email":"user@example.com","password":"secret123"}`, http.StatusOK},
+		{"wrong password", `{"email":"user@example.com","password":"wrong"}`, http.StatusUnauthorized},
+		{"unknown email", `{"email":"nobody@example.com","password":"secret123"}`, http.StatusUnauthorized},
+		{"missing password", `{"email":"user@example.com"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := serve(t, h.Login, testRequest{method: http.MethodPost, body: tt.body})
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d: %+v", status, tt.status, resp)
+			}
+			if status != http.StatusOK {
+				return
+			}
+
+			var auth model.AuthResponse
+			decodeData(t, resp, &auth)
+			if claims, err := testTokens.validateToken(auth.Token); err != nil || claims.ID != user.ID {
+				t.Errorf("token claims %+v: %v", claims, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ipExtractor returns how the client IP of a request is determined. Without
+// trusted proxies it is the peer address, as clients can set any
+// X-Forwarded-For. Behind proxies, X-Forwarded-For is followed back through
+// the given CIDR ranges only. The ranges are validated by the configuration.
+func ipExtractor(trustedProxies []string) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range trustedProxies {
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil {
+			options = append(options, echo.TrustIPRange(ipNet))
+		}
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
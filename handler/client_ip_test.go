@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPExtractor(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		remote  string
+		xff     string
+		want    string
+	}{
+		{"no proxies ignores the header", nil, "198.51.100.7:4321", "203.0.113.9", "198.51.100.7"},
+		{"no proxies ignores private peers", nil, "10.0.0.2:4321", "203.0.113.9", "10.0.0.2"},
+		{"trusted proxy", []string{"10.0.0.0/8"}, "10.0.0.2:4321", "203.0.113.9", "203.0.113.9"},
+		{"chain of trusted proxies", []string{"10.0.0.0/8"}, "10.0.0.2:4321", "203.0.113.9, 10.0.0.3", "203.0.113.9"},
+		{"spoofed hop before the client", []string{"10.0.0.0/8"}, "10.0.0.2:4321", "192.0.2.1, 203.0.113.9", "203.0.113.9"},
+		{"untrusted peer", []string{"10.0.0.0/8"}, "198.51.100.7:4321", "203.0.113.9", "198.51.100.7"},
+		{"private peer outside the ranges", []string{"10.0.0.0/8"}, "192.168.1.5:4321", "203.0.113.9", "192.168.1.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			req.Header.Set("X-Forwarded-For", tt.xff)
+			if got := ipExtractor(tt.proxies)(req); got != tt.want {
+				t.Errorf("client IP = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// HeaderCompanyID selects the active company for users that belong to more
+// than one. Without it the user's oldest membership is used. Company API keys
+// are always bound to their own company.
+const HeaderCompanyID = "X-Company-ID"
+
+type CompanyMiddleware struct {
+	memberRepo repository.MemberRepository
+}
+
+func NewCompanyMiddleware(memberRepo repository.MemberRepository) *CompanyMiddleware {
+	return &CompanyMiddleware{memberRepo: memberRepo}
+}
+
+// ResolveCompany resolves the active company membership of the authenticated
+// user and stores it in the context. It must run after ValidateJWT.
+func (m *CompanyMiddleware) ResolveCompany(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userClaims, err := authSession(c)
+		if err != nil {
+			return errUnauthorized
+		}
+
+		p, err := authPrincipal(c)
+		if err != nil {
+			return errUnauthorized
+		}
+
+		var companyID uint64
+		if header := c.Request().Header.Get(HeaderCompanyID); header != "" {
+			companyID, err = strconv.ParseUint(header, 10, 32)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, response{
+					Success: false,
+					Message: "invalid company id",
+				})
+			}
+		}
+
+		if p.CompanyID != 0 {
+			if companyID != 0 && uint(companyID) != p.CompanyID {
+				return repository.Forbidden("api key is not valid for this company")
+			}
+			companyID = uint64(p.CompanyID)
+		}
+
+		var member *model.CompanyMember
+		if companyID != 0 {
+			member, err = m.memberRepo.FindByCompanyAndUser(c.Request().Context(), uint(companyID), userClaims.ID)
+			if err != nil {
+				return fmt.Errorf("failed to retrieve company: %w", err)
+			}
+			if member == nil {
+				return repository.Forbidden("you are not a member of this company")
+			}
+		} else {
+			member, err = m.memberRepo.FindDefaultByUserID(c.Request().Context(), userClaims.ID)
+			if err != nil {
+				return fmt.Errorf("failed to retrieve company: %w", err)
+			}
+		}
+
+		if member != nil {
+			c.Set("membership", member)
+			addLogFields(c, logrus.Fields{"company_id": member.CompanyID})
+		}
+
+		return next(c)
+	}
+}
+
+// activeMembership returns the membership resolved by CompanyMiddleware. It
+// returns nil without error when the user does not belong to any company yet.
+func activeMembership(c echo.Context) (*model.CompanyMember, error) {
+	if _, err := authSession(c); err != nil {
+		return nil, errUnauthorized
+	}
+
+	m := c.Get("membership")
+	if m == nil {
+		return nil, nil
+	}
+
+	member, ok := m.(*model.CompanyMember)
+	if !ok {
+		return nil, errors.New("invalid membership")
+	}
+
+	return member, nil
+}
+
+// can reports whether the request may exercise the permission in the active
+// company: the member's role must grant it and, for API keys, the key's scopes
+func can(c echo.Context, member *model.CompanyMember, permission model.Permission) bool {
+	if member == nil || !member.Role.Can(permission) {
+		return false
+	}
+
+	return hasScope(c, permission)
+}
+
+// hasScope checks only the API key scopes, for actions outside of a company
+func hasScope(c echo.Context, permission model.Permission) bool {
+	p, err := authPrincipal(c)
+	if err != nil {
+		return false
+	}
+
+	return p.HasScope(permission)
+}
@@ -1,53 +1,124 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/audit"
 	"github.com/notblessy/bikinota-core/model"
 	"github.com/notblessy/bikinota-core/repository"
+	"github.com/notblessy/bikinota-core/storage"
 	"github.com/notblessy/bikinota-core/utils"
-	"github.com/sirupsen/logrus"
 )
 
 type companyHandler struct {
-	companyRepo       repository.CompanyRepository
-	validate          *validator.Validate
-	cloudinaryService *utils.CloudinaryService
+	companyRepo repository.CompanyRepository
+	memberRepo  repository.MemberRepository
+	validate    *validator.Validate
+	assets      *storage.AssetStore
+	auditor     audit.Recorder
 }
 
-func NewCompanyHandler(companyRepo repository.CompanyRepository, cloudinaryService *utils.CloudinaryService) *companyHandler {
+func NewCompanyHandler(companyRepo repository.CompanyRepository, memberRepo repository.MemberRepository, assets *storage.AssetStore, auditor audit.Recorder) *companyHandler {
 	return &companyHandler{
-		companyRepo:       companyRepo,
-		validate:          validator.New(),
-		cloudinaryService: cloudinaryService,
+		companyRepo: companyRepo,
+		memberRepo:  memberRepo,
+		validate:    newValidator(),
+		assets:      assets,
+		auditor:     auditor,
 	}
 }
 
+const (
+	// maxLogoFileSize is the largest logo upload accepted before processing
+	maxLogoFileSize = 5 * 1024 * 1024
+	logoAssetPrefix = "company-logos"
+)
+
+// saveLogo stores a processed logo and its thumbnail as assets and points the
+// company at them. The previous logo assets become orphans and are removed by
+// the asset sweeper. The saved assets are returned even on error, so callers
+// can discard them when the transaction is rolled back.
+func (h *companyHandler) saveLogo(ctx context.Context, company *model.Company, userID uint, logo *utils.ProcessedLogo) ([]*model.Asset, error) {
+	logoAsset, err := h.assets.Save(ctx, company.ID, userID, logoAssetPrefix, logo.Logo.Data, logo.Logo.ContentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store logo: %w", err)
+	}
+
+	thumbAsset, err := h.assets.Save(ctx, company.ID, userID, logoAssetPrefix, logo.Thumbnail.Data, logo.Thumbnail.ContentType)
+	if err != nil {
+		return []*model.Asset{logoAsset}, fmt.Errorf("failed to store logo thumbnail: %w", err)
+	}
+
+	company.LogoAssetID = &logoAsset.ID
+	company.LogoAsset = logoAsset
+	company.LogoThumbAssetID = &thumbAsset.ID
+	company.LogoThumbAsset = thumbAsset
+	return []*model.Asset{logoAsset, thumbAsset}, nil
+}
+
+// processLogo validates and processes image data, returning the message for
+// a 400 response if the image is rejected
+func processLogo(data []byte) (*utils.ProcessedLogo, string) {
+	if len(data) > maxLogoFileSize {
+		return nil, "file size must be less than 5MB"
+	}
+
+	logo, err := utils.ProcessLogo(data)
+	if err != nil {
+		if errors.Is(err, utils.ErrUnsupportedImage) || errors.Is(err, utils.ErrMalformedImage) || errors.Is(err, utils.ErrImageTooLarge) {
+			return nil, err.Error()
+		}
+		return nil, "failed to process image"
+	}
+	return logo, ""
+}
+
+// recordCompany audits a change of the company profile, before is nil for a
+// new company
+func (h *companyHandler) recordCompany(ctx context.Context, c echo.Context, company *model.Company, before interface{}) error {
+	action := model.AuditActionUpdate
+	if before == nil {
+		action = model.AuditActionCreate
+	}
+	entry := newAuditEntry(c, company.ID, model.AuditEntityCompany, company.ID, action)
+	return h.auditor.Record(ctx, entry, before, companyAuditSnapshot(company))
+}
+
+// findCompany loads the company of the authenticated user together with their
+// membership. Both are nil when the user does not belong to a company yet.
+func (h *companyHandler) findCompany(c echo.Context) (*model.Company, *model.CompanyMember, error) {
+	member, err := activeMembership(c)
+	if err != nil || member == nil {
+		return nil, nil, err
+	}
+
+	company, err := h.companyRepo.FindByID(c.Request().Context(), member.CompanyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return company, member, nil
+}
+
 // GetCompany retrieves the company information for the authenticated user
 func (h *companyHandler) GetCompany(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "get_company")
-
 	// Get user from JWT middleware
-	userClaims, err := authSession(c)
+	_, err := authSession(c)
 	if err != nil {
-		logger.Errorf("Error getting session: %v", err)
-		return c.JSON(http.StatusUnauthorized, response{
-			Success: false,
-			Message: "unauthorized",
-		})
+		return errUnauthorized
 	}
 
-	company, err := h.companyRepo.FindByUserID(c.Request().Context(), userClaims.ID)
+	company, member, err := h.findCompany(c)
 	if err != nil {
-		logger.Errorf("Error finding company: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to retrieve company",
-		})
+		return fmt.Errorf("failed to retrieve company: %w", err)
 	}
 
 	// If company doesn't exist, return default/empty company
@@ -59,6 +130,10 @@ func (h *companyHandler) GetCompany(c echo.Context) error {
 		})
 	}
 
+	if !can(c, member, model.PermissionCompanyRead) {
+		return errNoPermission
+	}
+
 	companyResponse := company.ToCompanyResponse()
 	return c.JSON(http.StatusOK, response{
 		Success: true,
@@ -68,43 +143,65 @@ func (h *companyHandler) GetCompany(c echo.Context) error {
 
 // UpdateCompany updates the company information
 func (h *companyHandler) UpdateCompany(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "update_company")
+	logger := requestLogger(c).WithField("endpoint", "update_company")
 
 	// Get user from JWT middleware
 	userClaims, err := authSession(c)
 	if err != nil {
-		logger.Errorf("Error getting session: %v", err)
-		return c.JSON(http.StatusUnauthorized, response{
-			Success: false,
-			Message: "unauthorized",
-		})
+		return errUnauthorized
 	}
 
 	var req model.UpdateCompanyRequest
 	if err := c.Bind(&req); err != nil {
 		logger.Errorf("Error parsing request: %v", err)
-		return c.JSON(http.StatusBadRequest, response{
-			Success: false,
-			Message: "invalid request body",
-		})
+		return invalidRequest(c)
+	}
+
+	// Inline logos are decoded here and stored like uploaded ones
+	var logo *utils.ProcessedLogo
+	if req.Logo != nil && *req.Logo != "" {
+		data, err := utils.DecodeImageData(*req.Logo)
+		if err != nil {
+			return invalidFields(c, newFieldError(c, "/logo", "image"))
+		}
+
+		var message string
+		if logo, message = processLogo(data); logo == nil {
+			return invalidFields(c, fieldError{Field: "/logo", Rule: "image", Message: message})
+		}
+
+		if h.assets == nil {
+			return c.JSON(http.StatusServiceUnavailable, response{
+				Success: false,
+				Message: "image upload service is not configured",
+			})
+		}
 	}
 
 	// Find or create company
-	company, err := h.companyRepo.FindByUserID(c.Request().Context(), userClaims.ID)
+	company, member, err := h.findCompany(c)
 	if err != nil {
-		logger.Errorf("Error finding company: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to retrieve company",
-		})
+		return fmt.Errorf("failed to retrieve company: %w", err)
 	}
 
 	if company == nil {
-		// Create new company
+		if !hasScope(c, model.PermissionCompanyWrite) {
+			return errNoPermission
+		}
+
+		// Create new company, its creator becomes the owner
 		company = &model.Company{
 			UserID:       userClaims.ID,
 			BankAccounts: []model.BankAccount{},
 		}
+	} else if !can(c, member, model.PermissionCompanyWrite) {
+		return errNoPermission
+	}
+
+	// New companies have no previous state to audit
+	var before interface{}
+	if company.ID != 0 {
+		before = companyAuditSnapshot(company)
 	}
 
 	// Update fields if provided
@@ -135,28 +232,49 @@ func (h *companyHandler) UpdateCompany(c echo.Context) error {
 	if req.Website != nil {
 		company.Website = *req.Website
 	}
-	if req.Logo != nil {
-		company.Logo = *req.Logo
+	if req.TaxID != nil {
+		company.TaxID = *req.TaxID
 	}
-
-	if company.ID == 0 {
-		err = h.companyRepo.Create(c.Request().Context(), company)
-	} else {
-		err = h.companyRepo.Update(c.Request().Context(), company)
+	if req.Logo != nil && *req.Logo == "" {
+		company.LogoAssetID = nil
+		company.LogoAsset = nil
+		company.LogoThumbAssetID = nil
+		company.LogoThumbAsset = nil
 	}
 
-	if err != nil {
-		logger.Errorf("Error saving company: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to save company",
-		})
-	}
+	var saved []*model.Asset
+	err = h.auditor.Transaction(c.Request().Context(), func(ctx context.Context) error {
+		// The logo is stored per company, so a new company needs its ID first
+		if company.ID == 0 {
+			if err := h.companyRepo.Create(ctx, company); err != nil {
+				return fmt.Errorf("failed to save company: %w", err)
+			}
+		}
 
-	// Reload with bank accounts
-	company, err = h.companyRepo.FindByUserID(c.Request().Context(), userClaims.ID)
+		if logo != nil {
+			var err error
+			if saved, err = h.saveLogo(ctx, company, userClaims.ID, logo); err != nil {
+				return fmt.Errorf("failed to upload logo: %w", err)
+			}
+		}
+
+		if err := h.companyRepo.Update(ctx, company); err != nil {
+			return fmt.Errorf("failed to save company: %w", err)
+		}
+
+		// Reload with bank accounts
+		if reloaded, err := h.companyRepo.FindByID(ctx, company.ID); err != nil {
+			logger.Errorf("Error reloading company: %v", err)
+		} else {
+			company = reloaded
+		}
+
+		return h.recordCompany(ctx, c, company, before)
+	})
 	if err != nil {
-		logger.Errorf("Error reloading company: %v", err)
+		// The asset rows went with the transaction, their blobs would leak
+		h.assets.Discard(c.Request().Context(), saved)
+		return err
 	}
 
 	companyResponse := company.ToCompanyResponse()
@@ -166,18 +284,14 @@ func (h *companyHandler) UpdateCompany(c echo.Context) error {
 	})
 }
 
-// UploadLogo uploads a company logo to Cloudinary
+// UploadLogo uploads a company logo to the configured blob store
 func (h *companyHandler) UploadLogo(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "upload_logo")
+	logger := requestLogger(c).WithField("endpoint", "upload_logo")
 
 	// Get user from JWT middleware
 	userClaims, err := authSession(c)
 	if err != nil {
-		logger.Errorf("Error getting session: %v", err)
-		return c.JSON(http.StatusUnauthorized, response{
-			Success: false,
-			Message: "unauthorized",
-		})
+		return errUnauthorized
 	}
 
 	// Get the uploaded file
@@ -191,89 +305,98 @@ func (h *companyHandler) UploadLogo(c echo.Context) error {
 	}
 
 	// Validate file size (5MB limit)
-	if file.Size > 5*1024*1024 {
+	if file.Size > maxLogoFileSize {
 		return c.JSON(http.StatusBadRequest, response{
 			Success: false,
 			Message: "file size must be less than 5MB",
 		})
 	}
 
-	// Validate file type
-	contentType := file.Header.Get("Content-Type")
-	if contentType != "image/jpeg" && contentType != "image/png" && contentType != "image/gif" && contentType != "image/webp" {
-		return c.JSON(http.StatusBadRequest, response{
-			Success: false,
-			Message: "file must be an image (jpeg, png, gif, or webp)",
-		})
-	}
-
-	// Open the file
+	// Read the file, never trusting the declared size
 	src, err := file.Open()
 	if err != nil {
-		logger.Errorf("Error opening file: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(src, maxLogoFileSize+1))
+	src.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	// Validate the image content, then resize and re-encode it
+	logo, message := processLogo(data)
+	if logo == nil {
+		logger.Warnf("Rejected logo: %s", message)
+		return c.JSON(http.StatusBadRequest, response{
 			Success: false,
-			Message: "failed to read file",
+			Message: message,
 		})
 	}
-	defer src.Close()
 
 	// Find or create company
-	company, err := h.companyRepo.FindByUserID(c.Request().Context(), userClaims.ID)
+	company, member, err := h.findCompany(c)
 	if err != nil {
-		logger.Errorf("Error finding company: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to retrieve company",
-		})
+		return fmt.Errorf("failed to retrieve company: %w", err)
 	}
 
 	if company == nil {
-		// Create new company
+		if !hasScope(c, model.PermissionCompanyWrite) {
+			return errNoPermission
+		}
+
+		// Create new company, its creator becomes the owner
 		company = &model.Company{
 			UserID:       userClaims.ID,
 			BankAccounts: []model.BankAccount{},
 		}
+	} else if !can(c, member, model.PermissionCompanyWrite) {
+		return errNoPermission
+	}
+
+	// New companies have no previous state to audit
+	var before interface{}
+	if company.ID != 0 {
+		before = companyAuditSnapshot(company)
 	}
 
-	// Upload to Cloudinary
-	if h.cloudinaryService == nil {
+	if h.assets == nil {
 		return c.JSON(http.StatusServiceUnavailable, response{
 			Success: false,
 			Message: "image upload service is not configured",
 		})
 	}
 
-	publicID := fmt.Sprintf("company-logo-%d", userClaims.ID)
-	logoURL, err := h.cloudinaryService.UploadImage(c.Request().Context(), src, publicID)
-	if err != nil {
-		logger.Errorf("Error uploading to Cloudinary: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to upload logo",
-		})
-	}
+	var saved []*model.Asset
+	err = h.auditor.Transaction(c.Request().Context(), func(ctx context.Context) error {
+		// The logo is stored per company, so a new company needs its ID first
+		if company.ID == 0 {
+			if err := h.companyRepo.Create(ctx, company); err != nil {
+				return fmt.Errorf("failed to save company: %w", err)
+			}
+		}
 
-	// Update company with logo URL
-	company.Logo = logoURL
-	if company.ID == 0 {
-		err = h.companyRepo.Create(c.Request().Context(), company)
-	} else {
-		err = h.companyRepo.Update(c.Request().Context(), company)
-	}
+		var err error
+		if saved, err = h.saveLogo(ctx, company, userClaims.ID, logo); err != nil {
+			return fmt.Errorf("failed to upload logo: %w", err)
+		}
 
-	if err != nil {
-		logger.Errorf("Error saving company: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to save company",
-		})
-	}
+		if err := h.companyRepo.Update(ctx, company); err != nil {
+			return fmt.Errorf("failed to save company: %w", err)
+		}
 
-	// Reload with bank accounts
-	company, err = h.companyRepo.FindByUserID(c.Request().Context(), userClaims.ID)
+		// Reload with bank accounts
+		if reloaded, err := h.companyRepo.FindByID(ctx, company.ID); err != nil {
+			logger.Errorf("Error reloading company: %v", err)
+		} else {
+			company = reloaded
+		}
+
+		return h.recordCompany(ctx, c, company, before)
+	})
 	if err != nil {
-		logger.Errorf("Error reloading company: %v", err)
+		// The asset rows went with the transaction, their blobs would leak
+		h.assets.Discard(c.Request().Context(), saved)
+		return err
 	}
 
 	companyResponse := company.ToCompanyResponse()
@@ -285,51 +408,40 @@ func (h *companyHandler) UploadLogo(c echo.Context) error {
 
 // RemoveLogo removes the company logo
 func (h *companyHandler) RemoveLogo(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "remove_logo")
-
 	// Get user from JWT middleware
-	userClaims, err := authSession(c)
+	_, err := authSession(c)
 	if err != nil {
-		logger.Errorf("Error getting session: %v", err)
-		return c.JSON(http.StatusUnauthorized, response{
-			Success: false,
-			Message: "unauthorized",
-		})
+		return errUnauthorized
 	}
 
-	company, err := h.companyRepo.FindByUserID(c.Request().Context(), userClaims.ID)
+	company, member, err := h.findCompany(c)
 	if err != nil {
-		logger.Errorf("Error finding company: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to retrieve company",
-		})
+		return fmt.Errorf("failed to retrieve company: %w", err)
 	}
 
 	if company == nil {
-		return c.JSON(http.StatusNotFound, response{
-			Success: false,
-			Message: "company not found",
-		})
+		return repository.NotFound("company not found")
 	}
 
-	// Delete from Cloudinary if URL exists and service is available
-	if company.Logo != "" && h.cloudinaryService != nil {
-		publicID := fmt.Sprintf("bikinota/company-logos/company-logo-%d", userClaims.ID)
-		if err := h.cloudinaryService.DeleteImage(c.Request().Context(), publicID); err != nil {
-			logger.Warnf("Failed to delete image from Cloudinary: %v", err)
-			// Continue with removing from database even if Cloudinary delete fails
-		}
+	if !can(c, member, model.PermissionCompanyWrite) {
+		return errNoPermission
 	}
 
-	company.Logo = ""
-	err = h.companyRepo.Update(c.Request().Context(), company)
+	before := companyAuditSnapshot(company)
+
+	// The stored files are deleted by the asset sweeper once unreferenced
+	company.LogoAssetID = nil
+	company.LogoAsset = nil
+	company.LogoThumbAssetID = nil
+	company.LogoThumbAsset = nil
+	err = h.auditor.Transaction(c.Request().Context(), func(ctx context.Context) error {
+		if err := h.companyRepo.Update(ctx, company); err != nil {
+			return fmt.Errorf("failed to remove logo: %w", err)
+		}
+		return h.recordCompany(ctx, c, company, before)
+	})
 	if err != nil {
-		logger.Errorf("Error updating company: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to remove logo",
-		})
+		return err
 	}
 
 	companyResponse := company.ToCompanyResponse()
@@ -341,54 +453,43 @@ func (h *companyHandler) RemoveLogo(c echo.Context) error {
 
 // AddBankAccount adds a new bank account to the company
 func (h *companyHandler) AddBankAccount(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "add_bank_account")
+	logger := requestLogger(c).WithField("endpoint", "add_bank_account")
 
 	// Get user from JWT middleware
-	userClaims, err := authSession(c)
+	_, err := authSession(c)
 	if err != nil {
-		logger.Errorf("Error getting session: %v", err)
-		return c.JSON(http.StatusUnauthorized, response{
-			Success: false,
-			Message: "unauthorized",
-		})
+		return errUnauthorized
 	}
 
 	var req model.CreateBankAccountRequest
 	if err := c.Bind(&req); err != nil {
 		logger.Errorf("Error parsing request: %v", err)
-		return c.JSON(http.StatusBadRequest, response{
-			Success: false,
-			Message: "invalid request body",
-		})
+		return invalidRequest(c)
 	}
 
 	if err := h.validate.Struct(req); err != nil {
 		logger.Errorf("Validation error: %v", err)
-		return c.JSON(http.StatusBadRequest, response{
-			Success: false,
-			Message: err.Error(),
-		})
+		return validationFailed(c, err)
 	}
 
 	// Find company
-	company, err := h.companyRepo.FindByUserID(c.Request().Context(), userClaims.ID)
+	company, member, err := h.findCompany(c)
 	if err != nil {
-		logger.Errorf("Error finding company: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to retrieve company",
-		})
+		return fmt.Errorf("failed to retrieve company: %w", err)
 	}
 
 	if company == nil {
-		return c.JSON(http.StatusNotFound, response{
-			Success: false,
-			Message: "company not found. Please create company first",
-		})
+		return repository.NotFound("company not found. Please create company first")
+	}
+
+	if !can(c, member, model.PermissionBankAccountWrite) {
+		return errNoPermission
 	}
 
 	bankAccount := &model.BankAccount{
 		CompanyID:     company.ID,
+		Country:       req.Country,
+		BankCode:      req.BankCode,
 		BankName:      req.BankName,
 		AccountName:   req.AccountName,
 		AccountNumber: req.AccountNumber,
@@ -396,13 +497,19 @@ func (h *companyHandler) AddBankAccount(c echo.Context) error {
 		RoutingNumber: req.RoutingNumber,
 	}
 
-	err = h.companyRepo.AddBankAccount(c.Request().Context(), bankAccount)
+	if errs := validateBankAccount(bankAccount); len(errs) > 0 {
+		return invalidFields(c, bankAccountFieldErrors(c, errs)...)
+	}
+
+	err = h.auditor.Transaction(c.Request().Context(), func(ctx context.Context) error {
+		if err := h.companyRepo.AddBankAccount(ctx, bankAccount); err != nil {
+			return fmt.Errorf("failed to add bank account: %w", err)
+		}
+		entry := newAuditEntry(c, company.ID, model.AuditEntityBankAccount, bankAccount.ID, model.AuditActionCreate)
+		return h.auditor.Record(ctx, entry, nil, newBankAccountAuditSnapshot(bankAccount))
+	})
 	if err != nil {
-		logger.Errorf("Error adding bank account: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to add bank account",
-		})
+		return err
 	}
 
 	bankAccountResponse := bankAccount.ToBankAccountResponse()
@@ -414,16 +521,12 @@ func (h *companyHandler) AddBankAccount(c echo.Context) error {
 
 // UpdateBankAccount updates an existing bank account
 func (h *companyHandler) UpdateBankAccount(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "update_bank_account")
+	logger := requestLogger(c).WithField("endpoint", "update_bank_account")
 
 	// Get user from JWT middleware
-	userClaims, err := authSession(c)
+	_, err := authSession(c)
 	if err != nil {
-		logger.Errorf("Error getting session: %v", err)
-		return c.JSON(http.StatusUnauthorized, response{
-			Success: false,
-			Message: "unauthorized",
-		})
+		return errUnauthorized
 	}
 
 	bankAccountID, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -437,31 +540,42 @@ func (h *companyHandler) UpdateBankAccount(c echo.Context) error {
 	var req model.UpdateBankAccountRequest
 	if err := c.Bind(&req); err != nil {
 		logger.Errorf("Error parsing request: %v", err)
-		return c.JSON(http.StatusBadRequest, response{
-			Success: false,
-			Message: "invalid request body",
-		})
+		return invalidRequest(c)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		logger.Errorf("Validation error: %v", err)
+		return validationFailed(c, err)
 	}
 
 	// Find company
-	company, err := h.companyRepo.FindByUserID(c.Request().Context(), userClaims.ID)
-	if err != nil || company == nil {
-		return c.JSON(http.StatusNotFound, response{
-			Success: false,
-			Message: "company not found",
-		})
+	company, member, err := h.findCompany(c)
+	if err != nil {
+		return err
+	}
+	if company == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, member, model.PermissionBankAccountWrite) {
+		return errNoPermission
 	}
 
 	// Find bank account
 	bankAccount, err := h.companyRepo.FindBankAccountByID(c.Request().Context(), uint(bankAccountID), company.ID)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, response{
-			Success: false,
-			Message: "bank account not found",
-		})
+		return err
 	}
 
+	before := newBankAccountAuditSnapshot(bankAccount)
+
 	// Update fields if provided
+	if req.Country != nil {
+		bankAccount.Country = *req.Country
+	}
+	if req.BankCode != nil {
+		bankAccount.BankCode = req.BankCode
+	}
 	if req.BankName != nil {
 		bankAccount.BankName = *req.BankName
 	}
@@ -478,13 +592,19 @@ func (h *companyHandler) UpdateBankAccount(c echo.Context) error {
 		bankAccount.RoutingNumber = req.RoutingNumber
 	}
 
-	err = h.companyRepo.UpdateBankAccount(c.Request().Context(), bankAccount)
+	if errs := validateBankAccount(bankAccount); len(errs) > 0 {
+		return invalidFields(c, bankAccountFieldErrors(c, errs)...)
+	}
+
+	err = h.auditor.Transaction(c.Request().Context(), func(ctx context.Context) error {
+		if err := h.companyRepo.UpdateBankAccount(ctx, bankAccount); err != nil {
+			return fmt.Errorf("failed to update bank account: %w", err)
+		}
+		entry := newAuditEntry(c, company.ID, model.AuditEntityBankAccount, bankAccount.ID, model.AuditActionUpdate)
+		return h.auditor.Record(ctx, entry, before, newBankAccountAuditSnapshot(bankAccount))
+	})
 	if err != nil {
-		logger.Errorf("Error updating bank account: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to update bank account",
-		})
+		return err
 	}
 
 	bankAccountResponse := bankAccount.ToBankAccountResponse()
@@ -494,18 +614,14 @@ func (h *companyHandler) UpdateBankAccount(c echo.Context) error {
 	})
 }
 
-// DeleteBankAccount deletes a bank account
-func (h *companyHandler) DeleteBankAccount(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "delete_bank_account")
+// RevealBankAccount returns the unmasked account number of a bank account
+func (h *companyHandler) RevealBankAccount(c echo.Context) error {
+	logger := requestLogger(c).WithField("endpoint", "reveal_bank_account")
 
 	// Get user from JWT middleware
 	userClaims, err := authSession(c)
 	if err != nil {
-		logger.Errorf("Error getting session: %v", err)
-		return c.JSON(http.StatusUnauthorized, response{
-			Success: false,
-			Message: "unauthorized",
-		})
+		return errUnauthorized
 	}
 
 	bankAccountID, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -517,23 +633,77 @@ func (h *companyHandler) DeleteBankAccount(c echo.Context) error {
 	}
 
 	// Find company
-	company, err := h.companyRepo.FindByUserID(c.Request().Context(), userClaims.ID)
-	if err != nil || company == nil {
-		return c.JSON(http.StatusNotFound, response{
-			Success: false,
-			Message: "company not found",
-		})
+	company, member, err := h.findCompany(c)
+	if err != nil {
+		return err
+	}
+	if company == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, member, model.PermissionBankAccountReveal) {
+		return errNoPermission
+	}
+
+	bankAccount, err := h.companyRepo.FindBankAccountByID(c.Request().Context(), uint(bankAccountID), company.ID)
+	if err != nil {
+		return err
 	}
 
-	err = h.companyRepo.DeleteBankAccount(c.Request().Context(), uint(bankAccountID), company.ID)
+	logger.WithField("user_id", userClaims.ID).Infof("Revealed bank account %d of company %d", bankAccount.ID, company.ID)
+
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    bankAccount.ToBankAccountRevealResponse(),
+	})
+}
+
+// DeleteBankAccount deletes a bank account
+func (h *companyHandler) DeleteBankAccount(c echo.Context) error {
+	// Get user from JWT middleware
+	_, err := authSession(c)
+	if err != nil {
+		return errUnauthorized
+	}
+
+	bankAccountID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		logger.Errorf("Error deleting bank account: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
+		return c.JSON(http.StatusBadRequest, response{
 			Success: false,
-			Message: "failed to delete bank account",
+			Message: "invalid bank account id",
 		})
 	}
 
+	// Find company
+	company, member, err := h.findCompany(c)
+	if err != nil {
+		return err
+	}
+	if company == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, member, model.PermissionBankAccountWrite) {
+		return errNoPermission
+	}
+
+	err = h.auditor.Transaction(c.Request().Context(), func(ctx context.Context) error {
+		if err := h.companyRepo.DeleteBankAccount(ctx, uint(bankAccountID), company.ID); err != nil {
+			return fmt.Errorf("failed to delete bank account: %w", err)
+		}
+
+		// Deleting the default account promotes another one
+		bankAccounts, err := h.companyRepo.GetBankAccounts(ctx, company.ID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve bank accounts: %w", err)
+		}
+		return recordBankAccountChanges(ctx, c, h.auditor, company.ID, company.BankAccounts, bankAccounts)
+	})
+	if err != nil {
+		return err
+	}
+
 	return c.JSON(http.StatusOK, response{
 		Success: true,
 		Message: "bank account deleted successfully",
@@ -542,16 +712,10 @@ func (h *companyHandler) DeleteBankAccount(c echo.Context) error {
 
 // SetDefaultBankAccount sets a bank account as the default
 func (h *companyHandler) SetDefaultBankAccount(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "set_default_bank_account")
-
 	// Get user from JWT middleware
-	userClaims, err := authSession(c)
+	_, err := authSession(c)
 	if err != nil {
-		logger.Errorf("Error getting session: %v", err)
-		return c.JSON(http.StatusUnauthorized, response{
-			Success: false,
-			Message: "unauthorized",
-		})
+		return errUnauthorized
 	}
 
 	bankAccountID, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -563,32 +727,243 @@ func (h *companyHandler) SetDefaultBankAccount(c echo.Context) error {
 	}
 
 	// Find company
-	company, err := h.companyRepo.FindByUserID(c.Request().Context(), userClaims.ID)
-	if err != nil || company == nil {
-		return c.JSON(http.StatusNotFound, response{
-			Success: false,
-			Message: "company not found",
-		})
+	company, member, err := h.findCompany(c)
+	if err != nil {
+		return err
+	}
+	if company == nil {
+		return repository.NotFound("company not found")
 	}
 
-	err = h.companyRepo.SetDefaultBankAccount(c.Request().Context(), uint(bankAccountID), company.ID)
+	if !can(c, member, model.PermissionBankAccountWrite) {
+		return errNoPermission
+	}
+
+	err = h.auditor.Transaction(c.Request().Context(), func(ctx context.Context) error {
+		if err := h.companyRepo.SetDefaultBankAccount(ctx, uint(bankAccountID), company.ID); err != nil {
+			return fmt.Errorf("failed to set default bank account: %w", err)
+		}
+
+		// Reload company with updated bank accounts
+		reloaded, err := h.companyRepo.FindByID(ctx, company.ID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve company: %w", err)
+		}
+		if err := recordBankAccountChanges(ctx, c, h.auditor, company.ID, company.BankAccounts, reloaded.BankAccounts); err != nil {
+			return err
+		}
+		company = reloaded
+		return nil
+	})
 	if err != nil {
-		logger.Errorf("Error setting default bank account: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to set default bank account",
+		return err
+	}
+
+	companyResponse := company.ToCompanyResponse()
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    companyResponse,
+	})
+}
+
+// ReorderBankAccounts sets the display order of the company's bank accounts
+func (h *companyHandler) ReorderBankAccounts(c echo.Context) error {
+	logger := requestLogger(c).WithField("endpoint", "reorder_bank_accounts")
+
+	// Get user from JWT middleware
+	_, err := authSession(c)
+	if err != nil {
+		return errUnauthorized
+	}
+
+	var req model.ReorderBankAccountsRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return invalidRequest(c)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		logger.Errorf("Validation error: %v", err)
+		return validationFailed(c, err)
+	}
+
+	ids := make([]uint, len(req.IDs))
+	for i, idStr := range req.IDs {
+		id, err := strconv.ParseUint(idStr, 10, 32)
+		if err != nil {
+			return invalidFields(c, newFieldError(c, fmt.Sprintf("/ids/%d", i), "invalid"))
+		}
+		ids[i] = uint(id)
+	}
+
+	// Find company
+	company, member, err := h.findCompany(c)
+	if err != nil {
+		return err
+	}
+	if company == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, member, model.PermissionBankAccountWrite) {
+		return errNoPermission
+	}
+
+	var bankAccounts []model.BankAccount
+	err = h.auditor.Transaction(c.Request().Context(), func(ctx context.Context) error {
+		if err := h.companyRepo.ReorderBankAccounts(ctx, company.ID, ids); err != nil {
+			return fmt.Errorf("failed to reorder bank accounts: %w", err)
+		}
+
+		var err error
+		bankAccounts, err = h.companyRepo.GetBankAccounts(ctx, company.ID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve bank accounts: %w", err)
+		}
+		return recordBankAccountChanges(ctx, c, h.auditor, company.ID, company.BankAccounts, bankAccounts)
+	})
+	if errors.Is(err, repository.ErrInvalidBankAccountOrder) {
+		return invalidFields(c, newFieldError(c, "/ids", "bank_account_order"))
+	}
+	if err != nil {
+		return err
+	}
+
+	bankAccountResponses := make([]model.BankAccountResponse, len(bankAccounts))
+	for i, ba := range bankAccounts {
+		bankAccountResponses[i] = ba.ToBankAccountResponse()
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    bankAccountResponses,
+	})
+}
+
+// ListCompanies lists all companies the authenticated user belongs to
+func (h *companyHandler) ListCompanies(c echo.Context) error {
+	// Get user from JWT middleware
+	userClaims, err := authSession(c)
+	if err != nil {
+		return errUnauthorized
+	}
+
+	memberships, err := h.memberRepo.ListByUserID(c.Request().Context(), userClaims.ID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve companies: %w", err)
+	}
+
+	companies := make([]model.CompanySummaryResponse, 0, len(memberships))
+	for _, m := range memberships {
+		if m.Company == nil {
+			continue
+		}
+		companies = append(companies, model.CompanySummaryResponse{
+			ID:   strconv.FormatUint(uint64(m.CompanyID), 10),
+			Name: m.Company.Name,
+			Logo: m.Company.LogoURL(),
+			Role: m.Role,
 		})
 	}
 
-	// Reload company with updated bank accounts
-	company, err = h.companyRepo.FindByUserID(c.Request().Context(), userClaims.ID)
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    companies,
+	})
+}
+
+// CreateCompany creates an additional company owned by the authenticated user.
+// Select it for subsequent requests with the X-Company-ID header.
+func (h *companyHandler) CreateCompany(c echo.Context) error {
+	logger := requestLogger(c).WithField("endpoint", "create_company")
+
+	// Get user from JWT middleware
+	userClaims, err := authSession(c)
 	if err != nil {
-		logger.Errorf("Error reloading company: %v", err)
+		return errUnauthorized
+	}
+
+	var req model.CreateCompanyRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return invalidRequest(c)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		logger.Errorf("Validation error: %v", err)
+		return validationFailed(c, err)
+	}
+
+	company := &model.Company{
+		UserID:       userClaims.ID,
+		Name:         req.Name,
+		Address:      req.Address,
+		City:         req.City,
+		State:        req.State,
+		ZipCode:      req.ZipCode,
+		Country:      req.Country,
+		Email:        req.Email,
+		Phone:        req.Phone,
+		Website:      req.Website,
+		TaxID:        req.TaxID,
+		BankAccounts: []model.BankAccount{},
+	}
+
+	err = h.auditor.Transaction(c.Request().Context(), func(ctx context.Context) error {
+		if err := h.companyRepo.Create(ctx, company); err != nil {
+			return fmt.Errorf("failed to create company: %w", err)
+		}
+		return h.recordCompany(ctx, c, company, nil)
+	})
+	if err != nil {
+		return err
 	}
 
 	companyResponse := company.ToCompanyResponse()
-	return c.JSON(http.StatusOK, response{
+	return c.JSON(http.StatusCreated, response{
 		Success: true,
 		Data:    companyResponse,
 	})
 }
+
+// validateBankAccount normalizes the user entered fields of a bank account and
+// checks them against the rules of its country
+func validateBankAccount(ba *model.BankAccount) []utils.FieldError {
+	ba.Country = strings.ToUpper(strings.TrimSpace(ba.Country))
+	ba.AccountNumber = utils.NormalizeAccountNumber(ba.AccountNumber)
+	ba.BankCode = normalizeOptional(ba.BankCode, strings.TrimSpace)
+	ba.SwiftCode = normalizeOptional(ba.SwiftCode, utils.NormalizeAccountNumber)
+	ba.RoutingNumber = normalizeOptional(ba.RoutingNumber, utils.NormalizeAccountNumber)
+
+	details := utils.BankAccountDetails{
+		Country:       ba.Country,
+		AccountNumber: ba.AccountNumber,
+	}
+	if ba.BankCode != nil {
+		details.BankCode = *ba.BankCode
+	}
+	if ba.SwiftCode != nil {
+		details.SwiftCode = *ba.SwiftCode
+	}
+	if ba.RoutingNumber != nil {
+		details.RoutingNumber = *ba.RoutingNumber
+	}
+
+	errs := utils.ValidateBankAccount(details)
+	if ba.AccountNumber == "" {
+		errs = append(errs, utils.FieldError{Field: "account_number", Rule: "required"})
+	}
+	return errs
+}
+
+// normalizeOptional applies normalize to an optional value, empty results become nil
+func normalizeOptional(value *string, normalize func(string) string) *string {
+	if value == nil {
+		return nil
+	}
+	normalized := normalize(*value)
+	if normalized == "" {
+		return nil
+	}
+	return &normalized
+}
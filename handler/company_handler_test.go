@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/notblessy/bikinota-core/audit"
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository/repositorytest"
+	"github.com/notblessy/bikinota-core/storage"
+)
+
+// testLogo returns a small PNG as a data URL
+func testLogo(t *testing.T) string {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 4), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// storedBlobs counts the files below root
+func storedBlobs(t *testing.T, root string) int {
+	t.Helper()
+
+	count := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			count++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestUpdateCompanyLogoDiscardedWithoutAuditEntry(t *testing.T) {
+	f := newInvoiceFixture(t)
+	root := t.TempDir()
+	blobs, err := storage.NewLocalStore(root, "http://example.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assets := storage.NewAssetStore(blobs, repositorytest.NewAssetRepository())
+	h := NewCompanyHandler(f.companies, nil, assets, audit.NewTrail(f.audits, repositorytest.Transactor{}))
+
+	body, err := json.Marshal(map[string]string{"logo": testLogo(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := testRequest{
+		method: http.MethodPut,
+		body:   string(body),
+		user:   1,
+		member: &testMember{companyID: 1, role: model.RoleOwner},
+	}
+
+	// The uploaded blobs are removed with the rolled back asset rows
+	f.audits.Err = errors.New("audit log unavailable")
+	if status, resp := serve(t, h.UpdateCompany, request); status != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d: %+v", status, http.StatusInternalServerError, resp)
+	}
+	if n := storedBlobs(t, root); n != 0 {
+		t.Errorf("%d blobs left after the failed update, want none", n)
+	}
+
+	f.audits.Err = nil
+	if status, resp := serve(t, h.UpdateCompany, request); status != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %+v", status, http.StatusOK, resp)
+	}
+	if n := storedBlobs(t, root); n != 2 {
+		t.Errorf("%d blobs stored, want the logo and its thumbnail", n)
+	}
+}
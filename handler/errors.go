@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+// Error codes of the domain errors mapped by HTTPErrorHandler
+const (
+	codeUnauthorized  = "unauthorized"
+	codeForbidden     = "forbidden"
+	codeNotFound      = "not_found"
+	codeConflict      = "conflict"
+	codeInternalError = "internal_error"
+)
+
+// errUnauthorized is returned by handlers reached without a valid session
+var errUnauthorized = echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+
+// errNoPermission is returned when the member's role or the API key's scopes
+// do not allow the action
+var errNoPermission = repository.Forbidden("you do not have permission to perform this action")
+
+// HTTPErrorHandler writes errors returned by handlers and middlewares as the
+// standard response envelope. Repository domain errors map to their status
+// code; any other error is logged and reported as an internal error without
+// exposing its details.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status, res := errorResponse(c, err)
+	if status == http.StatusInternalServerError {
+		requestLogger(c).Errorf("Unhandled error: %v", err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, res)
+	}
+	if err != nil {
+		requestLogger(c).Errorf("Error writing error response: %v", err)
+	}
+}
+
+// errorResponse maps an error to a status code and response body
+func errorResponse(c echo.Context, err error) (int, response) {
+	lang := requestLanguage(c)
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return http.StatusBadRequest, response{
+			Code:    codeValidationFailed,
+			Message: translate(lang, codeValidationFailed),
+			Errors:  validationFieldErrors(lang, validationErrs),
+		}
+	}
+
+	var domainErr *repository.Error
+	if errors.As(err, &domainErr) {
+		switch domainErr.Kind {
+		case repository.ErrNotFound:
+			return http.StatusNotFound, response{Code: codeNotFound, Message: domainErr.Message}
+		case repository.ErrConflict:
+			return http.StatusConflict, response{Code: codeConflict, Message: domainErr.Message}
+		case repository.ErrForbidden:
+			return http.StatusForbidden, response{Code: codeForbidden, Message: domainErr.Message}
+		case repository.ErrValidation:
+			return http.StatusBadRequest, response{Code: codeValidationFailed, Message: domainErr.Message}
+		}
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		res := response{Message: http.StatusText(httpErr.Code)}
+		if msg, ok := httpErr.Message.(string); ok {
+			res.Message = msg
+		}
+		switch httpErr.Code {
+		case http.StatusUnauthorized:
+			res.Code = codeUnauthorized
+		case http.StatusForbidden:
+			res.Code = codeForbidden
+		case http.StatusNotFound:
+			res.Code = codeNotFound
+		case http.StatusInternalServerError:
+			res = response{Code: codeInternalError, Message: translate(lang, codeInternalError)}
+		}
+		return httpErr.Code, res
+	}
+
+	return http.StatusInternalServerError, response{
+		Code:    codeInternalError,
+		Message: translate(lang, codeInternalError),
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{"not found", repository.NotFound("invoice not found"), http.StatusNotFound, codeNotFound, "invoice not found"},
+		{"wrapped conflict", fmt.Errorf("failed to register: %w", repository.Conflict("email already registered")), http.StatusConflict, codeConflict, "email already registered"},
+		{"forbidden", errNoPermission, http.StatusForbidden, codeForbidden, "you do not have permission to perform this action"},
+		{"unauthorized", fmt.Errorf("failed to retrieve company: %w", errUnauthorized), http.StatusUnauthorized, codeUnauthorized, "unauthorized"},
+		{"database outage", fmt.Errorf("failed to retrieve invoice: %w", errors.New("connection refused")), http.StatusInternalServerError, codeInternalError, "something went wrong, please try again later"},
+		{"echo error", echo.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "", "Method Not Allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+			HTTPErrorHandler(tt.err, c)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			var res response
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.Success || res.Code != tt.code || res.Message != tt.message {
+				t.Errorf("got %+v, want code %q and message %q", res, tt.code, tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/storage"
+)
+
+type fileHandler struct {
+	blobStore storage.BlobStore
+}
+
+func NewFileHandler(blobStore storage.BlobStore) *fileHandler {
+	return &fileHandler{
+		blobStore: blobStore,
+	}
+}
+
+// ServeFile streams an object of the local blob store. Uploads such as logos
+// are embedded in invoices shared with customers, so no auth is required.
+func (h *fileHandler) ServeFile(c echo.Context) error {
+	logger := requestLogger(c).WithField("endpoint", "serve_file")
+
+	obj, err := h.blobStore.Get(c.Request().Context(), c.Param("*"))
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			logger.Errorf("Error reading file: %v", err)
+		}
+		return c.JSON(http.StatusNotFound, response{
+			Success: false,
+			Message: "file not found",
+		})
+	}
+	defer obj.Body.Close()
+
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.Stream(http.StatusOK, obj.ContentType, obj.Body)
+}
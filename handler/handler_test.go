@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/config"
+	"github.com/notblessy/bikinota-core/model"
+)
+
+var testTokens = newTokenSigner(config.JWTConfig{Secret: "test-secret", SessionTTL: time.Hour})
+
+// testRequest describes a request made directly against a handler
+type testRequest struct {
+	method string
+	body   string
+	query  string        // Encoded query string
+	id     string        // Value of the :id path parameter
+	user   uint          // Authenticated user, none when zero
+	member *testMember   // Active company membership of the user
+	apiKey *model.APIKey // API key the user authenticated with, a session when nil
+}
+
+type testMember struct {
+	companyID uint
+	role      model.Role
+}
+
+// serve calls h with a request built from r, writing returned errors the way
+// the server does, and returns the status and decoded response
+func serve(t *testing.T, h echo.HandlerFunc, r testRequest) (int, response) {
+	t.Helper()
+
+	rec := record(h, r)
+	var resp response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response body %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, resp
+}
+
+// record calls h like serve and returns the recorded response, for handlers
+// that do not respond with JSON
+func record(h echo.HandlerFunc, r testRequest) *httptest.ResponseRecorder {
+	method := r.method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req := httptest.NewRequest(method, "/?"+r.query, strings.NewReader(r.body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	c := echo.New().NewContext(req, rec)
+	if r.id != "" {
+		c.SetParamNames("id")
+		c.SetParamValues(r.id)
+	}
+	if r.user != 0 {
+		c.Set("user", jwtClaims{ID: r.user, Email: "user@example.com", Name: "User"})
+		c.Set("principal", principal{UserID: r.user, APIKey: r.apiKey})
+	}
+	if r.member != nil {
+		c.Set("membership", &model.CompanyMember{CompanyID: r.member.companyID, UserID: r.user, Role: r.member.role})
+	}
+
+	if err := h(c); err != nil {
+		HTTPErrorHandler(err, c)
+	}
+	return rec
+}
+
+// decodeData converts the data of a response into v
+func decodeData(t *testing.T, resp response, v interface{}) {
+	t.Helper()
+
+	raw, err := json.Marshal(resp.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// recordingPublisher records published webhook events
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []model.WebhookEvent
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, companyID uint, event model.WebhookEvent, data interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// readinessTimeout bounds each check so a hanging dependency fails the probe
+// instead of stalling it
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck reports whether a dependency such as the database can serve
+// requests
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type healthHandler struct {
+	checks []ReadinessCheck
+}
+
+func NewHealthHandler(checks []ReadinessCheck) *healthHandler {
+	return &healthHandler{
+		checks: checks,
+	}
+}
+
+// Ready runs every readiness check and answers 503 when any of them fails, so
+// load balancers stop routing to an instance that lost its database
+func (h *healthHandler) Ready(c echo.Context) error {
+	logger := requestLogger(c).WithField("endpoint", "readyz")
+
+	status := http.StatusOK
+	results := make(map[string]string, len(h.checks))
+	for _, check := range h.checks {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), readinessTimeout)
+		err := check.Check(ctx)
+		cancel()
+
+		if err != nil {
+			logger.Warnf("Readiness check %s failed: %v", check.Name, err)
+			status = http.StatusServiceUnavailable
+			results[check.Name] = "unavailable"
+			continue
+		}
+		results[check.Name] = "ok"
+	}
+
+	return c.JSON(status, response{
+		Success: status == http.StatusOK,
+		Data:    results,
+	})
+}
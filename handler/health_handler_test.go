@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestReady(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("connection refused") }
+
+	tests := []struct {
+		name   string
+		checks []ReadinessCheck
+		status int
+		body   string
+	}{
+		{"all available", []ReadinessCheck{{"database", ok}, {"database_replica", ok}}, http.StatusOK, `"database_replica":"ok"`},
+		{"replica down", []ReadinessCheck{{"database", ok}, {"database_replica", down}}, http.StatusServiceUnavailable, `"database_replica":"unavailable"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/readyz", nil), rec)
+
+			if err := NewHealthHandler(tt.checks).Ready(c); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if !strings.Contains(rec.Body.String(), tt.body) {
+				t.Errorf("body = %s, want it to contain %s", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
@@ -1,14 +1,21 @@
 package handler
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/audit"
+	"github.com/notblessy/bikinota-core/metrics"
 	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/render"
 	"github.com/notblessy/bikinota-core/repository"
+	"github.com/notblessy/bikinota-core/webhook"
 	"github.com/sirupsen/logrus"
 )
 
@@ -18,37 +25,98 @@ func rupiahToCents(rupiah float64) int {
 }
 
 type invoiceHandler struct {
-	invoiceRepo repository.InvoiceRepository
-	validate    *validator.Validate
+	invoiceRepo  repository.InvoiceRepository
+	memberRepo   repository.MemberRepository
+	companyRepo  repository.CompanyRepository
+	settingsRepo repository.InvoiceSettingsRepository
+	publisher    webhook.Publisher
+	auditor      audit.Recorder
+	validate     *validator.Validate
 }
 
-func NewInvoiceHandler(invoiceRepo repository.InvoiceRepository) *invoiceHandler {
+func NewInvoiceHandler(invoiceRepo repository.InvoiceRepository, memberRepo repository.MemberRepository, companyRepo repository.CompanyRepository, settingsRepo repository.InvoiceSettingsRepository, publisher webhook.Publisher, auditor audit.Recorder) *invoiceHandler {
 	return &invoiceHandler{
-		invoiceRepo: invoiceRepo,
-		validate:    validator.New(),
+		invoiceRepo:  invoiceRepo,
+		memberRepo:   memberRepo,
+		companyRepo:  companyRepo,
+		settingsRepo: settingsRepo,
+		publisher:    publisher,
+		auditor:      auditor,
+		validate:     newValidator(),
 	}
 }
 
-// GetInvoices retrieves all invoices for the authenticated user
+// publish queues webhook events for an invoice. Failures are logged only, the
+// invoice change itself has already been saved.
+func (h *invoiceHandler) publish(c echo.Context, logger *logrus.Entry, invoice *model.Invoice, events ...model.WebhookEvent) {
+	data := invoice.ToInvoiceResponse()
+	for _, event := range events {
+		if err := h.publisher.Publish(c.Request().Context(), invoice.CompanyID, event, data); err != nil {
+			logger.Errorf("Error publishing %s webhook: %v", event, err)
+		}
+	}
+}
+
+// statusEvents returns the events triggered by moving an invoice into status
+func statusEvents(status string) []model.WebhookEvent {
+	switch status {
+	case "sent":
+		return []model.WebhookEvent{model.WebhookEventInvoiceSent}
+	case "paid":
+		return []model.WebhookEvent{model.WebhookEventInvoicePaid, model.WebhookEventPaymentRecorded}
+	}
+	return nil
+}
+
+// findBankAccount parses a bank account ID from a request and looks the
+// account up within the company
+func (h *invoiceHandler) findBankAccount(ctx context.Context, companyID uint, idStr string) (*model.BankAccount, error) {
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bank account id %q", idStr)
+	}
+	return h.companyRepo.FindBankAccountByID(ctx, uint(id), companyID)
+}
+
+// setBankAccount assigns a bank account to an invoice along with a snapshot
+// of its details, nil removes it
+func setBankAccount(invoice *model.Invoice, bankAccount *model.BankAccount) {
+	if bankAccount == nil {
+		invoice.BankAccountID = nil
+		invoice.BankDetails = nil
+		return
+	}
+	invoice.BankAccountID = &bankAccount.ID
+	invoice.BankDetails = bankAccount.Snapshot()
+}
+
+// GetInvoices retrieves all invoices of the authenticated user's company
 func (h *invoiceHandler) GetInvoices(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "get_invoices")
+	_, err := authSession(c)
+	if err != nil {
+		return errUnauthorized
+	}
 
-	userClaims, err := authSession(c)
+	member, err := activeMembership(c)
 	if err != nil {
-		logger.Errorf("Error getting session: %v", err)
-		return c.JSON(http.StatusUnauthorized, response{
-			Success: false,
-			Message: "unauthorized",
+		return fmt.Errorf("failed to retrieve invoices: %w", err)
+	}
+
+	// Users without a company have no invoices yet
+	if member == nil {
+		return c.JSON(http.StatusOK, response{
+			Success: true,
+			Data:    []model.InvoiceResponse{},
 		})
 	}
 
-	invoices, err := h.invoiceRepo.FindByUserID(c.Request().Context(), userClaims.ID)
+	if !can(c, member, model.PermissionInvoiceRead) {
+		return errNoPermission
+	}
+
+	invoices, err := h.invoiceRepo.FindByCompanyID(c.Request().Context(), member.CompanyID)
 	if err != nil {
-		logger.Errorf("Error finding invoices: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to retrieve invoices",
-		})
+		return fmt.Errorf("failed to retrieve invoices: %w", err)
 	}
 
 	invoiceResponses := make([]model.InvoiceResponse, len(invoices))
@@ -64,15 +132,9 @@ func (h *invoiceHandler) GetInvoices(c echo.Context) error {
 
 // GetInvoice retrieves a single invoice by ID
 func (h *invoiceHandler) GetInvoice(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "get_invoice")
-
-	userClaims, err := authSession(c)
+	_, err := authSession(c)
 	if err != nil {
-		logger.Errorf("Error getting session: %v", err)
-		return c.JSON(http.StatusUnauthorized, response{
-			Success: false,
-			Message: "unauthorized",
-		})
+		return errUnauthorized
 	}
 
 	idStr := c.Param("id")
@@ -86,19 +148,27 @@ func (h *invoiceHandler) GetInvoice(c echo.Context) error {
 
 	invoice, err := h.invoiceRepo.FindByID(c.Request().Context(), uint(id))
 	if err != nil {
-		logger.Errorf("Error finding invoice: %v", err)
-		return c.JSON(http.StatusNotFound, response{
-			Success: false,
-			Message: "invoice not found",
-		})
+		return err
 	}
 
-	// Verify invoice belongs to user
-	if invoice.UserID != userClaims.ID {
-		return c.JSON(http.StatusForbidden, response{
-			Success: false,
-			Message: "access denied",
-		})
+	// Verify invoice belongs to the user's company
+	member, err := activeMembership(c)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve company: %w", err)
+	}
+
+	if member == nil {
+		return repository.Forbidden("access denied")
+	}
+
+	// Another company's invoice is reported as missing, like GetInvoiceHTML
+	// does, so its ID does not reveal that it exists
+	if invoice.CompanyID != member.CompanyID {
+		return repository.NotFound("invoice not found")
+	}
+
+	if !can(c, member, model.PermissionInvoiceRead) {
+		return errNoPermission
 	}
 
 	return c.JSON(http.StatusOK, response{
@@ -107,34 +177,100 @@ func (h *invoiceHandler) GetInvoice(c echo.Context) error {
 	})
 }
 
+// GetInvoiceHTML renders an invoice as a standalone, print-ready HTML
+// document. The optional template query parameter selects one of the
+// company's invoice templates, the default template is used otherwise.
+func (h *invoiceHandler) GetInvoiceHTML(c echo.Context) error {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: "invalid invoice id",
+		})
+	}
+
+	member, err := activeMembership(c)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve company: %w", err)
+	}
+
+	if member == nil {
+		return repository.Forbidden("access denied")
+	}
+
+	if !can(c, member, model.PermissionInvoiceRead) {
+		return errNoPermission
+	}
+
+	ctx := c.Request().Context()
+
+	invoice, err := h.invoiceRepo.FindByID(ctx, uint(id))
+	if err != nil {
+		return err
+	}
+	if invoice.CompanyID != member.CompanyID {
+		return repository.NotFound("invoice not found")
+	}
+
+	company, err := h.companyRepo.FindByID(ctx, invoice.CompanyID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve company: %w", err)
+	}
+
+	settings, err := h.settingsRepo.FindByCompanyID(ctx, invoice.CompanyID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve invoice settings: %w", err)
+	}
+
+	doc := render.InvoiceDocument{
+		Invoice:     invoice,
+		Company:     company,
+		BankAccount: invoice.BankDetails,
+		Template:    settings.Template(c.QueryParam("template")),
+	}
+
+	var buf bytes.Buffer
+	if err := render.HTML(&buf, doc); err != nil {
+		return fmt.Errorf("failed to render invoice: %w", err)
+	}
+
+	// The document is self-contained, only the logo is loaded from elsewhere
+	c.Response().Header().Set("Content-Security-Policy", "default-src 'none'; img-src https: http: data:; style-src 'unsafe-inline'")
+	return c.HTMLBlob(http.StatusOK, buf.Bytes())
+}
+
 // CreateInvoice creates a new invoice
 func (h *invoiceHandler) CreateInvoice(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "create_invoice")
+	logger := requestLogger(c).WithField("endpoint", "create_invoice")
 
 	userClaims, err := authSession(c)
 	if err != nil {
-		logger.Errorf("Error getting session: %v", err)
-		return c.JSON(http.StatusUnauthorized, response{
-			Success: false,
-			Message: "unauthorized",
-		})
+		return errUnauthorized
 	}
 
 	var req model.CreateInvoiceRequest
 	if err := c.Bind(&req); err != nil {
 		logger.Errorf("Error binding request: %v", err)
-		return c.JSON(http.StatusBadRequest, response{
-			Success: false,
-			Message: "invalid request",
-		})
+		return invalidRequest(c)
 	}
 
 	if err := h.validate.Struct(req); err != nil {
 		logger.Errorf("Validation error: %v", err)
-		return c.JSON(http.StatusBadRequest, response{
-			Success: false,
-			Message: "validation failed",
-		})
+		return validationFailed(c, err)
+	}
+
+	member, err := activeMembership(c)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve company: %w", err)
+	}
+
+	if member == nil {
+		return repository.NotFound("company not found. Please create company first")
+	}
+
+	if !can(c, member, model.PermissionInvoiceWrite) {
+		return errNoPermission
 	}
 
 	// Parse due date (optional)
@@ -142,10 +278,7 @@ func (h *invoiceHandler) CreateInvoice(c echo.Context) error {
 	if req.DueDate != nil && *req.DueDate != "" {
 		parsedDate, err := time.Parse("2006-01-02", *req.DueDate)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, response{
-				Success: false,
-				Message: "invalid due date format",
-			})
+			return invalidFields(c, newFieldError(c, "/due_date", "date"))
 		}
 		dueDate = &parsedDate
 	}
@@ -171,58 +304,50 @@ func (h *invoiceHandler) CreateInvoice(c echo.Context) error {
 		}
 	}
 
-	// Calculate totals
-	subtotal := 0
-	for _, item := range items {
-		subtotal += item.Quantity * item.Price
-	}
-
-	adjustmentsTotal := 0
-	for _, adj := range adjustments {
-		if adj.Type == "addition" {
-			adjustmentsTotal += adj.Amount
-		} else {
-			adjustmentsTotal -= adj.Amount
-		}
-	}
-
-	taxAmount := int(float64(subtotal) * req.TaxRate / 100.0)
-	total := subtotal + taxAmount + adjustmentsTotal
-
-	// Parse bank account ID if provided
-	var bankAccountID *uint
+	// Use the given bank account, or the company's default one
+	var bankAccount *model.BankAccount
 	if req.BankAccountID != nil && *req.BankAccountID != "" {
-		id, err := strconv.ParseUint(*req.BankAccountID, 10, 32)
-		if err == nil {
-			uid := uint(id)
-			bankAccountID = &uid
+		bankAccount, err = h.findBankAccount(c.Request().Context(), member.CompanyID, *req.BankAccountID)
+		if err != nil {
+			logger.Errorf("Error finding bank account: %v", err)
+			return invalidFields(c, newFieldError(c, "/bank_account_id", "exists"))
+		}
+	} else {
+		bankAccount, err = h.companyRepo.FindDefaultBankAccount(c.Request().Context(), member.CompanyID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve bank account: %w", err)
 		}
 	}
 
 	invoice := &model.Invoice{
-		UserID:           userClaims.ID,
-		CustomerName:     req.CustomerName,
-		CustomerEmail:    req.CustomerEmail,
-		DueDate:          dueDate,
-		TaxRate:          req.TaxRate,
-		Status:           req.Status,
-		Subtotal:         subtotal,
-		TaxAmount:        taxAmount,
-		AdjustmentsTotal: adjustmentsTotal,
-		Total:            total,
-		BankAccountID:    bankAccountID,
-		Items:            items,
-		Adjustments:      adjustments,
-	}
-
-	if err := h.invoiceRepo.Create(c.Request().Context(), invoice); err != nil {
-		logger.Errorf("Error creating invoice: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to create invoice",
-		})
+		UserID:        userClaims.ID,
+		CompanyID:     member.CompanyID,
+		CustomerName:  req.CustomerName,
+		CustomerEmail: req.CustomerEmail,
+		DueDate:       dueDate,
+		TaxRate:       req.TaxRate,
+		Status:        req.Status,
+		Items:         items,
+		Adjustments:   adjustments,
+	}
+	invoice.CalculateTotals()
+	setBankAccount(invoice, bankAccount)
+
+	err = h.auditor.Transaction(c.Request().Context(), func(ctx context.Context) error {
+		if err := h.invoiceRepo.Create(ctx, invoice); err != nil {
+			return fmt.Errorf("failed to create invoice: %w", err)
+		}
+		entry := newAuditEntry(c, invoice.CompanyID, model.AuditEntityInvoice, invoice.ID, model.AuditActionCreate)
+		return h.auditor.Record(ctx, entry, nil, invoice.ToInvoiceResponse())
+	})
+	if err != nil {
+		return err
 	}
 
+	h.publish(c, logger, invoice, append([]model.WebhookEvent{model.WebhookEventInvoiceCreated}, statusEvents(invoice.Status)...)...)
+	metrics.InvoiceCreated()
+	metrics.InvoiceStatus(invoice.Status)
+
 	return c.JSON(http.StatusCreated, response{
 		Success: true,
 		Data:    invoice.ToInvoiceResponse(),
@@ -231,15 +356,11 @@ func (h *invoiceHandler) CreateInvoice(c echo.Context) error {
 
 // UpdateInvoice updates an existing invoice
 func (h *invoiceHandler) UpdateInvoice(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "update_invoice")
+	logger := requestLogger(c).WithField("endpoint", "update_invoice")
 
-	userClaims, err := authSession(c)
+	_, err := authSession(c)
 	if err != nil {
-		logger.Errorf("Error getting session: %v", err)
-		return c.JSON(http.StatusUnauthorized, response{
-			Success: false,
-			Message: "unauthorized",
-		})
+		return errUnauthorized
 	}
 
 	idStr := c.Param("id")
@@ -253,30 +374,41 @@ func (h *invoiceHandler) UpdateInvoice(c echo.Context) error {
 
 	invoice, err := h.invoiceRepo.FindByID(c.Request().Context(), uint(id))
 	if err != nil {
-		logger.Errorf("Error finding invoice: %v", err)
-		return c.JSON(http.StatusNotFound, response{
-			Success: false,
-			Message: "invoice not found",
-		})
+		return err
 	}
 
-	// Verify invoice belongs to user
-	if invoice.UserID != userClaims.ID {
-		return c.JSON(http.StatusForbidden, response{
-			Success: false,
-			Message: "access denied",
-		})
+	// Verify invoice belongs to the user's company
+	member, err := activeMembership(c)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve company: %w", err)
+	}
+
+	if member == nil {
+		return repository.Forbidden("access denied")
+	}
+
+	if invoice.CompanyID != member.CompanyID {
+		return repository.NotFound("invoice not found")
+	}
+
+	if !can(c, member, model.PermissionInvoiceWrite) {
+		return errNoPermission
 	}
 
 	var req model.UpdateInvoiceRequest
 	if err := c.Bind(&req); err != nil {
 		logger.Errorf("Error binding request: %v", err)
-		return c.JSON(http.StatusBadRequest, response{
-			Success: false,
-			Message: "invalid request",
-		})
+		return invalidRequest(c)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		logger.Errorf("Validation error: %v", err)
+		return validationFailed(c, err)
 	}
 
+	previousStatus := invoice.Status
+	before := invoice.ToInvoiceResponse()
+
 	// Update fields
 	if req.CustomerName != nil {
 		invoice.CustomerName = *req.CustomerName
@@ -291,10 +423,7 @@ func (h *invoiceHandler) UpdateInvoice(c echo.Context) error {
 		} else {
 			dueDate, err := time.Parse("2006-01-02", *req.DueDate)
 			if err != nil {
-				return c.JSON(http.StatusBadRequest, response{
-					Success: false,
-					Message: "invalid due date format",
-				})
+				return invalidFields(c, newFieldError(c, "/due_date", "date"))
 			}
 			invoice.DueDate = &dueDate
 		}
@@ -351,48 +480,41 @@ func (h *invoiceHandler) UpdateInvoice(c echo.Context) error {
 
 	// Recalculate totals if items, adjustments, or tax rate changed
 	if req.Items != nil || req.Adjustments != nil || req.TaxRate != nil {
-		subtotal := 0
-		for _, item := range invoice.Items {
-			subtotal += item.Quantity * item.Price
-		}
-
-		adjustmentsTotal := 0
-		for _, adj := range invoice.Adjustments {
-			if adj.Type == "addition" {
-				adjustmentsTotal += adj.Amount
-			} else {
-				adjustmentsTotal -= adj.Amount
-			}
-		}
-
-		taxAmount := int(float64(subtotal) * invoice.TaxRate / 100.0)
-		invoice.Subtotal = subtotal
-		invoice.TaxAmount = taxAmount
-		invoice.AdjustmentsTotal = adjustmentsTotal
-		invoice.Total = subtotal + taxAmount + adjustmentsTotal
+		invoice.CalculateTotals()
 	}
 
-	// Update bank account ID if provided
+	// Update bank account if provided, an empty ID removes it
 	if req.BankAccountID != nil {
 		if *req.BankAccountID == "" {
-			invoice.BankAccountID = nil
+			setBankAccount(invoice, nil)
 		} else {
-			id, err := strconv.ParseUint(*req.BankAccountID, 10, 32)
-			if err == nil {
-				uid := uint(id)
-				invoice.BankAccountID = &uid
+			bankAccount, err := h.findBankAccount(c.Request().Context(), invoice.CompanyID, *req.BankAccountID)
+			if err != nil {
+				logger.Errorf("Error finding bank account: %v", err)
+				return invalidFields(c, newFieldError(c, "/bank_account_id", "exists"))
 			}
+			setBankAccount(invoice, bankAccount)
 		}
 	}
 
-	if err := h.invoiceRepo.Update(c.Request().Context(), invoice); err != nil {
-		logger.Errorf("Error updating invoice: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to update invoice",
-		})
+	err = h.auditor.Transaction(c.Request().Context(), func(ctx context.Context) error {
+		if err := h.invoiceRepo.Update(ctx, invoice); err != nil {
+			return fmt.Errorf("failed to update invoice: %w", err)
+		}
+		entry := newAuditEntry(c, invoice.CompanyID, model.AuditEntityInvoice, invoice.ID, model.AuditActionUpdate)
+		return h.auditor.Record(ctx, entry, before, invoice.ToInvoiceResponse())
+	})
+	if err != nil {
+		return err
 	}
 
+	events := []model.WebhookEvent{model.WebhookEventInvoiceUpdated}
+	if invoice.Status != previousStatus {
+		events = append(events, statusEvents(invoice.Status)...)
+		metrics.InvoiceStatus(invoice.Status)
+	}
+	h.publish(c, logger, invoice, events...)
+
 	return c.JSON(http.StatusOK, response{
 		Success: true,
 		Data:    invoice.ToInvoiceResponse(),
@@ -401,15 +523,11 @@ func (h *invoiceHandler) UpdateInvoice(c echo.Context) error {
 
 // DeleteInvoice deletes an invoice
 func (h *invoiceHandler) DeleteInvoice(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "delete_invoice")
+	logger := requestLogger(c).WithField("endpoint", "delete_invoice")
 
-	userClaims, err := authSession(c)
+	_, err := authSession(c)
 	if err != nil {
-		logger.Errorf("Error getting session: %v", err)
-		return c.JSON(http.StatusUnauthorized, response{
-			Success: false,
-			Message: "unauthorized",
-		})
+		return errUnauthorized
 	}
 
 	idStr := c.Param("id")
@@ -423,29 +541,40 @@ func (h *invoiceHandler) DeleteInvoice(c echo.Context) error {
 
 	invoice, err := h.invoiceRepo.FindByID(c.Request().Context(), uint(id))
 	if err != nil {
-		logger.Errorf("Error finding invoice: %v", err)
-		return c.JSON(http.StatusNotFound, response{
-			Success: false,
-			Message: "invoice not found",
-		})
+		return err
 	}
 
-	// Verify invoice belongs to user
-	if invoice.UserID != userClaims.ID {
-		return c.JSON(http.StatusForbidden, response{
-			Success: false,
-			Message: "access denied",
-		})
+	// Verify invoice belongs to the user's company
+	member, err := activeMembership(c)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve company: %w", err)
 	}
 
-	if err := h.invoiceRepo.Delete(c.Request().Context(), uint(id)); err != nil {
-		logger.Errorf("Error deleting invoice: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to delete invoice",
-		})
+	if member == nil {
+		return repository.Forbidden("access denied")
 	}
 
+	if invoice.CompanyID != member.CompanyID {
+		return repository.NotFound("invoice not found")
+	}
+
+	if !can(c, member, model.PermissionInvoiceDelete) {
+		return errNoPermission
+	}
+
+	err = h.auditor.Transaction(c.Request().Context(), func(ctx context.Context) error {
+		if err := h.invoiceRepo.Delete(ctx, uint(id)); err != nil {
+			return fmt.Errorf("failed to delete invoice: %w", err)
+		}
+		entry := newAuditEntry(c, invoice.CompanyID, model.AuditEntityInvoice, invoice.ID, model.AuditActionDelete)
+		return h.auditor.Record(ctx, entry, invoice.ToInvoiceResponse(), nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	h.publish(c, logger, invoice, model.WebhookEventInvoiceDeleted)
+
 	return c.JSON(http.StatusOK, response{
 		Success: true,
 		Message: "invoice deleted successfully",
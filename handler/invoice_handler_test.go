@@ -0,0 +1,409 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/notblessy/bikinota-core/audit"
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository/repositorytest"
+)
+
+const testInvoiceBody = `{
+	"customer_name": "PT Maju Bersama",
+	"customer_email": "finance@majubersama.co.id",
+	"status": "draft",
+	"tax_rate": 11,
+	"items": [
+		{"name": "Logo design", "quantity": 2, "price": 150000},
+		{"name": "Brand guideline", "quantity": 1, "price": 50000}
+	],
+	"adjustments": [
+		{"description": "Travel", "type": "addition", "amount": 25000},
+		{"description": "Discount", "type": "deduction", "amount": 10000}
+	]
+}`
+
+type invoiceFixture struct {
+	handler   *invoiceHandler
+	invoices  *repositorytest.InvoiceRepository
+	companies *repositorytest.CompanyRepository
+	settings  *repositorytest.InvoiceSettingsRepository
+	publisher *recordingPublisher
+	audits    *repositorytest.AuditRepository
+}
+
+func newInvoiceFixture(t *testing.T) invoiceFixture {
+	t.Helper()
+
+	f := invoiceFixture{
+		invoices:  repositorytest.NewInvoiceRepository(),
+		companies: repositorytest.NewCompanyRepository(),
+		settings:  repositorytest.NewInvoiceSettingsRepository(),
+		publisher: &recordingPublisher{},
+		audits:    repositorytest.NewAuditRepository(),
+	}
+	for _, name := range []string{"Acme", "Globex"} {
+		if err := f.companies.Create(context.Background(), &model.Company{UserID: 1, Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.handler = NewInvoiceHandler(f.invoices, nil, f.companies, f.settings, f.publisher, audit.NewTrail(f.audits, repositorytest.Transactor{}))
+	return f
+}
+
+// createInvoice stores an invoice of the company directly in the repository
+func (f invoiceFixture) createInvoice(t *testing.T, companyID uint) *model.Invoice {
+	t.Helper()
+
+	invoice := &model.Invoice{
+		UserID:        1,
+		CompanyID:     companyID,
+		CustomerName:  "CV Cahaya Abadi",
+		CustomerEmail: "admin@cahayaabadi.com",
+		Status:        "draft",
+		Items:         []model.InvoiceItem{{Name: "Landing page", Quantity: 1, Price: 50000000}},
+	}
+	invoice.CalculateTotals()
+	if err := f.invoices.Create(context.Background(), invoice); err != nil {
+		t.Fatal(err)
+	}
+	return invoice
+}
+
+func TestCreateInvoiceTotals(t *testing.T) {
+	f := newInvoiceFixture(t)
+	bankAccount := &model.BankAccount{CompanyID: 1, Country: "ID", BankName: "BCA", AccountName: "Acme", AccountNumber: "1234567890"}
+	if err := f.companies.AddBankAccount(context.Background(), bankAccount); err != nil {
+		t.Fatal(err)
+	}
+
+	status, resp := serve(t, f.handler.CreateInvoice, testRequest{
+		method: http.MethodPost,
+		body:   testInvoiceBody,
+		user:   1,
+		member: &testMember{companyID: 1, role: model.RoleAccountant},
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %+v", status, http.StatusCreated, resp)
+	}
+
+	var invoice model.InvoiceResponse
+	decodeData(t, resp, &invoice)
+
+	// 2 x 150,000 + 50,000, 11% tax, then 25,000 added and 10,000 deducted
+	if invoice.Subtotal != 350000 || invoice.TaxAmount != 38500 || invoice.AdjustmentsTotal != 15000 || invoice.Total != 403500 {
+		t.Errorf("totals = %v / %v / %v / %v, want 350000 / 38500 / 15000 / 403500",
+			invoice.Subtotal, invoice.TaxAmount, invoice.AdjustmentsTotal, invoice.Total)
+	}
+	if invoice.BankAccountID == nil || *invoice.BankAccountID != strconv.FormatUint(uint64(bankAccount.ID), 10) {
+		t.Errorf("bank account = %v, want the company default %d", invoice.BankAccountID, bankAccount.ID)
+	}
+	if len(f.publisher.events) != 1 || f.publisher.events[0] != model.WebhookEventInvoiceCreated {
+		t.Errorf("published %v, want only %s", f.publisher.events, model.WebhookEventInvoiceCreated)
+	}
+}
+
+func TestCreateInvoiceAccess(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    testRequest
+		status int
+	}{
+		{"owner", testRequest{user: 1, member: &testMember{1, model.RoleOwner}}, http.StatusCreated},
+		{"viewer", testRequest{user: 1, member: &testMember{1, model.RoleViewer}}, http.StatusForbidden},
+		{"without company", testRequest{user: 1}, http.StatusNotFound},
+		{"without session", testRequest{}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newInvoiceFixture(t)
+			tt.req.method = http.MethodPost
+			tt.req.body = testInvoiceBody
+
+			status, resp := serve(t, f.handler.CreateInvoice, tt.req)
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d: %+v", status, tt.status, resp)
+			}
+			if status == http.StatusCreated {
+				return
+			}
+			if invoices, _ := f.invoices.FindByCompanyID(context.Background(), 1); len(invoices) != 0 {
+				t.Errorf("invoice created despite status %d", status)
+			}
+		})
+	}
+}
+
+func TestInvoiceChangesFailWithoutAuditEntry(t *testing.T) {
+	f := newInvoiceFixture(t)
+	invoice := f.createInvoice(t, 1)
+	id := strconv.FormatUint(uint64(invoice.ID), 10)
+	f.audits.Err = errors.New("audit log unavailable")
+
+	actions := []struct {
+		name string
+		call func(testRequest) (int, response)
+		req  testRequest
+	}{
+		{"create", func(r testRequest) (int, response) { return serve(t, f.handler.CreateInvoice, r) }, testRequest{method: http.MethodPost, body: testInvoiceBody}},
+		{"update", func(r testRequest) (int, response) { return serve(t, f.handler.UpdateInvoice, r) }, testRequest{method: http.MethodPut, id: id, body: `{"status":"paid"}`}},
+		{"delete", func(r testRequest) (int, response) { return serve(t, f.handler.DeleteInvoice, r) }, testRequest{method: http.MethodDelete, id: id}},
+	}
+
+	for _, a := range actions {
+		t.Run(a.name, func(t *testing.T) {
+			a.req.user, a.req.member = 1, &testMember{companyID: 1, role: model.RoleOwner}
+			if status, resp := a.call(a.req); status != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want %d: %+v", status, http.StatusInternalServerError, resp)
+			}
+		})
+	}
+
+	// Changes are rolled back with their audit entry, so nothing may be
+	// announced either
+	if len(f.publisher.events) != 0 {
+		t.Errorf("published %v for unaudited changes", f.publisher.events)
+	}
+}
+
+func TestInvoiceOwnership(t *testing.T) {
+	f := newInvoiceFixture(t)
+	theirs := f.createInvoice(t, 2)
+	id := strconv.FormatUint(uint64(theirs.ID), 10)
+	member := &testMember{companyID: 1, role: model.RoleOwner}
+
+	actions := []struct {
+		name string
+		call func(testRequest) (int, response)
+		req  testRequest
+	}{
+		{"get", func(r testRequest) (int, response) { return serve(t, f.handler.GetInvoice, r) }, testRequest{}},
+		{"update", func(r testRequest) (int, response) { return serve(t, f.handler.UpdateInvoice, r) }, testRequest{method: http.MethodPut, body: `{"status":"paid"}`}},
+		{"delete", func(r testRequest) (int, response) { return serve(t, f.handler.DeleteInvoice, r) }, testRequest{method: http.MethodDelete}},
+	}
+
+	for _, a := range actions {
+		t.Run(a.name, func(t *testing.T) {
+			a.req.id, a.req.user, a.req.member = id, 1, member
+			if status, resp := a.call(a.req); status != http.StatusNotFound {
+				t.Fatalf("status = %d, want %d: %+v", status, http.StatusNotFound, resp)
+			}
+		})
+	}
+
+	stored, err := f.invoices.FindByID(context.Background(), theirs.ID)
+	if err != nil {
+		t.Fatalf("invoice of the other company is gone: %v", err)
+	}
+	if stored.Status != "draft" {
+		t.Errorf("status = %q, the other company's invoice changed", stored.Status)
+	}
+	if len(f.publisher.events) != 0 {
+		t.Errorf("published %v for a foreign invoice", f.publisher.events)
+	}
+}
+
+func TestUpdateInvoiceRecalculatesTotals(t *testing.T) {
+	f := newInvoiceFixture(t)
+	invoice := f.createInvoice(t, 1)
+	itemID := strconv.FormatUint(uint64(invoice.Items[0].ID), 10)
+
+	status, resp := serve(t, f.handler.UpdateInvoice, testRequest{
+		method: http.MethodPut,
+		id:     strconv.FormatUint(uint64(invoice.ID), 10),
+		body: `{
+			"tax_rate": 10,
+			"status": "sent",
+			"items": [
+				{"id": "` + itemID + `", "name": "Landing page", "quantity": 2, "price": 500000},
+				{"name": "Hosting", "quantity": 12, "price": 25000}
+			],
+			"adjustments": [{"description": "Discount", "type": "deduction", "amount": 100000}]
+		}`,
+		user:   1,
+		member: &testMember{companyID: 1, role: model.RoleAdmin},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %+v", status, http.StatusOK, resp)
+	}
+
+	var updated model.InvoiceResponse
+	decodeData(t, resp, &updated)
+
+	// 2 x 500,000 + 12 x 25,000, 10% tax, then 100,000 deducted
+	if updated.Subtotal != 1300000 || updated.TaxAmount != 130000 || updated.AdjustmentsTotal != -100000 || updated.Total != 1330000 {
+		t.Errorf("totals = %v / %v / %v / %v, want 1300000 / 130000 / -100000 / 1330000",
+			updated.Subtotal, updated.TaxAmount, updated.AdjustmentsTotal, updated.Total)
+	}
+	if len(updated.Items) != 2 || updated.Items[0].ID != itemID {
+		t.Errorf("items = %+v, want the existing item %s kept first", updated.Items, itemID)
+	}
+
+	want := []model.WebhookEvent{model.WebhookEventInvoiceUpdated, model.WebhookEventInvoiceSent}
+	if len(f.publisher.events) != len(want) || f.publisher.events[0] != want[0] || f.publisher.events[1] != want[1] {
+		t.Errorf("published %v, want %v", f.publisher.events, want)
+	}
+}
+
+func TestUpdateInvoiceValidation(t *testing.T) {
+	f := newInvoiceFixture(t)
+	invoice := f.createInvoice(t, 1)
+	id := strconv.FormatUint(uint64(invoice.ID), 10)
+
+	tests := []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{"empty customer name", `{"customer_name":""}`, "/customer_name"},
+		{"invalid email", `{"customer_email":"finance"}`, "/customer_email"},
+		{"invalid status", `{"status":"void"}`, "/status"},
+		{"no items", `{"items":[]}`, "/items"},
+		{"item without quantity", `{"items":[{"name":"Hosting","price":25000}]}`, "/items/0/quantity"},
+		{"invalid adjustment", `{"adjustments":[{"description":"Fee","type":"bonus","amount":1}]}`, "/adjustments/0/type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := serve(t, f.handler.UpdateInvoice, testRequest{
+				method: http.MethodPut,
+				id:     id,
+				body:   tt.body,
+				user:   1,
+				member: &testMember{companyID: 1, role: model.RoleOwner},
+			})
+			if status != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %+v", status, http.StatusBadRequest, resp)
+			}
+			if len(resp.Errors) != 1 || resp.Errors[0].Field != tt.field {
+				t.Errorf("errors = %+v, want one for %s", resp.Errors, tt.field)
+			}
+		})
+	}
+
+	stored, err := f.invoices.FindByID(context.Background(), invoice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.CustomerName != invoice.CustomerName || len(stored.Items) != 1 {
+		t.Errorf("invalid updates changed the invoice: %+v", stored)
+	}
+	if len(f.publisher.events) != 0 {
+		t.Errorf("published %v for rejected updates", f.publisher.events)
+	}
+}
+
+func TestInvoiceHTMLAppliesSettings(t *testing.T) {
+	f := newInvoiceFixture(t)
+	owner := &testMember{companyID: 1, role: model.RoleOwner}
+
+	status, resp := serve(t, f.handler.CreateInvoice, testRequest{method: http.MethodPost, body: testInvoiceBody, user: 1, member: owner})
+	if status != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d: %+v", status, http.StatusCreated, resp)
+	}
+	var created model.InvoiceResponse
+	decodeData(t, resp, &created)
+
+	renderHTML := func(query string) string {
+		t.Helper()
+		rec := record(f.handler.GetInvoiceHTML, testRequest{id: created.ID, query: query, user: 1, member: owner})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("render status = %d: %s", rec.Code, rec.Body.String())
+		}
+		return rec.Body.String()
+	}
+
+	// Companies without saved settings get the built-in defaults
+	if html := renderHTML(""); !strings.Contains(html, model.DefaultInvoiceAccentColor) {
+		t.Errorf("invoice without settings does not use the default accent color %s", model.DefaultInvoiceAccentColor)
+	}
+
+	status, resp = serve(t, NewInvoiceSettingsHandler(f.settings).UpdateInvoiceSettings, testRequest{
+		method: http.MethodPut,
+		body: `{"default_template": "Brand", "templates": [
+			{"name": "Plain", "theme": "minimal", "accent_color": "#111111", "font": "system"},
+			{"name": "Brand", "theme": "modern", "accent_color": "#e11d48", "font": "lora",
+			 "footer_text": "Thank you for your business", "payment_terms": "Due within 14 days", "notes": "Transfer to the account below"}
+		]}`,
+		user:   1,
+		member: owner,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("settings status = %d, want %d: %+v", status, http.StatusOK, resp)
+	}
+
+	// The default template applies to invoices created before the settings
+	html := renderHTML("")
+	for _, want := range []string{"#e11d48", "Lora", "Thank you for your business", "Due within 14 days", "Transfer to the account below"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("invoice with the default template does not contain %q", want)
+		}
+	}
+
+	html = renderHTML("template=plain")
+	if !strings.Contains(html, "#111111") || strings.Contains(html, "Thank you for your business") {
+		t.Error("template query parameter does not select the Plain template")
+	}
+}
+
+func TestInvoiceKeepsBankSnapshot(t *testing.T) {
+	f := newInvoiceFixture(t)
+	owner := &testMember{companyID: 1, role: model.RoleOwner}
+	bankAccount := &model.BankAccount{CompanyID: 1, Country: "ID", BankName: "BCA", AccountName: "Acme", AccountNumber: "1234567890"}
+	if err := f.companies.AddBankAccount(context.Background(), bankAccount); err != nil {
+		t.Fatal(err)
+	}
+	bankAccountID := strconv.FormatUint(uint64(bankAccount.ID), 10)
+
+	status, resp := serve(t, f.handler.CreateInvoice, testRequest{method: http.MethodPost, body: testInvoiceBody, user: 1, member: owner})
+	if status != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d: %+v", status, http.StatusCreated, resp)
+	}
+	var created model.InvoiceResponse
+	decodeData(t, resp, &created)
+
+	companies := NewCompanyHandler(f.companies, nil, nil, audit.NewTrail(f.audits, repositorytest.Transactor{}))
+	wantSnapshot := func(step string) {
+		t.Helper()
+		status, resp := serve(t, f.handler.GetInvoice, testRequest{id: created.ID, user: 1, member: owner})
+		if status != http.StatusOK {
+			t.Fatalf("%s: get status = %d: %+v", step, status, resp)
+		}
+		var invoice model.InvoiceResponse
+		decodeData(t, resp, &invoice)
+		if ba := invoice.BankAccount; ba == nil || ba.BankName != "BCA" || ba.AccountName != "Acme" || ba.AccountNumber != "1234567890" {
+			t.Errorf("%s: bank details = %+v, want BCA / Acme / 1234567890", step, ba)
+		}
+	}
+
+	status, resp = serve(t, companies.UpdateBankAccount, testRequest{
+		method: http.MethodPut,
+		id:     bankAccountID,
+		body:   `{"bank_name": "Mandiri", "account_name": "Acme Corp", "account_number": "9876543210"}`,
+		user:   1,
+		member: owner,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("update bank account status = %d: %+v", status, resp)
+	}
+	wantSnapshot("after editing the account")
+
+	status, resp = serve(t, companies.DeleteBankAccount, testRequest{method: http.MethodDelete, id: bankAccountID, user: 1, member: owner})
+	if status != http.StatusOK {
+		t.Fatalf("delete bank account status = %d: %+v", status, resp)
+	}
+	wantSnapshot("after deleting the account")
+
+	// Updating other fields keeps the snapshot as well
+	status, resp = serve(t, f.handler.UpdateInvoice, testRequest{method: http.MethodPut, id: created.ID, body: `{"status": "sent"}`, user: 1, member: owner})
+	if status != http.StatusOK {
+		t.Fatalf("update invoice status = %d: %+v", status, resp)
+	}
+	wantSnapshot("after updating the invoice")
+}
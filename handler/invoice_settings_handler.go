@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+type invoiceSettingsHandler struct {
+	settingsRepo repository.InvoiceSettingsRepository
+	validate     *validator.Validate
+}
+
+func NewInvoiceSettingsHandler(settingsRepo repository.InvoiceSettingsRepository) *invoiceSettingsHandler {
+	return &invoiceSettingsHandler{
+		settingsRepo: settingsRepo,
+		validate:     newValidator(),
+	}
+}
+
+// GetInvoiceSettings retrieves the invoice presentation settings of the active company
+func (h *invoiceSettingsHandler) GetInvoiceSettings(c echo.Context) error {
+	member, err := activeMembership(c)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, member, model.PermissionCompanyRead) {
+		return errNoPermission
+	}
+
+	settings, err := h.settingsRepo.FindByCompanyID(c.Request().Context(), member.CompanyID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve invoice settings: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    settings.ToInvoiceSettingsResponse(),
+	})
+}
+
+// UpdateInvoiceSettings replaces the invoice presentation settings of the active company
+func (h *invoiceSettingsHandler) UpdateInvoiceSettings(c echo.Context) error {
+	logger := requestLogger(c).WithField("endpoint", "update_invoice_settings")
+
+	var req model.UpdateInvoiceSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return invalidRequest(c)
+	}
+
+	// Normalize before validating so " #FFF " is accepted
+	for i := range req.Templates {
+		req.Templates[i].Name = strings.TrimSpace(req.Templates[i].Name)
+		req.Templates[i].AccentColor = strings.ToLower(strings.TrimSpace(req.Templates[i].AccentColor))
+	}
+	req.DefaultTemplate = strings.TrimSpace(req.DefaultTemplate)
+
+	if err := h.validate.Struct(req); err != nil {
+		logger.Errorf("Validation error: %v", err)
+		return validationFailed(c, err)
+	}
+
+	// Template names must be unique and the default must be one of them
+	names := make(map[string]bool, len(req.Templates))
+	for i, t := range req.Templates {
+		key := strings.ToLower(t.Name)
+		if names[key] {
+			return invalidFields(c, newFieldError(c, fmt.Sprintf("/templates/%d/name", i), "unique"))
+		}
+		names[key] = true
+	}
+
+	if !names[strings.ToLower(req.DefaultTemplate)] {
+		return invalidFields(c, newFieldError(c, "/default_template", "template"))
+	}
+
+	member, err := activeMembership(c)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, member, model.PermissionCompanyWrite) {
+		return errNoPermission
+	}
+
+	settings := &model.InvoiceSettings{
+		CompanyID:       member.CompanyID,
+		DefaultTemplate: req.DefaultTemplate,
+		Templates:       req.Templates,
+	}
+
+	if err := h.settingsRepo.Save(c.Request().Context(), settings); err != nil {
+		return fmt.Errorf("failed to save invoice settings: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    settings.ToInvoiceSettingsResponse(),
+	})
+}
@@ -2,14 +2,24 @@ package handler
 
 import (
 	"errors"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/config"
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+	"github.com/sirupsen/logrus"
 )
 
+// apiKeyPrefix marks API keys so they can be told apart from JWTs in the
+// Authorization header. Keys look like bk_<prefix>_<secret>.
+const apiKeyPrefix = "bk_"
+
+// HeaderAPIKey is an alternative to sending the API key as a Bearer token
+const HeaderAPIKey = "X-API-Key"
+
 type jwtClaims struct {
 	ID    uint   `json:"id"`
 	Email string `json:"email"`
@@ -17,18 +27,54 @@ type jwtClaims struct {
 	jwt.RegisteredClaims
 }
 
-func signJWTToken(id uint, email, name string) (string, error) {
+// challengeClaims identify a user that passed the password check but still has
+// to provide a second factor. They are signed with the same secret but carry a
+// purpose so they are never accepted as a session token.
+type challengeClaims struct {
+	ID      uint   `json:"id"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+const (
+	challengePurpose2FA = "2fa"
+	challengeTokenTTL   = 5 * time.Minute
+)
+
+// tokenSigner signs and validates session and challenge tokens with the
+// configured secret
+type tokenSigner struct {
+	secret     []byte
+	sessionTTL time.Duration
+}
+
+func newTokenSigner(cfg config.JWTConfig) *tokenSigner {
+	return &tokenSigner{
+		secret:     []byte(cfg.Secret),
+		sessionTTL: cfg.SessionTTL,
+	}
+}
+
+func (s *tokenSigner) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, errors.New("unexpected signing method")
+	}
+
+	return s.secret, nil
+}
+
+func (s *tokenSigner) signJWTToken(id uint, email, name string) (string, error) {
 	claims := &jwtClaims{
 		ID:    id,
 		Email: email,
 		Name:  name,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(24*7))),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.sessionTTL)),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	t, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	t, err := token.SignedString(s.secret)
 	if err != nil {
 		return "", err
 	}
@@ -36,14 +82,35 @@ func signJWTToken(id uint, email, name string) (string, error) {
 	return t, nil
 }
 
-func validateToken(tokenString string) (jwtClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
+func (s *tokenSigner) signChallengeToken(id uint) (string, error) {
+	claims := &challengeClaims{
+		ID:      id,
+		Purpose: challengePurpose2FA,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(challengeTokenTTL)),
+		},
+	}
 
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(s.secret)
+}
+
+func (s *tokenSigner) validateChallengeToken(tokenString string) (uint, error) {
+	claims := &challengeClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.keyFunc)
+	if err != nil {
+		return 0, err
+	}
+
+	if !token.Valid || claims.Purpose != challengePurpose2FA || claims.ID == 0 {
+		return 0, errors.New("invalid challenge token")
+	}
+
+	return claims.ID, nil
+}
+
+func (s *tokenSigner) validateToken(tokenString string) (jwtClaims, error) {
+	token, err := jwt.Parse(tokenString, s.keyFunc)
 
 	if err != nil || !token.Valid {
 		return jwtClaims{}, err
@@ -54,6 +121,11 @@ func validateToken(tokenString string) (jwtClaims, error) {
 		return jwtClaims{}, errors.New("invalid token claims")
 	}
 
+	// Challenge tokens are only valid for completing a 2FA login
+	if _, ok := claims["purpose"]; ok {
+		return jwtClaims{}, errors.New("token cannot be used as a session")
+	}
+
 	id, ok := claims["id"].(float64)
 	if !ok {
 		return jwtClaims{}, errors.New("user id not found in claims")
@@ -90,14 +162,66 @@ func authSession(c echo.Context) (jwtClaims, error) {
 	return user, nil
 }
 
-type JWTMiddleware struct{}
+// principal describes who is making the request. Session (JWT) principals are
+// unrestricted; API key principals are limited to the key's scopes and, for
+// company keys, to a single company.
+type principal struct {
+	UserID    uint
+	APIKey    *model.APIKey
+	CompanyID uint // Non-zero for company API keys
+}
+
+// IsAPIKey reports whether the request was authenticated with an API key
+func (p principal) IsAPIKey() bool {
+	return p.APIKey != nil
+}
 
-func NewJWTMiddleware() *JWTMiddleware {
-	return &JWTMiddleware{}
+// HasScope reports whether the principal may exercise the given permission.
+// Role checks are applied separately on the company membership.
+func (p principal) HasScope(permission model.Permission) bool {
+	if p.APIKey == nil {
+		return true
+	}
+	return p.APIKey.HasScope(permission)
 }
 
+func authPrincipal(c echo.Context) (principal, error) {
+	p := c.Get("principal")
+	if p == nil {
+		return principal{}, errors.New("missing session")
+	}
+
+	pr, ok := p.(principal)
+	if !ok {
+		return principal{}, errors.New("invalid session")
+	}
+
+	return pr, nil
+}
+
+type JWTMiddleware struct {
+	apiKeyRepo repository.APIKeyRepository
+	userRepo   repository.UserRepository
+	tokens     *tokenSigner
+}
+
+func NewJWTMiddleware(apiKeyRepo repository.APIKeyRepository, userRepo repository.UserRepository, tokens *tokenSigner) *JWTMiddleware {
+	return &JWTMiddleware{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		tokens:     tokens,
+	}
+}
+
+// ValidateJWT authenticates the request with either a Bearer JWT or an API key
+// (as Bearer token or X-API-Key header) and stores the user and principal in
+// the context
 func (m *JWTMiddleware) ValidateJWT(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		if key := c.Request().Header.Get(HeaderAPIKey); key != "" {
+			return m.validateAPIKey(c, next, key)
+		}
+
 		authHeader := c.Request().Header.Get("Authorization")
 		if authHeader == "" {
 			return c.JSON(401, response{
@@ -114,7 +238,11 @@ func (m *JWTMiddleware) ValidateJWT(next echo.HandlerFunc) echo.HandlerFunc {
 			})
 		}
 
-		user, err := validateToken(token)
+		if strings.HasPrefix(token, apiKeyPrefix) {
+			return m.validateAPIKey(c, next, token)
+		}
+
+		user, err := m.tokens.validateToken(token)
 		if err != nil || user.ID == 0 {
 			return c.JSON(401, response{
 				Success: false,
@@ -123,8 +251,99 @@ func (m *JWTMiddleware) ValidateJWT(next echo.HandlerFunc) echo.HandlerFunc {
 		}
 
 		c.Set("user", user)
+		c.Set("principal", principal{UserID: user.ID})
+		addLogFields(c, logrus.Fields{"user_id": user.ID})
+
+		return next(c)
+	}
+}
+
+func (m *JWTMiddleware) validateAPIKey(c echo.Context, next echo.HandlerFunc, key string) error {
+	logger := requestLogger(c).WithField("middleware", "api_key")
+
+	prefix, ok := parseAPIKey(key)
+	if !ok {
+		return c.JSON(401, response{
+			Success: false,
+			Message: "api key is malformed",
+		})
+	}
+
+	apiKey, err := m.apiKeyRepo.FindByKey(c.Request().Context(), prefix, key)
+	if err != nil {
+		logger.Warnf("Invalid api key %s: %v", prefix, err)
+		return c.JSON(401, response{
+			Success: false,
+			Message: "invalid api key",
+		})
+	}
+
+	if apiKey.IsExpired(time.Now()) {
+		return c.JSON(401, response{
+			Success: false,
+			Message: "api key has expired",
+		})
+	}
+
+	user, err := m.userRepo.FindByID(c.Request().Context(), apiKey.UserID)
+	if err != nil {
+		logger.Warnf("Owner of api key %s not found: %v", prefix, err)
+		return c.JSON(401, response{
+			Success: false,
+			Message: "invalid api key",
+		})
+	}
+
+	if err := m.apiKeyRepo.Touch(c.Request().Context(), apiKey.ID); err != nil {
+		logger.Warnf("Could not record api key usage: %v", err)
+	}
+
+	p := principal{
+		UserID: user.ID,
+		APIKey: apiKey,
+	}
+	if apiKey.CompanyID != nil {
+		p.CompanyID = *apiKey.CompanyID
+	}
+
+	c.Set("user", jwtClaims{
+		ID:    user.ID,
+		Email: user.Email,
+		Name:  user.Name,
+	})
+	c.Set("principal", p)
+	addLogFields(c, logrus.Fields{"user_id": user.ID, "api_key": prefix})
+
+	return next(c)
+}
+
+// RequireSession rejects API key principals. It guards account management
+// endpoints (2FA, API keys, joining companies) that need an interactive login.
+func RequireSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		p, err := authPrincipal(c)
+		if err != nil || p.IsAPIKey() {
+			return c.JSON(403, response{
+				Success: false,
+				Message: "this endpoint requires a user session",
+			})
+		}
 
 		return next(c)
 	}
 }
 
+// parseAPIKey extracts the lookup prefix from a bk_<prefix>_<secret> key
+func parseAPIKey(key string) (string, bool) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return "", false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(key, apiKeyPrefix), "_", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+
+	return parts[0], true
+}
+
@@ -0,0 +1,352 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+type memberHandler struct {
+	memberRepo repository.MemberRepository
+	validate   *validator.Validate
+}
+
+func NewMemberHandler(memberRepo repository.MemberRepository) *memberHandler {
+	return &memberHandler{
+		memberRepo: memberRepo,
+		validate:   newValidator(),
+	}
+}
+
+// ListMembers lists the members of the authenticated user's company
+func (h *memberHandler) ListMembers(c echo.Context) error {
+	member, err := activeMembership(c)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve members: %w", err)
+	}
+
+	if member == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, member, model.PermissionCompanyRead) {
+		return errNoPermission
+	}
+
+	members, err := h.memberRepo.ListByCompanyID(c.Request().Context(), member.CompanyID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve members: %w", err)
+	}
+
+	memberResponses := make([]model.MemberResponse, len(members))
+	for i, m := range members {
+		memberResponses[i] = m.ToMemberResponse()
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    memberResponses,
+	})
+}
+
+// UpdateMember changes the role of a member. Only the owner can grant or
+// revoke the admin role, and the owner's own role cannot be changed.
+func (h *memberHandler) UpdateMember(c echo.Context) error {
+	logger := requestLogger(c).WithField("endpoint", "update_member")
+
+	memberID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: "invalid member id",
+		})
+	}
+
+	var req model.UpdateMemberRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return invalidRequest(c)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		logger.Errorf("Validation error: %v", err)
+		return validationFailed(c, err)
+	}
+
+	actor, err := activeMembership(c)
+	if err != nil {
+		return err
+	}
+	if actor == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, actor, model.PermissionMemberManage) {
+		return errNoPermission
+	}
+
+	target, err := h.memberRepo.FindByID(c.Request().Context(), uint(memberID), actor.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	if target.Role == model.RoleOwner || target.ID == actor.ID {
+		return errNoPermission
+	}
+
+	if actor.Role != model.RoleOwner && (target.Role == model.RoleAdmin || req.Role == model.RoleAdmin) {
+		return errNoPermission
+	}
+
+	target.Role = req.Role
+	if err := h.memberRepo.UpdateRole(c.Request().Context(), target); err != nil {
+		return fmt.Errorf("failed to update member: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    target.ToMemberResponse(),
+	})
+}
+
+// RemoveMember removes a member from the company. Members may always remove
+// themselves, except for the owner who cannot leave their own company.
+func (h *memberHandler) RemoveMember(c echo.Context) error {
+	memberID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: "invalid member id",
+		})
+	}
+
+	actor, err := activeMembership(c)
+	if err != nil {
+		return err
+	}
+	if actor == nil {
+		return repository.NotFound("company not found")
+	}
+
+	target, err := h.memberRepo.FindByID(c.Request().Context(), uint(memberID), actor.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	if target.Role == model.RoleOwner {
+		return errNoPermission
+	}
+
+	if target.ID != actor.ID {
+		if !can(c, actor, model.PermissionMemberManage) {
+			return errNoPermission
+		}
+		if actor.Role != model.RoleOwner && target.Role == model.RoleAdmin {
+			return errNoPermission
+		}
+	}
+
+	if err := h.memberRepo.Delete(c.Request().Context(), target.ID, actor.CompanyID); err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Message: "member removed successfully",
+	})
+}
+
+// ListInvitations lists pending invitations of the company
+func (h *memberHandler) ListInvitations(c echo.Context) error {
+	actor, err := activeMembership(c)
+	if err != nil {
+		return err
+	}
+	if actor == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, actor, model.PermissionMemberManage) {
+		return errNoPermission
+	}
+
+	invitations, err := h.memberRepo.ListInvitations(c.Request().Context(), actor.CompanyID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve invitations: %w", err)
+	}
+
+	invitationResponses := make([]model.InvitationResponse, len(invitations))
+	for i, inv := range invitations {
+		invitationResponses[i] = inv.ToInvitationResponse()
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    invitationResponses,
+	})
+}
+
+// CreateInvitation invites an email address to the company. Invitations are
+// not emailed yet: the token is returned once, to the member who created the
+// invitation, who passes it on to the invitee for AcceptInvitation.
+func (h *memberHandler) CreateInvitation(c echo.Context) error {
+	logger := requestLogger(c).WithField("endpoint", "create_invitation")
+
+	userClaims, err := authSession(c)
+	if err != nil {
+		return errUnauthorized
+	}
+
+	var req model.CreateInvitationRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return invalidRequest(c)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		logger.Errorf("Validation error: %v", err)
+		return validationFailed(c, err)
+	}
+
+	actor, err := activeMembership(c)
+	if err != nil {
+		return err
+	}
+	if actor == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, actor, model.PermissionMemberManage) {
+		return errNoPermission
+	}
+
+	if actor.Role != model.RoleOwner && req.Role == model.RoleAdmin {
+		return errNoPermission
+	}
+
+	token, err := generateInvitationToken()
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	invitation := &model.CompanyInvitation{
+		CompanyID:   actor.CompanyID,
+		Email:       strings.ToLower(strings.TrimSpace(req.Email)),
+		Role:        req.Role,
+		InvitedByID: userClaims.ID,
+		ExpiresAt:   time.Now().Add(invitationTTL),
+	}
+
+	if err := h.memberRepo.CreateInvitation(c.Request().Context(), invitation, token); err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	invitationResponse := invitation.ToInvitationResponse()
+	invitationResponse.Token = token
+
+	return c.JSON(http.StatusCreated, response{
+		Success: true,
+		Data:    invitationResponse,
+	})
+}
+
+// RevokeInvitation deletes a pending invitation
+func (h *memberHandler) RevokeInvitation(c echo.Context) error {
+	invitationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: "invalid invitation id",
+		})
+	}
+
+	actor, err := activeMembership(c)
+	if err != nil {
+		return err
+	}
+	if actor == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, actor, model.PermissionMemberManage) {
+		return errNoPermission
+	}
+
+	if err := h.memberRepo.DeleteInvitation(c.Request().Context(), uint(invitationID), actor.CompanyID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Message: "invitation revoked successfully",
+	})
+}
+
+// AcceptInvitation joins the authenticated user to the inviting company. The
+// invitation must have been issued to the user's email address.
+func (h *memberHandler) AcceptInvitation(c echo.Context) error {
+	logger := requestLogger(c).WithField("endpoint", "accept_invitation")
+
+	userClaims, err := authSession(c)
+	if err != nil {
+		return errUnauthorized
+	}
+
+	var req model.AcceptInvitationRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return invalidRequest(c)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		logger.Errorf("Validation error: %v", err)
+		return validationFailed(c, err)
+	}
+
+	invitation, err := h.memberRepo.FindInvitationByToken(c.Request().Context(), req.Token)
+	if err != nil {
+		logger.Warnf("Invitation not found: %v", err)
+		return err
+	}
+
+	if !strings.EqualFold(invitation.Email, userClaims.Email) {
+		return repository.Forbidden("invitation was issued to a different email address")
+	}
+
+	existing, err := h.memberRepo.FindByCompanyAndUser(c.Request().Context(), invitation.CompanyID, userClaims.ID)
+	if err != nil {
+		return fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	if existing != nil {
+		return repository.Conflict("you are already a member of this company")
+	}
+
+	member, err := h.memberRepo.AcceptInvitation(c.Request().Context(), invitation, userClaims.ID)
+	if err != nil {
+		return fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    member.ToMemberResponse(),
+	})
+}
+
+func generateInvitationToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
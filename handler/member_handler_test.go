@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository/repositorytest"
+)
+
+// invite creates an invitation as a member of company 1 and returns it
+func invite(t *testing.T, h *memberHandler, role model.Role, body string) (int, model.InvitationResponse) {
+	t.Helper()
+	status, resp := serve(t, h.CreateInvitation, testRequest{
+		method: http.MethodPost,
+		body:   body,
+		user:   1,
+		member: &testMember{companyID: 1, role: role},
+	})
+	var invitation model.InvitationResponse
+	if status == http.StatusCreated {
+		decodeData(t, resp, &invitation)
+	}
+	return status, invitation
+}
+
+func TestCreateInvitation(t *testing.T) {
+	h := NewMemberHandler(repositorytest.NewMemberRepository())
+
+	tests := []struct {
+		name       string
+		role       model.Role
+		body       string
+		wantStatus int
+		wantToken  bool
+	}{
+		{"owner invites admin", model.RoleOwner, `{"email":"a@example.com","role":"admin"}`, http.StatusCreated, true},
+		{"admin invites accountant", model.RoleAdmin, `{"email":"b@example.com","role":"accountant"}`, http.StatusCreated, true},
+		{"admin invites admin", model.RoleAdmin, `{"email":"c@example.com","role":"admin"}`, http.StatusForbidden, false},
+		{"accountant", model.RoleAccountant, `{"email":"d@example.com","role":"viewer"}`, http.StatusForbidden, false},
+		{"viewer", model.RoleViewer, `{"email":"e@example.com","role":"viewer"}`, http.StatusForbidden, false},
+		{"owner role", model.RoleOwner, `{"email":"f@example.com","role":"owner"}`, http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, invitation := invite(t, h, tt.role, tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if got := invitation.Token != ""; got != tt.wantToken {
+				t.Errorf("token returned = %v, want %v", got, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	members := repositorytest.NewMemberRepository()
+	h := NewMemberHandler(members)
+
+	_, invitation := invite(t, h, model.RoleOwner, `{"email":"USER@example.com","role":"accountant"}`)
+	_, other := invite(t, h, model.RoleOwner, `{"email":"other@example.com","role":"viewer"}`)
+	members.Add(&model.CompanyMember{CompanyID: 1, UserID: 3, Role: model.RoleViewer})
+
+	accept := func(user uint, token string) (int, response) {
+		return serve(t, h.AcceptInvitation, testRequest{method: http.MethodPost, body: `{"token":"` + token + `"}`, user: user})
+	}
+
+	if status, resp := accept(2, other.Token); status != http.StatusForbidden {
+		t.Errorf("invitation of another email: status = %d, want %d: %+v", status, http.StatusForbidden, resp)
+	}
+	if status, resp := accept(3, invitation.Token); status != http.StatusConflict {
+		t.Errorf("existing member: status = %d, want %d: %+v", status, http.StatusConflict, resp)
+	}
+	if status, resp := accept(2, "unknown"); status != http.StatusNotFound {
+		t.Errorf("unknown token: status = %d, want %d: %+v", status, http.StatusNotFound, resp)
+	}
+
+	status, resp := accept(2, invitation.Token)
+	if status != http.StatusOK {
+		t.Fatalf("accept: status = %d, want %d: %+v", status, http.StatusOK, resp)
+	}
+	member, err := members.FindByCompanyAndUser(context.Background(), 1, 2)
+	if err != nil || member == nil || member.Role != model.RoleAccountant {
+		t.Fatalf("membership after accepting = %+v, %v, want accountant", member, err)
+	}
+
+	if status, resp := accept(4, invitation.Token); status != http.StatusNotFound {
+		t.Errorf("accepted twice: status = %d, want %d: %+v", status, http.StatusNotFound, resp)
+	}
+}
+
+func TestRevokeInvitation(t *testing.T) {
+	h := NewMemberHandler(repositorytest.NewMemberRepository())
+	_, invitation := invite(t, h, model.RoleOwner, `{"email":"user@example.com","role":"viewer"}`)
+
+	tests := []struct {
+		name       string
+		member     *testMember
+		wantStatus int
+	}{
+		{"viewer", &testMember{companyID: 1, role: model.RoleViewer}, http.StatusForbidden},
+		{"admin of another company", &testMember{companyID: 2, role: model.RoleAdmin}, http.StatusNotFound},
+		{"admin", &testMember{companyID: 1, role: model.RoleAdmin}, http.StatusOK},
+		{"already revoked", &testMember{companyID: 1, role: model.RoleAdmin}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := serve(t, h.RevokeInvitation, testRequest{method: http.MethodDelete, id: invitation.ID, user: 1, member: tt.member})
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %+v", status, tt.wantStatus, resp)
+			}
+		})
+	}
+
+	status, resp := serve(t, h.AcceptInvitation, testRequest{method: http.MethodPost, body: `{"token":"` + invitation.Token + `"}`, user: 2})
+	if status != http.StatusNotFound {
+		t.Errorf("accepting a revoked invitation: status = %d, want %d: %+v", status, http.StatusNotFound, resp)
+	}
+}
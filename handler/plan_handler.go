@@ -1,48 +1,55 @@
 package handler
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/audit"
 	"github.com/notblessy/bikinota-core/model"
 	"github.com/notblessy/bikinota-core/repository"
-	"github.com/sirupsen/logrus"
 )
 
 type planHandler struct {
 	planRepo repository.PlanRepository
+	auditor  audit.Recorder
 	validate *validator.Validate
 }
 
-func NewPlanHandler(planRepo repository.PlanRepository) *planHandler {
+func NewPlanHandler(planRepo repository.PlanRepository, auditor audit.Recorder) *planHandler {
 	return &planHandler{
 		planRepo: planRepo,
-		validate: validator.New(),
+		auditor:  auditor,
+		validate: newValidator(),
 	}
 }
 
-// GetPlan retrieves the plan information for the authenticated user
+// GetPlan retrieves the plan information for the active company
 func (h *planHandler) GetPlan(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "get_plan")
-
 	// Get user from JWT middleware
-	userClaims, err := authSession(c)
+	_, err := authSession(c)
 	if err != nil {
-		logger.Errorf("Error getting session: %v", err)
-		return c.JSON(http.StatusUnauthorized, response{
-			Success: false,
-			Message: "unauthorized",
-		})
+		return errUnauthorized
 	}
 
-	plan, err := h.planRepo.FindByUserID(c.Request().Context(), userClaims.ID)
+	member, err := activeMembership(c)
 	if err != nil {
-		logger.Errorf("Error finding plan: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to retrieve plan",
-		})
+		return fmt.Errorf("failed to retrieve plan: %w", err)
+	}
+
+	if member != nil && !can(c, member, model.PermissionCompanyRead) {
+		return errNoPermission
+	}
+
+	// Users without a company are on the free plan
+	var plan *model.Plan
+	if member != nil {
+		plan, err = h.planRepo.FindByCompanyID(c.Request().Context(), member.CompanyID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to retrieve plan: %w", err)
 	}
 
 	// If plan doesn't exist, return default free plan
@@ -63,70 +70,82 @@ func (h *planHandler) GetPlan(c echo.Context) error {
 	})
 }
 
-// UpdatePlan updates the user's plan
+// UpdatePlan updates the plan of the active company
 func (h *planHandler) UpdatePlan(c echo.Context) error {
-	logger := logrus.WithField("endpoint", "update_plan")
+	logger := requestLogger(c).WithField("endpoint", "update_plan")
 
 	// Get user from JWT middleware
 	userClaims, err := authSession(c)
 	if err != nil {
-		logger.Errorf("Error getting session: %v", err)
-		return c.JSON(http.StatusUnauthorized, response{
-			Success: false,
-			Message: "unauthorized",
-		})
+		return errUnauthorized
 	}
 
 	var req model.UpdatePlanRequest
 	if err := c.Bind(&req); err != nil {
 		logger.Errorf("Error parsing request: %v", err)
-		return c.JSON(http.StatusBadRequest, response{
-			Success: false,
-			Message: "invalid request body",
-		})
+		return invalidRequest(c)
 	}
 
-	// Validate plan type
-	if req.PlanType != model.PlanFree && req.PlanType != model.PlanUnlimited {
-		return c.JSON(http.StatusBadRequest, response{
-			Success: false,
-			Message: "invalid plan type. Must be 'free' or 'unlimited'",
-		})
+	if err := h.validate.Struct(req); err != nil {
+		logger.Errorf("Validation error: %v", err)
+		return validationFailed(c, err)
+	}
+
+	member, err := activeMembership(c)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve plan: %w", err)
+	}
+
+	if member == nil {
+		return repository.NotFound("company not found. Please create company first")
+	}
+
+	if !can(c, member, model.PermissionPlanManage) {
+		return errNoPermission
 	}
 
 	// Find or create plan
-	plan, err := h.planRepo.FindByUserID(c.Request().Context(), userClaims.ID)
+	plan, err := h.planRepo.FindByCompanyID(c.Request().Context(), member.CompanyID)
 	if err != nil {
-		logger.Errorf("Error finding plan: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to retrieve plan",
-		})
+		return fmt.Errorf("failed to retrieve plan: %w", err)
 	}
 
 	if plan == nil {
 		// Create new plan with default free plan
 		plan = &model.Plan{
-			UserID:   userClaims.ID,
-			PlanType: model.PlanFree,
+			CompanyID: member.CompanyID,
+			PlanType:  model.PlanFree,
 		}
 	}
 
+	action := model.AuditActionCreate
+	var before interface{}
+	if plan.ID != 0 {
+		action = model.AuditActionUpdate
+		before = plan.ToPlanResponse()
+	}
+
 	// Update plan type
+	plan.UserID = userClaims.ID
 	plan.PlanType = req.PlanType
 
-	if plan.ID == 0 {
-		err = h.planRepo.Create(c.Request().Context(), plan)
-	} else {
-		err = h.planRepo.Update(c.Request().Context(), plan)
-	}
+	err = h.auditor.Transaction(c.Request().Context(), func(ctx context.Context) error {
+		var err error
+		if plan.ID == 0 {
+			err = h.planRepo.Create(ctx, plan)
+		} else {
+			err = h.planRepo.Update(ctx, plan)
+		}
 
+		if err != nil {
+			return fmt.Errorf("failed to save plan: %w", err)
+		}
+
+		entry := newAuditEntry(c, plan.CompanyID, model.AuditEntityPlan, plan.ID, action)
+		return h.auditor.Record(ctx, entry, before, plan.ToPlanResponse())
+	})
 	if err != nil {
-		logger.Errorf("Error saving plan: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "failed to save plan",
-		})
+		return err
 	}
 
 	planResponse := plan.ToPlanResponse()
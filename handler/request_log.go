@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/logging"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestLogger gives each request an ID, taken from a valid X-Request-ID
+// header or generated, and returns it in the response. The request context
+// carries a logger with the ID, the route and the trace ID, which later
+// middlewares extend with the user and company. A line with the outcome is
+// logged once the request is done.
+func RequestLogger(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		req := c.Request()
+
+		id := logging.IncomingRequestID(req)
+		c.Response().Header().Set(logging.HeaderRequestID, id)
+
+		ctx := logging.WithRequestID(req.Context(), id)
+		ctx = logging.WithFields(ctx, logrus.Fields{
+			"method": req.Method,
+			"route":  c.Path(),
+		})
+		if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+			ctx = logging.WithFields(ctx, logrus.Fields{"trace_id": span.TraceID().String()})
+		}
+		c.SetRequest(req.WithContext(ctx))
+
+		// Write errors now, the status isn't known otherwise
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+
+		res := c.Response()
+		logger := requestLogger(c).WithFields(logrus.Fields{
+			"path":        req.URL.Path,
+			"status":      res.Status,
+			"bytes_out":   res.Size,
+			"duration_ms": time.Since(start).Milliseconds(),
+			"remote_ip":   c.RealIP(),
+		})
+		switch {
+		case res.Status >= 500:
+			logger.Error("Request failed")
+		default:
+			logger.Info("Request")
+		}
+
+		return nil
+	}
+}
+
+// requestLogger returns the logger of the request, carrying its ID
+func requestLogger(c echo.Context) *logrus.Entry {
+	return logging.FromContext(c.Request().Context())
+}
+
+// addLogFields adds fields to the logger of the request
+func addLogFields(c echo.Context, fields logrus.Fields) {
+	c.SetRequest(c.Request().WithContext(logging.WithFields(c.Request().Context(), fields)))
+}
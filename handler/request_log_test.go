@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/logging"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestRequestLogger(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"honors incoming id", "req-123.abc", true},
+		{"generates missing id", "", false},
+		{"replaces invalid id", "bad id\nwith newline", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook.Reset()
+
+			e := echo.New()
+			e.Use(RequestLogger)
+			var handlerID string
+			e.GET("/api/invoice/:id", func(c echo.Context) error {
+				handlerID = logging.RequestID(c.Request().Context())
+				addLogFields(c, logrus.Fields{"user_id": uint(7)})
+				requestLogger(c).Info("Handled")
+				return nil
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/invoice/1", nil)
+			if tt.incoming != "" {
+				req.Header.Set(logging.HeaderRequestID, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(logging.HeaderRequestID)
+			if id == "" || id != handlerID {
+				t.Fatalf("response id %q, handler saw %q", id, handlerID)
+			}
+			if (id == tt.incoming) != tt.keep {
+				t.Errorf("id = %q for incoming %q", id, tt.incoming)
+			}
+
+			entries := hook.AllEntries()
+			if len(entries) != 2 {
+				t.Fatalf("%d log entries, want the handler's and the request's", len(entries))
+			}
+			for _, entry := range entries {
+				if entry.Data["request_id"] != id || entry.Data["route"] != "/api/invoice/:id" {
+					t.Errorf("entry %q has fields %v", entry.Message, entry.Data)
+				}
+			}
+			// Fields added by inner middlewares reach the request line
+			if last := hook.LastEntry(); last.Data["user_id"] != uint(7) || last.Data["status"] != http.StatusOK {
+				t.Errorf("request entry has fields %v", last.Data)
+			}
+		})
+	}
+}
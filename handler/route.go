@@ -3,30 +3,49 @@ package handler
 import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/notblessy/bikinota-core/audit"
+	"github.com/notblessy/bikinota-core/config"
+	"github.com/notblessy/bikinota-core/metrics"
 	"github.com/notblessy/bikinota-core/repository"
-	"github.com/notblessy/bikinota-core/utils"
+	"github.com/notblessy/bikinota-core/storage"
+	"github.com/notblessy/bikinota-core/tracing"
+	"github.com/notblessy/bikinota-core/webhook"
 )
 
-func SetupRoutes(e *echo.Echo, userRepo repository.UserRepository, companyRepo repository.CompanyRepository, planRepo repository.PlanRepository, invoiceRepo repository.InvoiceRepository, cloudinaryService interface{}) {
+func SetupRoutes(e *echo.Echo, cfg *config.Config, userRepo repository.UserRepository, companyRepo repository.CompanyRepository, memberRepo repository.MemberRepository, apiKeyRepo repository.APIKeyRepository, planRepo repository.PlanRepository, invoiceRepo repository.InvoiceRepository, settingsRepo repository.InvoiceSettingsRepository, webhookRepo repository.WebhookRepository, auditRepo repository.AuditRepository, transactor repository.Transactor, dispatcher *webhook.Dispatcher, blobStore storage.BlobStore, assets *storage.AssetStore, checks []ReadinessCheck) {
+	// Handlers return errors, which are written as the standard response
+	e.HTTPErrorHandler = HTTPErrorHandler
+
+	// Client IPs of request logs and audit entries
+	e.IPExtractor = ipExtractor(cfg.HTTP.TrustedProxies)
+
+	// Request spans, continuing the caller's trace
+	e.Use(tracing.Middleware())
+
+	// Request metrics, so every response is counted
+	e.Use(metrics.Middleware())
+
+	// Request IDs and request scoped loggers
+	e.Use(RequestLogger)
+
 	// CORS middleware
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
+		AllowOrigins: cfg.HTTP.CORSOrigins,
 		AllowHeaders: []string{
 			echo.HeaderOrigin,
 			echo.HeaderContentType,
 			echo.HeaderAccept,
 			echo.HeaderAuthorization,
+			HeaderCompanyID,
+			HeaderAPIKey,
 		},
 		AllowMethods: []string{echo.GET, echo.POST, echo.PUT, echo.DELETE, echo.PATCH, echo.OPTIONS},
 	}))
 
-	// Logger middleware
-	e.Use(middleware.Logger())
-
 	// Recover middleware
 	e.Use(middleware.Recover())
 
-	// Health check
+	// Liveness check, answers as long as the process is serving
 	e.GET("/ping", func(c echo.Context) error {
 		return c.JSON(200, response{
 			Success: true,
@@ -34,48 +53,113 @@ func SetupRoutes(e *echo.Echo, userRepo repository.UserRepository, companyRepo r
 		})
 	})
 
+	// Readiness check, fails while a dependency is unavailable
+	healthHandler := NewHealthHandler(checks)
+	e.GET("/readyz", healthHandler.Ready)
+
+	// Files of the local blob store
+	if _, ok := storage.Unwrap(blobStore).(*storage.LocalStore); ok {
+		fileHandler := NewFileHandler(blobStore)
+		e.GET(storage.LocalURLPrefix+"*", fileHandler.ServeFile)
+	}
+
+	// Changes to company data are recorded in the audit log
+	auditor := audit.NewTrail(auditRepo, transactor)
+
 	// Auth routes
-	authHandler := NewAuthHandler(userRepo)
+	tokens := newTokenSigner(cfg.JWT)
+	authHandler := NewAuthHandler(userRepo, tokens)
 	auth := e.Group("/api/auth")
 	auth.POST("/register", authHandler.Register)
 	auth.POST("/login", authHandler.Login)
+	auth.POST("/login/2fa", authHandler.VerifyTwoFactorLogin)
 
-	// Protected routes (require JWT)
+	// Protected routes (require JWT or API key)
 	protected := e.Group("/api")
-	protected.Use(NewJWTMiddleware().ValidateJWT)
+	protected.Use(NewJWTMiddleware(apiKeyRepo, userRepo, tokens).ValidateJWT)
+	protected.Use(NewCompanyMiddleware(memberRepo).ResolveCompany)
+
+	// Two-factor authentication routes
+	twoFactor := protected.Group("/auth/2fa", RequireSession)
+	twoFactor.POST("/enroll", authHandler.EnrollTOTP)
+	twoFactor.POST("/confirm", authHandler.ConfirmTOTP)
+	twoFactor.POST("/disable", authHandler.DisableTOTP)
 
 	// Company routes
-	var cloudinarySvc *utils.CloudinaryService
-	if cloudinaryService != nil {
-		cloudinarySvc = cloudinaryService.(*utils.CloudinaryService)
-	}
-	companyHandler := NewCompanyHandler(companyRepo, cloudinarySvc)
+	companyHandler := NewCompanyHandler(companyRepo, memberRepo, assets, auditor)
+	companies := protected.Group("/companies")
+	companies.GET("", companyHandler.ListCompanies)
+	companies.POST("", companyHandler.CreateCompany, RequireSession)
+
 	company := protected.Group("/company")
 	company.GET("", companyHandler.GetCompany)
 	company.PUT("", companyHandler.UpdateCompany)
 	company.POST("/logo", companyHandler.UploadLogo)
 	company.DELETE("/logo", companyHandler.RemoveLogo)
 
+	// Invoice branding routes
+	settingsHandler := NewInvoiceSettingsHandler(settingsRepo)
+	company.GET("/invoice-settings", settingsHandler.GetInvoiceSettings)
+	company.PUT("/invoice-settings", settingsHandler.UpdateInvoiceSettings)
+
 	// Bank account routes
 	bankAccounts := company.Group("/bank-accounts")
 	bankAccounts.POST("", companyHandler.AddBankAccount)
+	bankAccounts.PUT("/order", companyHandler.ReorderBankAccounts)
 	bankAccounts.PUT("/:id", companyHandler.UpdateBankAccount)
 	bankAccounts.DELETE("/:id", companyHandler.DeleteBankAccount)
 	bankAccounts.PUT("/:id/default", companyHandler.SetDefaultBankAccount)
+	bankAccounts.GET("/:id/reveal", companyHandler.RevealBankAccount)
+
+	// Team member routes
+	memberHandler := NewMemberHandler(memberRepo)
+	members := company.Group("/members")
+	members.GET("", memberHandler.ListMembers)
+	members.PUT("/:id", memberHandler.UpdateMember, RequireSession)
+	members.DELETE("/:id", memberHandler.RemoveMember, RequireSession)
+
+	invitations := company.Group("/invitations", RequireSession)
+	invitations.GET("", memberHandler.ListInvitations)
+	invitations.POST("", memberHandler.CreateInvitation)
+	invitations.DELETE("/:id", memberHandler.RevokeInvitation)
+	protected.POST("/invitations/accept", memberHandler.AcceptInvitation, RequireSession)
+
+	// Webhook routes
+	webhookHandler := NewWebhookHandler(webhookRepo, dispatcher, cfg.Webhook)
+	webhooks := company.Group("/webhooks")
+	webhooks.GET("", webhookHandler.ListEndpoints)
+	webhooks.POST("", webhookHandler.CreateEndpoint)
+	webhooks.PUT("/:id", webhookHandler.UpdateEndpoint)
+	webhooks.DELETE("/:id", webhookHandler.DeleteEndpoint)
+	webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+	webhooks.POST("/:id/deliveries/:deliveryId/redeliver", webhookHandler.Redeliver)
+
+	// API key routes
+	apiKeyHandler := NewAPIKeyHandler(apiKeyRepo)
+	apiKeys := protected.Group("/api-keys", RequireSession)
+	apiKeys.GET("", apiKeyHandler.ListAPIKeys)
+	apiKeys.POST("", apiKeyHandler.CreateAPIKey)
+	apiKeys.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
 
 	// Plan routes
-	planHandler := NewPlanHandler(planRepo)
+	planHandler := NewPlanHandler(planRepo, auditor)
 	plan := protected.Group("/plan")
 	plan.GET("", planHandler.GetPlan)
 	plan.PUT("", planHandler.UpdatePlan)
 
 	// Invoice routes
-	invoiceHandler := NewInvoiceHandler(invoiceRepo)
+	invoiceHandler := NewInvoiceHandler(invoiceRepo, memberRepo, companyRepo, settingsRepo, dispatcher, auditor)
 	invoice := protected.Group("/invoice")
 	invoice.GET("", invoiceHandler.GetInvoices)
 	invoice.GET("/:id", invoiceHandler.GetInvoice)
+	invoice.GET("/:id/html", invoiceHandler.GetInvoiceHTML)
 	invoice.POST("", invoiceHandler.CreateInvoice)
 	invoice.PUT("/:id", invoiceHandler.UpdateInvoice)
 	invoice.DELETE("/:id", invoiceHandler.DeleteInvoice)
+
+	// Audit log routes
+	auditHandler := NewAuditHandler(auditRepo)
+	protected.GET("/audit", auditHandler.ListEntries)
+	invoice.GET("/:id/history", auditHandler.InvoiceHistory)
 }
 
@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/metrics"
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+	"github.com/notblessy/bikinota-core/utils"
+)
+
+const (
+	totpIssuer         = "Bikinota"
+	recoveryCodesCount = 10
+
+	// After twoFactorMaxAttempts codes, further codes are refused until no
+	// code was entered for twoFactorLockout
+	twoFactorMaxAttempts = 5
+	twoFactorLockout     = 15 * time.Minute
+)
+
+// errTooManyAttempts is returned while second factor attempts are locked
+var errTooManyAttempts = echo.NewHTTPError(http.StatusTooManyRequests, "too many invalid authentication codes, try again later")
+
+// VerifyTwoFactorLogin exchanges a login challenge token and a TOTP or recovery
+// code for a full session token
+func (h *authHandler) VerifyTwoFactorLogin(c echo.Context) error {
+	logger := requestLogger(c).WithField("endpoint", "verify_two_factor_login")
+
+	var req model.TwoFactorLoginRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return invalidRequest(c)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		logger.Errorf("Validation error: %v", err)
+		return validationFailed(c, err)
+	}
+
+	userID, err := h.tokens.validateChallengeToken(req.ChallengeToken)
+	if err != nil {
+		logger.Warnf("Invalid challenge token: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "challenge token is invalid or expired",
+		})
+	}
+
+	user, err := h.userRepo.FindByID(c.Request().Context(), userID)
+	if err != nil || !user.TOTPEnabled {
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "challenge token is invalid or expired",
+		})
+	}
+
+	ok, err := h.verifySecondFactor(c.Request().Context(), user, req.Code, req.RecoveryCode)
+	if err != nil {
+		return fmt.Errorf("failed to verify code: %w", err)
+	}
+	if !ok {
+		logger.Warnf("Invalid second factor for user: %d", user.ID)
+		metrics.Login(false)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "invalid authentication code",
+		})
+	}
+
+	// Generate JWT token
+	token, err := h.tokens.signJWTToken(user.ID, user.Email, user.Name)
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	metrics.Login(true)
+
+	// Remove password from response
+	user.Password = ""
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data: model.AuthResponse{
+			Token: token,
+			Type:  "Bearer",
+			User:  *user,
+		},
+	})
+}
+
+// EnrollTOTP generates a new TOTP secret for the authenticated user. The secret
+// is not active until it is confirmed with ConfirmTOTP.
+func (h *authHandler) EnrollTOTP(c echo.Context) error {
+	// Get user from JWT middleware
+	userClaims, err := authSession(c)
+	if err != nil {
+		return errUnauthorized
+	}
+
+	user, err := h.userRepo.FindByID(c.Request().Context(), userClaims.ID)
+	if err != nil {
+		return err
+	}
+
+	if user.TOTPEnabled {
+		return repository.Conflict("two-factor authentication is already enabled")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	uri := utils.TOTPAuthURI(totpIssuer, user.Email, secret)
+	qrCode, err := utils.TOTPQRCode(uri)
+	if err != nil {
+		return fmt.Errorf("failed to generate qr code: %w", err)
+	}
+
+	user.TOTPSecret = secret
+	user.TOTPLastStep = 0
+	if err := h.userRepo.Update(c.Request().Context(), user); err != nil {
+		return fmt.Errorf("failed to save secret: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data: model.TOTPEnrollResponse{
+			Secret:     secret,
+			OTPAuthURI: uri,
+			QRCode:     qrCode,
+		},
+	})
+}
+
+// ConfirmTOTP activates 2FA once the user proves their authenticator works and
+// returns a fresh set of recovery codes. The codes are only shown once.
+func (h *authHandler) ConfirmTOTP(c echo.Context) error {
+	logger := requestLogger(c).WithField("endpoint", "confirm_totp")
+
+	// Get user from JWT middleware
+	userClaims, err := authSession(c)
+	if err != nil {
+		return errUnauthorized
+	}
+
+	var req model.TOTPConfirmRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return invalidRequest(c)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		logger.Errorf("Validation error: %v", err)
+		return validationFailed(c, err)
+	}
+
+	user, err := h.userRepo.FindByID(c.Request().Context(), userClaims.ID)
+	if err != nil {
+		return err
+	}
+
+	if user.TOTPEnabled {
+		return repository.Conflict("two-factor authentication is already enabled")
+	}
+
+	if user.TOTPSecret == "" {
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: "two-factor enrollment has not been started",
+		})
+	}
+
+	ok, err := h.verifySecondFactor(c.Request().Context(), user, req.Code, "")
+	if err != nil {
+		return fmt.Errorf("failed to verify code: %w", err)
+	}
+	if !ok {
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: "invalid authentication code",
+		})
+	}
+
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodesCount)
+	if err != nil {
+		return fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	if err := h.userRepo.ReplaceRecoveryCodes(c.Request().Context(), user.ID, codes); err != nil {
+		return fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+
+	// Reload to keep the last used step written by verifySecondFactor
+	user, err = h.userRepo.FindByID(c.Request().Context(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	user.TOTPEnabled = true
+	if err := h.userRepo.Update(c.Request().Context(), user); err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data: model.RecoveryCodesResponse{
+			RecoveryCodes: codes,
+		},
+	})
+}
+
+// DisableTOTP turns 2FA off. It requires the password and a current code (or a
+// recovery code) so a stolen session alone cannot remove the second factor.
+func (h *authHandler) DisableTOTP(c echo.Context) error {
+	logger := requestLogger(c).WithField("endpoint", "disable_totp")
+
+	// Get user from JWT middleware
+	userClaims, err := authSession(c)
+	if err != nil {
+		return errUnauthorized
+	}
+
+	var req model.TOTPDisableRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return invalidRequest(c)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		logger.Errorf("Validation error: %v", err)
+		return validationFailed(c, err)
+	}
+
+	user, err := h.userRepo.FindByID(c.Request().Context(), userClaims.ID)
+	if err != nil {
+		return err
+	}
+
+	if !user.TOTPEnabled {
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: "two-factor authentication is not enabled",
+		})
+	}
+
+	if !repository.VerifyPassword(user.Password, req.Password) {
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "invalid password",
+		})
+	}
+
+	ok, err := h.verifySecondFactor(c.Request().Context(), user, req.Code, req.RecoveryCode)
+	if err != nil {
+		return fmt.Errorf("failed to verify code: %w", err)
+	}
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "invalid authentication code",
+		})
+	}
+
+	if err := h.userRepo.ReplaceRecoveryCodes(c.Request().Context(), user.ID, nil); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	if err := h.userRepo.Update(c.Request().Context(), user); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Message: "two-factor authentication disabled",
+	})
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code.
+// TOTP codes are single use: a code whose time step was already claimed fails.
+// Attempts are capped per user and return errTooManyAttempts once exceeded.
+func (h *authHandler) verifySecondFactor(ctx context.Context, user *model.User, code, recoveryCode string) (bool, error) {
+	allowed, err := h.userRepo.ReserveTwoFactorAttempt(ctx, user.ID, twoFactorMaxAttempts, twoFactorLockout)
+	if err != nil {
+		return false, err
+	}
+	if !allowed {
+		return false, errTooManyAttempts
+	}
+
+	ok, err := h.checkSecondFactor(ctx, user, code, recoveryCode)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, h.userRepo.ResetTwoFactorAttempts(ctx, user.ID)
+}
+
+func (h *authHandler) checkSecondFactor(ctx context.Context, user *model.User, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		step, ok := utils.ValidateTOTPCode(user.TOTPSecret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		return h.userRepo.ClaimTOTPStep(ctx, user.ID, step)
+	}
+
+	if recoveryCode != "" && user.TOTPEnabled {
+		return h.userRepo.UseRecoveryCode(ctx, user.ID, recoveryCode)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository/repositorytest"
+	"github.com/notblessy/bikinota-core/utils"
+)
+
+// twoFactorFixture is a user who enrolled and confirmed 2FA
+type twoFactorFixture struct {
+	handler       *authHandler
+	users         *repositorytest.UserRepository
+	user          *model.User
+	secret        string
+	confirmCode   string // Code used to confirm the enrollment
+	recoveryCodes []string
+}
+
+func newTwoFactorFixture(t *testing.T) twoFactorFixture {
+	t.Helper()
+
+	f := twoFactorFixture{users: repositorytest.NewUserRepository()}
+	f.user = &model.User{Email: "user@example.com", Name: "User", Password: "secret123"}
+	if err := f.users.Create(context.Background(), f.user); err != nil {
+		t.Fatal(err)
+	}
+	f.handler = NewAuthHandler(f.users, testTokens)
+
+	status, resp := serve(t, f.handler.EnrollTOTP, testRequest{method: http.MethodPost, user: f.user.ID})
+	if status != http.StatusOK {
+		t.Fatalf("enroll status = %d: %+v", status, resp)
+	}
+	var enrolled model.TOTPEnrollResponse
+	decodeData(t, resp, &enrolled)
+	f.secret = enrolled.Secret
+
+	f.confirmCode = f.code(t, 0)
+	status, resp = serve(t, f.handler.ConfirmTOTP, testRequest{
+		method: http.MethodPost,
+		body:   `{"code":"` + f.confirmCode + `"}`,
+		user:   f.user.ID,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("confirm status = %d: %+v", status, resp)
+	}
+	var confirmed model.RecoveryCodesResponse
+	decodeData(t, resp, &confirmed)
+	f.recoveryCodes = confirmed.RecoveryCodes
+	return f
+}
+
+// code returns the TOTP code of the step offset periods from now
+func (f twoFactorFixture) code(t *testing.T, offset int64) string {
+	t.Helper()
+	code, err := utils.GenerateTOTPCode(f.secret, utils.TOTPStep(time.Now())+offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// challenge logs in with the password and returns the 2FA challenge token
+func (f twoFactorFixture) challenge(t *testing.T) string {
+	t.Helper()
+	status, resp := serve(t, f.handler.Login, testRequest{
+		method: http.MethodPost,
+		body:   `{"email":"user@example.com","password":"secret123"}`,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("login status = %d: %+v", status, resp)
+	}
+	var challenge model.TwoFactorChallengeResponse
+	decodeData(t, resp, &challenge)
+	if challenge.ChallengeToken == "" {
+		t.Fatalf("login without a challenge: %+v", resp)
+	}
+	return challenge.ChallengeToken
+}
+
+// verify completes a 2FA login with a fresh challenge
+func (f twoFactorFixture) verify(t *testing.T, code, recoveryCode string) (int, response) {
+	t.Helper()
+	body, err := json.Marshal(model.TwoFactorLoginRequest{ChallengeToken: f.challenge(t), Code: code, RecoveryCode: recoveryCode})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serve(t, f.handler.VerifyTwoFactorLogin, testRequest{method: http.MethodPost, body: string(body)})
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	f := newTwoFactorFixture(t)
+
+	challenge := f.challenge(t)
+	if _, err := testTokens.validateToken(challenge); err == nil {
+		t.Error("challenge token accepted as a session token")
+	}
+
+	// Codes are single use
+	if status, resp := f.verify(t, f.confirmCode, ""); status != http.StatusUnauthorized {
+		t.Errorf("replayed code: status = %d, want %d: %+v", status, http.StatusUnauthorized, resp)
+	}
+
+	status, resp := f.verify(t, f.code(t, 1), "")
+	if status != http.StatusOK {
+		t.Fatalf("next code: status = %d, want %d: %+v", status, http.StatusOK, resp)
+	}
+	var auth model.AuthResponse
+	decodeData(t, resp, &auth)
+	if claims, err := testTokens.validateToken(auth.Token); err != nil || claims.ID != f.user.ID {
+		t.Errorf("session token claims %+v: %v", claims, err)
+	}
+
+	// Recovery codes work once
+	if status, resp := f.verify(t, "", f.recoveryCodes[0]); status != http.StatusOK {
+		t.Errorf("recovery code: status = %d, want %d: %+v", status, http.StatusOK, resp)
+	}
+	if status, resp := f.verify(t, "", f.recoveryCodes[0]); status != http.StatusUnauthorized {
+		t.Errorf("reused recovery code: status = %d, want %d: %+v", status, http.StatusUnauthorized, resp)
+	}
+
+	// A forged or expired challenge is rejected before any code is checked
+	status, resp = serve(t, f.handler.VerifyTwoFactorLogin, testRequest{
+		method: http.MethodPost,
+		body:   `{"challenge_token":"invalid","recovery_code":"` + f.recoveryCodes[1] + `"}`,
+	})
+	if status != http.StatusUnauthorized {
+		t.Errorf("invalid challenge: status = %d, want %d: %+v", status, http.StatusUnauthorized, resp)
+	}
+
+	// Disabling needs the password and a second factor
+	for _, tt := range []struct {
+		body   string
+		status int
+	}{
+		{`{"password":"wrong","recovery_code":"` + f.recoveryCodes[1] + `"}`, http.StatusUnauthorized},
+		{`{"password":"secret123","code":"abcdef"}`, http.StatusUnauthorized},
+		{`{"password":"secret123","recovery_code":"` + f.recoveryCodes[1] + `"}`, http.StatusOK},
+	} {
+		status, resp := serve(t, f.handler.DisableTOTP, testRequest{method: http.MethodPost, body: tt.body, user: f.user.ID})
+		if status != tt.status {
+			t.Errorf("disable with %s: status = %d, want %d: %+v", tt.body, status, tt.status, resp)
+		}
+	}
+
+	// Without 2FA, login returns a session right away
+	status, resp = serve(t, f.handler.Login, testRequest{
+		method: http.MethodPost,
+		body:   `{"email":"user@example.com","password":"secret123"}`,
+	})
+	decodeData(t, resp, &auth)
+	if status != http.StatusOK || auth.Token == "" {
+		t.Errorf("login after disabling: status = %d, token %q", status, auth.Token)
+	}
+}
+
+func TestTwoFactorLoginAttemptsAreCapped(t *testing.T) {
+	f := newTwoFactorFixture(t)
+
+	for i := 0; i < twoFactorMaxAttempts; i++ {
+		if status, resp := f.verify(t, "abcdef", ""); status != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d, want %d: %+v", i+1, status, http.StatusUnauthorized, resp)
+		}
+	}
+
+	// Locked out: neither valid codes nor recovery codes are accepted
+	if status, resp := f.verify(t, f.code(t, 1), ""); status != http.StatusTooManyRequests {
+		t.Errorf("valid code when locked: status = %d, want %d: %+v", status, http.StatusTooManyRequests, resp)
+	}
+	if status, resp := f.verify(t, "", f.recoveryCodes[0]); status != http.StatusTooManyRequests {
+		t.Errorf("recovery code when locked: status = %d, want %d: %+v", status, http.StatusTooManyRequests, resp)
+	}
+}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/utils"
+)
+
+// Error codes returned in response.Code
+const (
+	codeInvalidRequest   = "invalid_request"   // The body could not be parsed
+	codeValidationFailed = "validation_failed" // See response.Errors
+)
+
+// fieldError describes an invalid request field. Field is a JSON pointer
+// into the request body, e.g. /items/0/price.
+type fieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+const (
+	langEnglish    = "en"
+	langIndonesian = "id"
+)
+
+// messages holds the translated texts of error responses. Validation rules
+// whose meaning depends on the field type have a ".string" and ".slice"
+// variant; a %s verb receives the rule parameter.
+var messages = map[string]map[string]string{
+	langEnglish: {
+		codeInvalidRequest:   "invalid request body",
+		codeValidationFailed: "validation failed",
+		codeInternalError:    "something went wrong, please try again later",
+
+		"required":       "is required",
+		"email":          "must be a valid email address",
+		"url":            "must be a valid URL",
+		"public_url":     "must point to a public address",
+		"alpha":          "must contain letters only",
+		"hexcolor":       "must be a hex color such as #2563eb",
+		"oneof":          "must be one of: %s",
+		"len":            "must be %s",
+		"len.string":     "must be %s characters long",
+		"len.slice":      "must contain %s items",
+		"min":            "must be at least %s",
+		"min.string":     "must be at least %s characters long",
+		"min.slice":      "must contain at least %s items",
+		"max":            "must be at most %s",
+		"max.string":     "must be at most %s characters long",
+		"max.slice":      "must contain at most %s items",
+		"unique":         "must be unique",
+		"date":           "must be a date in YYYY-MM-DD format",
+		"exists":         "does not exist",
+		"numeric":        "must contain digits only",
+		"iban":           "must be a valid IBAN",
+		"iban_country":   "must be an IBAN from %s",
+		"swift":          "must be an 8 or 11 character SWIFT/BIC code",
+		"swift_country":  "must be a SWIFT/BIC code from %s",
+		"aba_routing":    "must be a valid 9 digit ABA routing number",
+		"bank_code":      "is not a known bank code",
+		"account_length": "must be %s digits long",
+		"image":          "must be a png, jpeg, gif or webp image",
+		"template":       "must be the name of one of the templates",
+
+		"bank_account_order": "must list every bank account exactly once",
+		"invalid":            "is invalid",
+	},
+	langIndonesian: {
+		codeInvalidRequest:   "isi permintaan tidak valid",
+		codeValidationFailed: "validasi gagal",
+		codeInternalError:    "terjadi kesalahan, silakan coba lagi nanti",
+
+		"required":       "wajib diisi",
+		"email":          "harus berupa alamat email yang valid",
+		"url":            "harus berupa URL yang valid",
+		"public_url":     "harus mengarah ke alamat publik",
+		"alpha":          "hanya boleh berisi huruf",
+		"hexcolor":       "harus berupa warna hex seperti #2563eb",
+		"oneof":          "harus salah satu dari: %s",
+		"len":            "harus %s",
+		"len.string":     "harus %s karakter",
+		"len.slice":      "harus berisi %s item",
+		"min":            "minimal %s",
+		"min.string":     "minimal %s karakter",
+		"min.slice":      "harus berisi minimal %s item",
+		"max":            "maksimal %s",
+		"max.string":     "maksimal %s karakter",
+		"max.slice":      "harus berisi maksimal %s item",
+		"unique":         "harus unik",
+		"date":           "harus berupa tanggal dengan format YYYY-MM-DD",
+		"exists":         "tidak ditemukan",
+		"numeric":        "hanya boleh berisi angka",
+		"iban":           "harus berupa IBAN yang valid",
+		"iban_country":   "harus berupa IBAN dari %s",
+		"swift":          "harus berupa kode SWIFT/BIC 8 atau 11 karakter",
+		"swift_country":  "harus berupa kode SWIFT/BIC dari %s",
+		"aba_routing":    "harus berupa nomor routing ABA 9 digit yang valid",
+		"bank_code":      "bukan kode bank yang dikenal",
+		"account_length": "harus %s digit",
+		"image":          "harus berupa gambar png, jpeg, gif atau webp",
+		"template":       "harus berupa nama salah satu template",
+
+		"bank_account_order": "harus mencantumkan setiap rekening bank tepat satu kali",
+		"invalid":            "tidak valid",
+	},
+}
+
+// newValidator returns a validator that reports fields by their JSON names
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// requestLanguage picks the response language from the Accept-Language header
+func requestLanguage(c echo.Context) string {
+	for _, part := range strings.Split(c.Request().Header.Get("Accept-Language"), ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		switch {
+		case tag == "id" || strings.HasPrefix(tag, "id-") || tag == "in":
+			return langIndonesian
+		case tag == "en" || strings.HasPrefix(tag, "en-"):
+			return langEnglish
+		}
+	}
+	return langEnglish
+}
+
+// translate returns the message for key in lang, falling back to English
+func translate(lang, key string, args ...interface{}) string {
+	msg, ok := messages[lang][key]
+	if !ok {
+		msg, ok = messages[langEnglish][key]
+	}
+	if !ok {
+		return translate(lang, "invalid")
+	}
+	if strings.Contains(msg, "%s") && len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// invalidRequest responds to a request body that could not be parsed
+func invalidRequest(c echo.Context) error {
+	return c.JSON(http.StatusBadRequest, response{
+		Success: false,
+		Code:    codeInvalidRequest,
+		Message: translate(requestLanguage(c), codeInvalidRequest),
+	})
+}
+
+// validationFailed responds with the field errors of a failed struct validation
+func validationFailed(c echo.Context, err error) error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return invalidRequest(c)
+	}
+
+	return invalidFields(c, validationFieldErrors(requestLanguage(c), validationErrs)...)
+}
+
+// validationFieldErrors translates the errors of a failed struct validation
+func validationFieldErrors(lang string, errs validator.ValidationErrors) []fieldError {
+	fields := make([]fieldError, len(errs))
+	for i, fe := range errs {
+		fields[i] = fieldError{
+			Field:   jsonPointer(fe.Namespace()),
+			Rule:    fe.Tag(),
+			Message: translate(lang, messageKey(fe), fe.Param()),
+		}
+	}
+	return fields
+}
+
+// invalidFields responds with field errors found by handler checks
+func invalidFields(c echo.Context, fields ...fieldError) error {
+	return c.JSON(http.StatusBadRequest, response{
+		Success: false,
+		Code:    codeValidationFailed,
+		Message: translate(requestLanguage(c), codeValidationFailed),
+		Errors:  fields,
+	})
+}
+
+// newFieldError builds a translated field error for a handler check. Field is
+// a JSON pointer.
+func newFieldError(c echo.Context, field, rule string, params ...interface{}) fieldError {
+	return fieldError{
+		Field:   field,
+		Rule:    rule,
+		Message: translate(requestLanguage(c), rule, params...),
+	}
+}
+
+// bankAccountFieldErrors converts bank account validation results
+func bankAccountFieldErrors(c echo.Context, errs []utils.FieldError) []fieldError {
+	fields := make([]fieldError, len(errs))
+	for i, e := range errs {
+		fields[i] = newFieldError(c, "/"+e.Field, e.Rule, e.Param)
+	}
+	return fields
+}
+
+// messageKey selects the type specific message of length rules
+func messageKey(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "len", "min", "max":
+		switch fe.Kind() {
+		case reflect.String:
+			return fe.Tag() + ".string"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return fe.Tag() + ".slice"
+		}
+	}
+	return fe.Tag()
+}
+
+// jsonPointer turns a validator namespace such as
+// CreateInvoiceRequest.items[0].price into /items/0/price
+func jsonPointer(namespace string) string {
+	// The first segment is the name of the validated struct
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		namespace = namespace[i+1:]
+	} else {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, segment := range strings.Split(namespace, ".") {
+		for segment != "" {
+			open := strings.IndexByte(segment, '[')
+			if open < 0 {
+				b.WriteString("/" + escapePointer(segment))
+				break
+			}
+			if open > 0 {
+				b.WriteString("/" + escapePointer(segment[:open]))
+			}
+			end := strings.IndexByte(segment, ']')
+			if end < open {
+				break
+			}
+			b.WriteString("/" + escapePointer(segment[open+1:end]))
+			segment = segment[end+1:]
+		}
+	}
+	return b.String()
+}
+
+// escapePointer escapes a JSON pointer reference token (RFC 6901)
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/model"
+)
+
+func TestJSONPointer(t *testing.T) {
+	tests := map[string]string{
+		"CreateInvoiceRequest.customer_email":    "/customer_email",
+		"CreateInvoiceRequest.items[2].price":    "/items/2/price",
+		"UpdateInvoiceSettingsRequest.templates": "/templates",
+		"Request.meta[a/b].value":                "/meta/a~1b/value",
+		"Request":                                "",
+	}
+	for namespace, want := range tests {
+		if got := jsonPointer(namespace); got != want {
+			t.Errorf("jsonPointer(%q) = %q, want %q", namespace, got, want)
+		}
+	}
+}
+
+func TestValidationFailed(t *testing.T) {
+	req := model.CreateInvoiceRequest{
+		CustomerName:  "Budi",
+		CustomerEmail: "not-an-email",
+		Status:        "draft",
+		Items:         []model.CreateInvoiceItemRequest{{Name: "Design", Quantity: 0, Price: 10}},
+	}
+	err := newValidator().Struct(req)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/", nil)
+	httpReq.Header.Set("Accept-Language", "id-ID,id;q=0.9,en;q=0.8")
+	rec := httptest.NewRecorder()
+	if err := validationFailed(echo.New().NewContext(httpReq, rec), err); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+
+	var body response
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != codeValidationFailed || body.Message != "validasi gagal" {
+		t.Errorf("unexpected code %q and message %q", body.Code, body.Message)
+	}
+
+	want := []fieldError{
+		{Field: "/customer_email", Rule: "email", Message: "harus berupa alamat email yang valid"},
+		{Field: "/items/0/quantity", Rule: "required", Message: "wajib diisi"},
+	}
+	if len(body.Errors) != len(want) {
+		t.Fatalf("errors = %+v, want %+v", body.Errors, want)
+	}
+	for i := range want {
+		if body.Errors[i] != want[i] {
+			t.Errorf("error %d = %+v, want %+v", i, body.Errors[i], want[i])
+		}
+	}
+}
+
+func TestTranslateFallsBackToEnglish(t *testing.T) {
+	if got := translate(langIndonesian, "min.string", "6"); got != "minimal 6 karakter" {
+		t.Errorf("got %q", got)
+	}
+	if got := translate("fr", "required"); got != "is required" {
+		t.Errorf("got %q", got)
+	}
+}
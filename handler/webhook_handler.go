@@ -0,0 +1,362 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/bikinota-core/config"
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+	"github.com/notblessy/bikinota-core/webhook"
+)
+
+const webhookDeliveriesLimit = 100
+
+type webhookHandler struct {
+	webhookRepo          repository.WebhookRepository
+	dispatcher           *webhook.Dispatcher
+	allowPrivateNetworks bool
+	validate             *validator.Validate
+}
+
+func NewWebhookHandler(webhookRepo repository.WebhookRepository, dispatcher *webhook.Dispatcher, cfg config.WebhookConfig) *webhookHandler {
+	return &webhookHandler{
+		webhookRepo:          webhookRepo,
+		dispatcher:           dispatcher,
+		allowPrivateNetworks: cfg.AllowPrivateNetworks,
+		validate:             newValidator(),
+	}
+}
+
+// ListEndpoints lists the webhook endpoints of the active company
+func (h *webhookHandler) ListEndpoints(c echo.Context) error {
+	member, err := activeMembership(c)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, member, model.PermissionWebhookManage) {
+		return errNoPermission
+	}
+
+	endpoints, err := h.webhookRepo.ListEndpoints(c.Request().Context(), member.CompanyID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve webhook endpoints: %w", err)
+	}
+
+	endpointResponses := make([]model.WebhookEndpointResponse, len(endpoints))
+	for i, e := range endpoints {
+		endpointResponses[i] = e.ToWebhookEndpointResponse()
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    endpointResponses,
+	})
+}
+
+// CreateEndpoint registers a webhook endpoint. The signing secret is returned
+// only once.
+func (h *webhookHandler) CreateEndpoint(c echo.Context) error {
+	logger := requestLogger(c).WithField("endpoint", "create_webhook_endpoint")
+
+	var req model.CreateWebhookEndpointRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return invalidRequest(c)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		logger.Errorf("Validation error: %v", err)
+		return validationFailed(c, err)
+	}
+
+	if fields := h.validateEndpoint(c, req.URL, req.Events); len(fields) > 0 {
+		return invalidFields(c, fields...)
+	}
+
+	member, err := activeMembership(c)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, member, model.PermissionWebhookManage) {
+		return errNoPermission
+	}
+
+	secret, err := webhook.GenerateSecret()
+	if err != nil {
+		return fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+
+	endpoint := &model.WebhookEndpoint{
+		CompanyID: member.CompanyID,
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    req.Events,
+		Enabled:   true,
+	}
+
+	if err := h.webhookRepo.CreateEndpoint(c.Request().Context(), endpoint); err != nil {
+		return fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+
+	endpointResponse := endpoint.ToWebhookEndpointResponse()
+	endpointResponse.Secret = secret
+
+	return c.JSON(http.StatusCreated, response{
+		Success: true,
+		Data:    endpointResponse,
+	})
+}
+
+// UpdateEndpoint changes the URL or events of an endpoint, or enables and
+// disables it
+func (h *webhookHandler) UpdateEndpoint(c echo.Context) error {
+	logger := requestLogger(c).WithField("endpoint", "update_webhook_endpoint")
+
+	endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: "invalid webhook endpoint id",
+		})
+	}
+
+	var req model.UpdateWebhookEndpointRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return invalidRequest(c)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		logger.Errorf("Validation error: %v", err)
+		return validationFailed(c, err)
+	}
+
+	member, err := activeMembership(c)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, member, model.PermissionWebhookManage) {
+		return errNoPermission
+	}
+
+	endpoint, err := h.webhookRepo.FindEndpointByID(c.Request().Context(), uint(endpointID), member.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	// Update fields if provided
+	if req.URL != nil {
+		endpoint.URL = *req.URL
+	}
+	if req.Events != nil {
+		endpoint.Events = req.Events
+	}
+	if req.Enabled != nil {
+		if *req.Enabled && !endpoint.Enabled {
+			endpoint.FailureCount = 0
+			endpoint.DisabledAt = nil
+		}
+		endpoint.Enabled = *req.Enabled
+	}
+
+	if fields := h.validateEndpoint(c, endpoint.URL, endpoint.Events); len(fields) > 0 {
+		return invalidFields(c, fields...)
+	}
+
+	if err := h.webhookRepo.UpdateEndpoint(c.Request().Context(), endpoint); err != nil {
+		return fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    endpoint.ToWebhookEndpointResponse(),
+	})
+}
+
+// DeleteEndpoint removes an endpoint; its pending deliveries are dropped
+func (h *webhookHandler) DeleteEndpoint(c echo.Context) error {
+	endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: "invalid webhook endpoint id",
+		})
+	}
+
+	member, err := activeMembership(c)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, member, model.PermissionWebhookManage) {
+		return errNoPermission
+	}
+
+	if err := h.webhookRepo.DeleteEndpoint(c.Request().Context(), uint(endpointID), member.CompanyID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Message: "webhook endpoint deleted successfully",
+	})
+}
+
+// ListDeliveries returns the delivery log of an endpoint, newest first
+func (h *webhookHandler) ListDeliveries(c echo.Context) error {
+	endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: "invalid webhook endpoint id",
+		})
+	}
+
+	member, err := activeMembership(c)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, member, model.PermissionWebhookManage) {
+		return errNoPermission
+	}
+
+	endpoint, err := h.webhookRepo.FindEndpointByID(c.Request().Context(), uint(endpointID), member.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := h.webhookRepo.ListDeliveries(c.Request().Context(), endpoint.ID, webhookDeliveriesLimit)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve webhook deliveries: %w", err)
+	}
+
+	deliveryResponses := make([]model.WebhookDeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		deliveryResponses[i] = d.ToWebhookDeliveryResponse()
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    deliveryResponses,
+	})
+}
+
+// Redeliver queues a logged delivery to be sent again
+func (h *webhookHandler) Redeliver(c echo.Context) error {
+	endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: "invalid webhook endpoint id",
+		})
+	}
+
+	deliveryID, err := strconv.ParseUint(c.Param("deliveryId"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: "invalid webhook delivery id",
+		})
+	}
+
+	member, err := activeMembership(c)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return repository.NotFound("company not found")
+	}
+
+	if !can(c, member, model.PermissionWebhookManage) {
+		return errNoPermission
+	}
+
+	endpoint, err := h.webhookRepo.FindEndpointByID(c.Request().Context(), uint(endpointID), member.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	if !endpoint.Enabled {
+		return repository.Conflict("webhook endpoint is disabled")
+	}
+
+	delivery, err := h.webhookRepo.FindDeliveryByID(c.Request().Context(), uint(deliveryID), endpoint.ID)
+	if err != nil {
+		return err
+	}
+
+	redelivery, err := h.dispatcher.Redeliver(c.Request().Context(), delivery)
+	if err != nil {
+		return fmt.Errorf("failed to queue redelivery: %w", err)
+	}
+
+	return c.JSON(http.StatusAccepted, response{
+		Success: true,
+		Data:    redelivery.ToWebhookDeliveryResponse(),
+	})
+}
+
+// validateEndpoint returns the field errors of invalid endpoint settings. The
+// URL must resolve to public addresses only, unless private networks are
+// allowed.
+func (h *webhookHandler) validateEndpoint(c echo.Context, rawURL string, events []model.WebhookEvent) []fieldError {
+	var fields []fieldError
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		fields = append(fields, newFieldError(c, "/url", "url"))
+	} else if !h.allowPrivateNetworks {
+		if err := webhook.CheckHost(c.Request().Context(), u.Hostname()); err != nil {
+			requestLogger(c).Warnf("Rejected webhook endpoint %s: %v", u.Hostname(), err)
+			fields = append(fields, newFieldError(c, "/url", "public_url"))
+		}
+	}
+
+	if len(events) == 0 {
+		fields = append(fields, newFieldError(c, "/events", "required"))
+	}
+
+	known := make([]string, len(model.WebhookEvents))
+	for i, e := range model.WebhookEvents {
+		known[i] = string(e)
+	}
+	for i, event := range events {
+		valid := false
+		for _, e := range model.WebhookEvents {
+			if e == event {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			fields = append(fields, newFieldError(c, fmt.Sprintf("/events/%d", i), "oneof", strings.Join(known, " ")))
+		}
+	}
+
+	return fields
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/notblessy/bikinota-core/config"
+	"github.com/notblessy/bikinota-core/model"
+)
+
+func TestCreateWebhookEndpointRejectsPrivateAddresses(t *testing.T) {
+	h := NewWebhookHandler(nil, nil, config.WebhookConfig{})
+
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"https://10.0.0.5/hook",
+	} {
+		status, resp := serve(t, h.CreateEndpoint, testRequest{
+			method: http.MethodPost,
+			body:   `{"url":"` + url + `","events":["invoice.created"]}`,
+			user:   1,
+			member: &testMember{companyID: 1, role: model.RoleOwner},
+		})
+		if status != http.StatusBadRequest || len(resp.Errors) == 0 {
+			t.Errorf("%s: status = %d, want %d: %+v", url, status, http.StatusBadRequest, resp)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package logging configures the logger and carries request scoped log fields,
+// such as the request ID, through contexts
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+
+	"github.com/notblessy/bikinota-core/config"
+	"github.com/sirupsen/logrus"
+)
+
+// HeaderRequestID correlates a request across services and log lines
+const HeaderRequestID = "X-Request-ID"
+
+// Request IDs are chosen by clients, only harmless ones are taken over
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type loggerKey struct{}
+type requestIDKey struct{}
+
+// Setup configures the level and format of the standard logger
+func Setup(cfg config.LogConfig) error {
+	level, err := logrus.ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	logrus.SetLevel(level)
+
+	if cfg.Format == "json" {
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	} else {
+		logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	}
+	return nil
+}
+
+// FromContext returns the logger of ctx, or the standard logger when ctx has
+// none
+func FromContext(ctx context.Context) *logrus.Entry {
+	if logger, ok := ctx.Value(loggerKey{}).(*logrus.Entry); ok {
+		return logger
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
+
+// WithFields returns a copy of ctx whose logger adds fields
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	return context.WithValue(ctx, loggerKey{}, FromContext(ctx).WithFields(fields))
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID, which is also
+// added to its logger
+func WithRequestID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return WithFields(ctx, logrus.Fields{"request_id": id})
+}
+
+// RequestID returns the request ID of ctx, empty when it has none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random request ID
+func NewRequestID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// IncomingRequestID returns the request ID sent by the client, or a new one
+// when it sent none or an invalid one
+func IncomingRequestID(r *http.Request) string {
+	if id := r.Header.Get(HeaderRequestID); validRequestID.MatchString(id) {
+		return id
+	}
+	return NewRequestID()
+}
+
+// Transport forwards the request ID of the request context to the called
+// service
+type Transport struct {
+	Base http.RoundTripper // http.DefaultTransport when nil
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if id := RequestID(req.Context()); id != "" && req.Header.Get(HeaderRequestID) == "" {
+		// A RoundTripper must not modify the caller's request
+		req = req.Clone(req.Context())
+		req.Header.Set(HeaderRequestID, id)
+	}
+	return base.RoundTrip(req)
+}
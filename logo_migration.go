@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+	"github.com/notblessy/bikinota-core/storage"
+	"github.com/notblessy/bikinota-core/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// legacyLogo is a company row still holding its logo in the old text columns
+type legacyLogo struct {
+	ID        uint
+	UserID    uint
+	Logo      string
+	LogoThumb string
+}
+
+const logosUsage = `usage: logos migrate
+
+Moves company logos kept in the legacy companies.logo column into assets.
+The server runs it before applying migration 0011, which drops the column,
+when storage is configured. Run it by hand otherwise, or to retry logos the
+server could not migrate.`
+
+// runLogos migrates legacy company logos
+func runLogos(args []string) {
+	if len(args) == 0 || args[0] != "migrate" {
+		usageExit(logosUsage)
+	}
+
+	a := newApp(loadConfig(nil))
+	store, err := storage.New(a.cfg.Storage)
+	if err != nil {
+		logrus.Fatalf("Failed to initialize storage: %v", err)
+	}
+
+	migrated, failed, err := migrateLegacyLogos(context.Background(), a.db, store)
+	if err != nil {
+		logrus.Fatalf("Failed to migrate logos: %v", err)
+	}
+
+	fmt.Printf("migrated %d company logos\n", migrated)
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d company logos could not be migrated, see the log\n", failed)
+		os.Exit(1)
+	}
+}
+
+// migrateLegacyLogos moves logos kept in the former companies.logo and
+// companies.logo_thumb columns into assets and returns how many companies
+// were migrated and how many failed. Base64 data is processed and stored like
+// a fresh upload; URLs point at files store already holds and are recorded
+// under the key store serves them at. Migrated rows are cleared, so running it
+// again only retries the failures.
+func migrateLegacyLogos(ctx context.Context, db *gorm.DB, store storage.BlobStore) (int, int, error) {
+	migrator := db.Migrator()
+	if !migrator.HasColumn(&model.Company{}, "logo") {
+		return 0, 0, nil
+	}
+	hasThumb := migrator.HasColumn(&model.Company{}, "logo_thumb")
+
+	thumbColumn := "''"
+	if hasThumb {
+		thumbColumn = "logo_thumb"
+	}
+
+	var rows []legacyLogo
+	err := db.WithContext(ctx).
+		Table("companies").
+		Select("id, user_id, logo, " + thumbColumn + " AS logo_thumb").
+		Where("logo IS NOT NULL AND logo <> ''").
+		Scan(&rows).Error
+	if err != nil {
+		return 0, 0, fmt.Errorf("reading legacy logos: %w", err)
+	}
+
+	migrated, failed := 0, 0
+	for _, row := range rows {
+		if err := migrateCompanyLogo(ctx, db, store, row, hasThumb); err != nil {
+			logrus.Warnf("Could not migrate logo of company %d: %v", row.ID, err)
+			failed++
+			continue
+		}
+		migrated++
+	}
+
+	return migrated, failed, nil
+}
+
+// migrateCompanyLogo moves the logo of one company in a transaction. Blobs are
+// uploaded before it commits and deleted again when it is rolled back. The row
+// is locked, as server replicas starting together migrate the same logos.
+func migrateCompanyLogo(ctx context.Context, db *gorm.DB, store storage.BlobStore, row legacyLogo, hasThumb bool) error {
+	var uploaded []string
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current string
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Table("companies").
+			Select("logo").
+			Where("id = ?", row.ID).
+			Scan(&current).Error
+		if err != nil {
+			return err
+		}
+		if current != row.Logo {
+			// Migrated by someone else meanwhile
+			return nil
+		}
+
+		updates := map[string]interface{}{"logo": ""}
+		if hasThumb {
+			updates["logo_thumb"] = ""
+		}
+
+		if isURL(row.Logo) {
+			logoAsset, err := legacyURLAsset(ctx, store, row, row.Logo)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(logoAsset).Error; err != nil {
+				return err
+			}
+			updates["logo_asset_id"] = logoAsset.ID
+
+			if isURL(row.LogoThumb) {
+				thumbAsset, err := legacyURLAsset(ctx, store, row, row.LogoThumb)
+				if err != nil {
+					return err
+				}
+				if err := tx.Create(thumbAsset).Error; err != nil {
+					return err
+				}
+				updates["logo_thumb_asset_id"] = thumbAsset.ID
+			}
+
+			return tx.Table("companies").Where("id = ?", row.ID).Updates(updates).Error
+		}
+
+		// Anything else is inline base64 data
+		logo, err := processLegacyLogo(row.Logo)
+		if err != nil {
+			// Not a usable image, the logo is dropped
+			logrus.Warnf("Dropping invalid logo of company %d: %v", row.ID, err)
+			return tx.Table("companies").Where("id = ?", row.ID).Updates(updates).Error
+		}
+
+		assets := storage.NewAssetStore(store, repository.NewAssetRepository(tx))
+		logoAsset, err := assets.Save(ctx, row.ID, row.UserID, "company-logos", logo.Logo.Data, logo.Logo.ContentType)
+		if err != nil {
+			return err
+		}
+		uploaded = append(uploaded, logoAsset.Key)
+
+		thumbAsset, err := assets.Save(ctx, row.ID, row.UserID, "company-logos", logo.Thumbnail.Data, logo.Thumbnail.ContentType)
+		if err != nil {
+			return err
+		}
+		uploaded = append(uploaded, thumbAsset.Key)
+
+		updates["logo_asset_id"] = logoAsset.ID
+		updates["logo_thumb_asset_id"] = thumbAsset.ID
+		return tx.Table("companies").Where("id = ?", row.ID).Updates(updates).Error
+	})
+
+	if err != nil {
+		// The asset rows are gone with the transaction, so the sweeper would
+		// never find these blobs
+		for _, key := range uploaded {
+			if delErr := store.Delete(ctx, key); delErr != nil {
+				logrus.Warnf("Could not delete blob %s of company %d: %v", key, row.ID, delErr)
+			}
+		}
+	}
+	return err
+}
+
+func processLegacyLogo(value string) (*utils.ProcessedLogo, error) {
+	data, err := utils.DecodeImageData(value)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ProcessLogo(data)
+}
+
+// legacyURLAsset records a file uploaded before its key was stored. The key
+// comes from rawURL, as the old uploads were keyed by the ID of the uploading
+// user rather than the company. Size and checksum are filled in when the file
+// can still be read from the store.
+func legacyURLAsset(ctx context.Context, store storage.BlobStore, row legacyLogo, rawURL string) (*model.Asset, error) {
+	key, ok := storage.KeyForURL(store, rawURL)
+	if !ok {
+		return nil, fmt.Errorf("%s is not served by the configured storage", rawURL)
+	}
+
+	asset := &model.Asset{
+		CompanyID:   row.ID,
+		UserID:      row.UserID,
+		Key:         key,
+		URL:         rawURL,
+		ContentType: "application/octet-stream",
+	}
+
+	if u, err := url.Parse(rawURL); err == nil {
+		if contentType := mime.TypeByExtension(path.Ext(u.Path)); contentType != "" {
+			asset.ContentType = contentType
+		}
+	}
+
+	obj, err := store.Get(ctx, key)
+	if err != nil {
+		return asset, nil
+	}
+	defer obj.Body.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, obj.Body)
+	if err != nil {
+		return asset, nil
+	}
+
+	asset.Size = size
+	asset.Checksum = hex.EncodeToString(hash.Sum(nil))
+	if obj.ContentType != "" {
+		asset.ContentType = obj.ContentType
+	}
+	return asset, nil
+}
+
+func isURL(value string) bool {
+	return strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/notblessy/bikinota-core/config"
+	"github.com/notblessy/bikinota-core/db"
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/storage"
+)
+
+func TestMigrateLegacyLogosKeysURLsByUploader(t *testing.T) {
+	a := newTestApp(t)
+	ctx := context.Background()
+
+	// The migrations already dropped the legacy column
+	if err := a.db.Exec("ALTER TABLE companies ADD COLUMN logo text").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := storage.NewLocalStore(t.TempDir(), "http://example.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two tenants whose company and user IDs are crossed, so keying by the
+	// company ID would pick the blob of the other tenant
+	first := &model.User{Email: "first@example.com", Name: "First", Password: "secret123"}
+	second := &model.User{Email: "second@example.com", Name: "Second", Password: "secret123"}
+	for _, user := range []*model.User{first, second} {
+		if err := a.userRepo.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	company := &model.Company{UserID: second.ID, Name: "Acme"}
+	other := &model.Company{UserID: first.ID, Name: "Other"}
+	for _, c := range []*model.Company{company, other} {
+		if err := a.companyRepo.Create(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if company.ID != first.ID || company.ID == company.UserID {
+		t.Fatalf("company %d of user %d, want the ID of the other user", company.ID, company.UserID)
+	}
+
+	// Legacy uploads were keyed by the ID of the uploading user
+	upload := func(userID uint, data string) string {
+		t.Helper()
+
+		key := fmt.Sprintf("company-logos/company-logo-%d.png", userID)
+		url, err := store.Put(ctx, key, strings.NewReader(data), int64(len(data)), "image/png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return url
+	}
+	logoURL := upload(company.UserID, "acme-logo")
+	upload(other.UserID, "other-tenant-logo")
+
+	if err := a.db.Table("companies").Where("id = ?", company.ID).Update("logo", logoURL).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := a.db.Table("companies").Where("id = ?", other.ID).Update("logo", "https://elsewhere.test/logo.png").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, failed, err := migrateLegacyLogos(ctx, a.db, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != 1 || failed != 1 {
+		t.Fatalf("migrated %d and failed %d logos, want 1 each", migrated, failed)
+	}
+
+	var stored model.Company
+	if err := a.db.Preload("LogoAsset").First(&stored, company.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.LogoAsset == nil {
+		t.Fatal("logo was not migrated into an asset")
+	}
+	asset := stored.LogoAsset
+	wantKey := fmt.Sprintf("company-logos/company-logo-%d.png", company.UserID)
+	if asset.Key != wantKey || asset.CompanyID != company.ID || asset.URL != logoURL {
+		t.Errorf("asset %q of company %d at %s, want %q of company %d", asset.Key, asset.CompanyID, asset.URL, wantKey, company.ID)
+	}
+	if asset.Size != int64(len("acme-logo")) {
+		t.Errorf("asset size %d, want the size of the uploaded logo", asset.Size)
+	}
+
+	// A URL the store does not serve is left for another run
+	var remaining string
+	if err := a.db.Table("companies").Select("logo").Where("id = ?", other.ID).Scan(&remaining).Error; err != nil {
+		t.Fatal(err)
+	}
+	if remaining == "" {
+		t.Error("the logo that could not be migrated was cleared")
+	}
+}
+
+func TestMigrateUpMovesLegacyLogosBeforeDroppingThem(t *testing.T) {
+	a := newApp(&config.Config{Database: config.DatabaseConfig{Driver: db.DriverSQLite, URL: ":memory:"}})
+	t.Cleanup(func() {
+		if sqlDB, err := a.db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	ctx := context.Background()
+
+	// A deployment upgraded from before the logos moved into assets
+	migrator, err := db.NewMigrator(a.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.UpTo(ctx, dropLegacyLogosVersion-1); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.db.Exec("ALTER TABLE companies ADD COLUMN logo text").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := storage.NewLocalStore(t.TempDir(), "http://example.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{Email: "owner@example.com", Name: "Owner", Password: "secret123"}
+	if err := a.userRepo.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	company := &model.Company{UserID: user.ID, Name: "Acme"}
+	if err := a.companyRepo.Create(ctx, company); err != nil {
+		t.Fatal(err)
+	}
+	logoURL, err := store.Put(ctx, "company-logos/company-logo-1.png", strings.NewReader("logo"), 4, "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.db.Table("companies").Where("id = ?", company.ID).Update("logo", logoURL).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrateUp(ctx, a.db, store); err != nil {
+		t.Fatal(err)
+	}
+
+	var stored model.Company
+	if err := a.db.Preload("LogoAsset").First(&stored, company.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.LogoAsset == nil || stored.LogoAsset.URL != logoURL {
+		t.Errorf("logo asset = %+v, want the legacy logo", stored.LogoAsset)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			t.Errorf("migration %d_%s is pending", s.Version, s.Name)
+		}
+	}
+}
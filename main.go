@@ -2,20 +2,22 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
-	"github.com/notblessy/bikinota-core/db"
 	"github.com/notblessy/bikinota-core/handler"
-	"github.com/notblessy/bikinota-core/model"
-	"github.com/notblessy/bikinota-core/repository"
-	"github.com/notblessy/bikinota-core/utils"
+	"github.com/notblessy/bikinota-core/metrics"
+	"github.com/notblessy/bikinota-core/storage"
+	"github.com/notblessy/bikinota-core/tracing"
+	"github.com/notblessy/bikinota-core/webhook"
 	"github.com/sirupsen/logrus"
 )
 
@@ -26,68 +28,142 @@ func main() {
 		logrus.Warn("cannot load .env file")
 	}
 
-	// Initialize database
-	postgres := db.NewPostgres()
-
-	// Auto-migrate models
-	err = postgres.AutoMigrate(
-		&model.User{},
-		&model.Company{},
-		&model.BankAccount{},
-		&model.Plan{},
-		&model.Invoice{},
-		&model.InvoiceItem{},
-		&model.InvoiceAdjustment{},
-	)
+	// The first argument selects the command, server is the default and also
+	// receives leading flags such as -http-addr
+	name, args := "server", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			cmd.run(args)
+			return
+		}
+	}
+
+	printUsage()
+	if name != "help" {
+		os.Exit(2)
+	}
+}
+
+// command is a subcommand of the server binary
+type command struct {
+	name    string
+	summary string
+	run     func(args []string)
+}
+
+var commands = []command{
+	{"server", "serve the HTTP API and run the background workers", runServer},
+	{"migrate", "apply, revert or list schema migrations", runMigrate},
+	{"seed", "create a demo user, company and invoices", runSeed},
+	{"user", "create users and reset passwords", runUser},
+	{"plan", "change the plan of a company", runPlan},
+	{"invoice", "maintain invoices", runInvoice},
+	{"logos", "move legacy company logos into assets", runLogos},
+}
+
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [arguments]\n\ncommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun %s server -h to list the configuration flags.\n", os.Args[0])
+}
+
+// runServer serves the HTTP API and runs the background workers until it
+// receives SIGINT or SIGTERM. Its flags override the configuration.
+func runServer(args []string) {
+	a := newApp(loadConfig(args))
+
+	// Initialize blob storage (optional - will work without it but uploads will fail)
+	blobStore, err := storage.New(a.cfg.Storage)
 	if err != nil {
+		logrus.Warnf("Storage not configured: %v. Logo uploads will not work.", err)
+		blobStore = nil
+	}
+
+	// Apply pending schema migrations, replicas wait for each other. Legacy
+	// logos are moved to storage on the way.
+	if err := migrateUp(context.Background(), a.db, blobStore); err != nil {
 		logrus.Fatalf("Failed to migrate database: %v", err)
 	}
 
-	// Manual migration: Make due_date nullable (GORM AutoMigrate doesn't always modify existing columns)
-	// This is safe to run multiple times - if the column is already nullable, it will just be a no-op
-	err = postgres.Exec("ALTER TABLE invoices ALTER COLUMN due_date DROP NOT NULL").Error
+	if err := a.registerMetrics(); err != nil {
+		logrus.Fatalf("Failed to register database metrics: %v", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), a.cfg.Tracing)
 	if err != nil {
-		// Log warning but don't fail - column might already be nullable or migration might have run
-		logrus.Warnf("Could not alter due_date column (may already be nullable): %v", err)
-	} else {
-		logrus.Info("Successfully made due_date column nullable")
+		logrus.Fatalf("Failed to set up tracing: %v", err)
 	}
 
-	// Initialize repositories
-	userRepo := repository.NewUserRepository(postgres)
-	companyRepo := repository.NewCompanyRepository(postgres)
-	planRepo := repository.NewPlanRepository(postgres)
-	invoiceRepo := repository.NewInvoiceRepository(postgres)
+	// Webhook events are queued by the dispatcher and sent by the worker
+	webhookDispatcher := webhook.NewDispatcher(a.webhookRepo)
+	webhookWorker := webhook.NewWorker(a.webhookRepo, a.cfg.Webhook)
 
-	// Initialize Cloudinary service (optional - will work without it but uploads will fail)
-	var cloudinaryService *utils.CloudinaryService
-	cloudinaryService, err = utils.NewCloudinaryService()
-	if err != nil {
-		logrus.Warnf("Cloudinary not configured: %v. Logo uploads will not work.", err)
-		cloudinaryService = nil
+	var assets *storage.AssetStore
+	if blobStore != nil {
+		assets = storage.NewAssetStore(blobStore, a.assetRepo)
 	}
 
 	// Initialize Echo
 	e := echo.New()
+	e.Server.ReadTimeout = a.cfg.HTTP.ReadTimeout
+	e.Server.WriteTimeout = a.cfg.HTTP.WriteTimeout
+	e.Server.IdleTimeout = a.cfg.HTTP.IdleTimeout
 
 	// Setup routes
-	handler.SetupRoutes(e, userRepo, companyRepo, planRepo, invoiceRepo, cloudinaryService)
+	handler.SetupRoutes(e, a.cfg, a.userRepo, a.companyRepo, a.memberRepo, a.apiKeyRepo, a.planRepo, a.invoiceRepo, a.settingsRepo, a.webhookRepo, a.auditRepo, a.transactor, webhookDispatcher, blobStore, assets, a.readinessChecks())
 
 	// Shared context with cancel
-	_, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
 	wg := &sync.WaitGroup{}
 
+	// Webhook delivery worker
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		webhookWorker.Run(ctx)
+	}()
+
+	// Orphaned asset sweeper
+	if assets != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assets.RunSweeper(ctx, time.Hour)
+		}()
+	}
+
 	// HTTP server
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		logrus.Info("HTTP server starting on :8080")
+		logrus.Infof("HTTP server starting on %s", a.cfg.HTTP.Addr)
 
-		if err := e.Start(":8080"); err != nil && err != http.ErrServerClosed {
+		if err := e.Start(a.cfg.HTTP.Addr); err != nil && err != http.ErrServerClosed {
 			logrus.Errorf("HTTP server error: %v", err)
 		}
 	}()
 
+	// Metrics server, on its own listener so /metrics is not public
+	var metricsServer *http.Server
+	if a.cfg.HTTP.MetricsAddr != "" {
+		metricsServer = metrics.NewServer(a.cfg.HTTP.MetricsAddr)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logrus.Infof("Metrics server starting on %s", a.cfg.HTTP.MetricsAddr)
+
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logrus.Errorf("Metrics server error: %v", err)
+			}
+		}()
+	}
+
 	// Signal handling
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -97,12 +173,23 @@ func main() {
 
 	// Initiate graceful shutdown
 	cancel()
-	ctxTimeout, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctxTimeout, shutdownCancel := context.WithTimeout(context.Background(), a.cfg.HTTP.ShutdownTimeout)
 	defer shutdownCancel()
 	if err := e.Shutdown(ctxTimeout); err != nil {
 		logrus.Errorf("Server shutdown error: %v", err)
 	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctxTimeout); err != nil {
+			logrus.Errorf("Metrics server shutdown error: %v", err)
+		}
+	}
 
 	wg.Wait()
+
+	// Flush the spans of the last requests
+	if err := shutdownTracing(ctxTimeout); err != nil {
+		logrus.Errorf("Tracing shutdown error: %v", err)
+	}
+
 	logrus.Info("All services shut down gracefully")
 }
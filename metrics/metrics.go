@@ -0,0 +1,109 @@
+// Package metrics collects the Prometheus metrics served on /metrics
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "bikinota"
+
+// Registry holds every metric of the service, along with the Go runtime and
+// process collectors
+var Registry = prometheus.NewRegistry()
+
+var (
+	invoicesCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "invoices_created_total",
+		Help:      "Invoices created.",
+	})
+	invoicesSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "invoices_sent_total",
+		Help:      "Invoices marked as sent to the customer.",
+	})
+	invoicesPaid = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "invoices_paid_total",
+		Help:      "Invoices marked as paid.",
+	})
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by result, succeeded or failed.",
+	}, []string{"result"})
+	jobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_total",
+		Help:      "Background job runs by job and outcome.",
+	}, []string{"job", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		invoicesCreated,
+		invoicesSent,
+		invoicesPaid,
+		logins,
+		jobs,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// NewServer serves /metrics on addr. It listens apart from the API, whose
+// listener is public, so only the internal network can scrape the metrics.
+func NewServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+}
+
+// RegisterDB exports the connection pool statistics of a database, name tells
+// several pools apart, e.g. primary and replica
+func RegisterDB(name string, db *sql.DB) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// InvoiceCreated counts a newly created invoice
+func InvoiceCreated() {
+	invoicesCreated.Inc()
+}
+
+// InvoiceStatus counts an invoice entering status. Only the sent and paid
+// statuses are counted.
+func InvoiceStatus(status string) {
+	switch status {
+	case "sent":
+		invoicesSent.Inc()
+	case "paid":
+		invoicesPaid.Inc()
+	}
+}
+
+// Login counts a login attempt
+func Login(succeeded bool) {
+	result := "failed"
+	if succeeded {
+		result = "succeeded"
+	}
+	logins.WithLabelValues(result).Inc()
+}
+
+// Job counts a run of a background job with its outcome, e.g. succeeded
+func Job(job, outcome string) {
+	jobs.WithLabelValues(job, outcome).Inc()
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareLabelsRouteTemplates(t *testing.T) {
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/api/invoice/:id", func(c echo.Context) error {
+		if c.Param("id") == "0" {
+			return echo.ErrNotFound
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, path := range []string{"/api/invoice/1", "/api/invoice/2", "/api/invoice/0", "/nowhere/1", "/nowhere/2"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	tests := []struct {
+		route  string
+		status string
+		want   float64
+	}{
+		{"/api/invoice/:id", "200", 2},
+		{"/api/invoice/:id", "404", 1},
+		{unmatchedRoute, "404", 2},
+	}
+	for _, tt := range tests {
+		got := testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, tt.route, tt.status))
+		if got != tt.want {
+			t.Errorf("requests to %s with status %s = %v, want %v", tt.route, tt.status, got, tt.want)
+		}
+	}
+
+	if n := testutil.CollectAndCount(httpRequests); n != len(tests) {
+		t.Errorf("%d request series, want %d", n, len(tests))
+	}
+}
+
+func TestServerServesOnlyMetrics(t *testing.T) {
+	handler := NewServer(":0").Handler
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "bikinota_invoices_created_total") {
+		t.Errorf("GET /metrics = %d, want the service metrics", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/invoice", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /api/invoice = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
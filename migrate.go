@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/notblessy/bikinota-core/db"
+	"github.com/notblessy/bikinota-core/storage"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const migrateUsage = `usage: migrate <command>
+
+commands:
+  up           apply all pending migrations
+  down [n]     revert the last n applied migrations, 1 by default
+  status       list migrations and when they were applied`
+
+// runMigrate applies, reverts or lists the schema migrations
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		usageExit(migrateUsage)
+	}
+
+	ctx := context.Background()
+	a := newApp(loadConfig(nil))
+
+	migrator, err := newMigrator(a.db)
+	if err != nil {
+		logrus.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			logrus.Fatalf("Failed to migrate database: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("database is up to date")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				logrus.Fatalf("Invalid number of migrations to revert: %s", args[1])
+			}
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			logrus.Fatalf("Failed to revert migrations: %v", err)
+		}
+		if len(reverted) == 0 {
+			fmt.Println("no migrations to revert")
+		}
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			logrus.Fatalf("Failed to read migration status: %v", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		w.Flush()
+
+	default:
+		usageExit(migrateUsage)
+	}
+}
+
+// dropLegacyLogosVersion is the migration dropping the legacy logo columns
+const dropLegacyLogosVersion = 11
+
+// migrateUp applies pending migrations when the server starts. Legacy logos
+// are moved into assets right before the migration dropping their column, so
+// upgraded deployments need not run "logos migrate" first. Without store they
+// stay put and that migration fails until the command has been run.
+func migrateUp(ctx context.Context, db *gorm.DB, store storage.BlobStore) error {
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.UpTo(ctx, dropLegacyLogosVersion-1)
+	logApplied(applied)
+	if err != nil {
+		return err
+	}
+
+	if store != nil {
+		migrated, failed, err := migrateLegacyLogos(ctx, db, store)
+		if err != nil {
+			return fmt.Errorf("failed to migrate logos: %w", err)
+		}
+		if migrated > 0 {
+			logrus.Infof("Migrated %d company logos", migrated)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d company logos could not be migrated, see the log and run the \"logos migrate\" command once they are fixed", failed)
+		}
+	}
+
+	applied, err = migrator.Up(ctx)
+	logApplied(applied)
+	return err
+}
+
+// newMigrator loads the migrations for the driver of conn. Functions taking
+// the connection as db use it, as their parameter hides the db package.
+func newMigrator(conn *gorm.DB) (*db.Migrator, error) {
+	return db.NewMigrator(conn)
+}
+
+func logApplied(applied []db.Migration) {
+	for _, m := range applied {
+		logrus.Infof("Applied migration %04d_%s", m.Version, m.Name)
+	}
+}
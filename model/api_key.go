@@ -0,0 +1,103 @@
+package model
+
+import (
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyScopes lists the scopes that can be granted to an API key. A key can
+// never do more than its creator's role allows in the company it acts on.
+var APIKeyScopes = []Permission{
+	PermissionCompanyRead,
+	PermissionCompanyWrite,
+	PermissionBankAccountWrite,
+	PermissionBankAccountReveal,
+	PermissionInvoiceRead,
+	PermissionInvoiceWrite,
+	PermissionInvoiceDelete,
+	PermissionAuditRead,
+}
+
+// APIKey authenticates scripts and integrations on behalf of a user. Personal
+// keys act in any company of the user, company keys only in CompanyID. Only
+// the SHA-256 hash of the key is stored; Prefix identifies it in listings.
+type APIKey struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	UserID     uint           `json:"user_id" gorm:"not null;index"`
+	CompanyID  *uint          `json:"company_id" gorm:"index"`
+	Name       string         `json:"name" gorm:"not null"`
+	Prefix     string         `json:"prefix" gorm:"not null;uniqueIndex"`
+	KeyHash    string         `json:"-" gorm:"not null"`
+	Scopes     []Permission   `json:"scopes" gorm:"serializer:json;type:text;not null"`
+	ExpiresAt  *time.Time     `json:"expires_at"`
+	LastUsedAt *time.Time     `json:"last_used_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"` // Set when the key is revoked
+}
+
+// HasScope reports whether the key was granted the given scope
+func (k *APIKey) HasScope(scope Permission) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired reports whether the key is past its expiry
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// Request DTOs
+type CreateAPIKeyRequest struct {
+	Name          string       `json:"name" validate:"required,max=100"`
+	Scopes        []Permission `json:"scopes" validate:"required,min=1"`
+	Company       bool         `json:"company"`         // Bind the key to the active company instead of the user
+	ExpiresInDays *int         `json:"expires_in_days"` // Optional: keys without expiry are valid until revoked
+}
+
+// Response DTOs
+type APIKeyResponse struct {
+	ID         string       `json:"id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	CompanyID  *string      `json:"company_id"`
+	Scopes     []Permission `json:"scopes"`
+	ExpiresAt  *string      `json:"expires_at"`
+	LastUsedAt *string      `json:"last_used_at"`
+	CreatedAt  string       `json:"created_at"`
+	Key        string       `json:"key,omitempty"` // Only returned when the key is created
+}
+
+// ToAPIKeyResponse converts APIKey to APIKeyResponse
+func (k *APIKey) ToAPIKeyResponse() APIKeyResponse {
+	var companyID *string
+	if k.CompanyID != nil {
+		id := strconv.FormatUint(uint64(*k.CompanyID), 10)
+		companyID = &id
+	}
+
+	formatTime := func(t *time.Time) *string {
+		if t == nil {
+			return nil
+		}
+		s := t.Format(time.RFC3339)
+		return &s
+	}
+
+	return APIKeyResponse{
+		ID:         strconv.FormatUint(uint64(k.ID), 10),
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		CompanyID:  companyID,
+		Scopes:     k.Scopes,
+		ExpiresAt:  formatTime(k.ExpiresAt),
+		LastUsedAt: formatTime(k.LastUsedAt),
+		CreatedAt:  k.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+)
+
+// Asset is a file kept in the blob store, such as a company logo. Assets that
+// are no longer referenced are removed by the storage sweeper.
+type Asset struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	CompanyID   uint      `json:"company_id" gorm:"not null;index"` // Owning company
+	UserID      uint      `json:"user_id" gorm:"not null;index"`    // User who uploaded the file
+	Key         string    `json:"key" gorm:"not null;uniqueIndex"`  // Blob store key
+	URL         string    `json:"url" gorm:"type:text;not null"`
+	ContentType string    `json:"content_type" gorm:"not null"`
+	Size        int64     `json:"size" gorm:"not null;default:0"`
+	Checksum    string    `json:"checksum" gorm:"not null;default:''"` // Hex SHA-256 of the content, empty if unknown
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+	AuditActionDelete AuditAction = "delete"
+)
+
+// AuditEntity is the kind of record an audit entry is about. Invoice items and
+// adjustments are recorded as part of their invoice.
+type AuditEntity string
+
+const (
+	AuditEntityInvoice     AuditEntity = "invoice"
+	AuditEntityCompany     AuditEntity = "company"
+	AuditEntityBankAccount AuditEntity = "bank_account"
+	AuditEntityPlan        AuditEntity = "plan"
+)
+
+// AuditEntities lists the entity types that are recorded in the audit log
+var AuditEntities = []AuditEntity{
+	AuditEntityInvoice,
+	AuditEntityCompany,
+	AuditEntityBankAccount,
+	AuditEntityPlan,
+}
+
+// AuditChange holds the value of a field before and after a change. Before is
+// null for created fields, After for removed ones.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditEntry records who changed a record of a company, when and how. Changes
+// are keyed by the dotted path of the field in the record's API response, such
+// as items.12.price. Entries are append only; the database rejects updates and
+// deletes.
+type AuditEntry struct {
+	ID         uint                   `json:"id" gorm:"primaryKey"`
+	CompanyID  uint                   `json:"company_id" gorm:"not null;index"`
+	UserID     uint                   `json:"user_id" gorm:"not null;index"`
+	APIKeyID   *uint                  `json:"api_key_id"` // Set when the change was made with an API key
+	IPAddress  string                 `json:"ip_address" gorm:"not null"`
+	RequestID  string                 `json:"request_id"`
+	EntityType AuditEntity            `json:"entity_type" gorm:"type:varchar(30);not null"`
+	EntityID   uint                   `json:"entity_id" gorm:"not null"`
+	Action     AuditAction            `json:"action" gorm:"type:varchar(10);not null"`
+	Changes    map[string]AuditChange `json:"changes" gorm:"serializer:json;type:text;not null"`
+	User       *User                  `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// Response DTOs
+type AuditActorResponse struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Email    string  `json:"email"`
+	APIKeyID *string `json:"api_key_id"`
+}
+
+type AuditEntryResponse struct {
+	ID         string                 `json:"id"`
+	EntityType AuditEntity            `json:"entity_type"`
+	EntityID   string                 `json:"entity_id"`
+	Action     AuditAction            `json:"action"`
+	Changes    map[string]AuditChange `json:"changes"`
+	Actor      AuditActorResponse     `json:"actor"`
+	IPAddress  string                 `json:"ip_address"`
+	RequestID  string                 `json:"request_id"`
+	CreatedAt  string                 `json:"created_at"`
+}
+
+// ToAuditEntryResponse converts AuditEntry to AuditEntryResponse
+func (e *AuditEntry) ToAuditEntryResponse() AuditEntryResponse {
+	actor := AuditActorResponse{
+		ID: strconv.FormatUint(uint64(e.UserID), 10),
+	}
+	if e.User != nil {
+		actor.Name = e.User.Name
+		actor.Email = e.User.Email
+	}
+	if e.APIKeyID != nil {
+		id := strconv.FormatUint(uint64(*e.APIKeyID), 10)
+		actor.APIKeyID = &id
+	}
+
+	return AuditEntryResponse{
+		ID:         strconv.FormatUint(uint64(e.ID), 10),
+		EntityType: e.EntityType,
+		EntityID:   strconv.FormatUint(uint64(e.EntityID), 10),
+		Action:     e.Action,
+		Changes:    e.Changes,
+		Actor:      actor,
+		IPAddress:  e.IPAddress,
+		RequestID:  e.RequestID,
+		CreatedAt:  e.CreatedAt.Format(time.RFC3339),
+	}
+}
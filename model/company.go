@@ -2,6 +2,7 @@ package model
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -10,34 +11,78 @@ import (
 type BankAccount struct {
 	ID            uint           `json:"id" gorm:"primaryKey"`
 	CompanyID     uint           `json:"company_id" gorm:"not null;index"`
+	Country       string         `json:"country" gorm:"not null;default:''"` // ISO 3166-1 alpha-2, selects the validation rules
+	BankCode      *string        `json:"bank_code,omitempty"`                // Domestic clearing code, e.g. 014 for BCA
 	BankName      string         `json:"bank_name" gorm:"not null"`
 	AccountName   string         `json:"account_name" gorm:"not null"`
-	AccountNumber string         `json:"account_number" gorm:"not null"`
+	AccountNumber string         `json:"account_number" gorm:"not null"` // IBAN in IBAN countries
 	SwiftCode     *string        `json:"swift_code,omitempty"`
 	RoutingNumber *string        `json:"routing_number,omitempty"`
-	IsDefault     bool           `json:"is_default" gorm:"default:false"`
+	IsDefault     bool           `json:"is_default" gorm:"default:false"`    // At most one per company, see idx_bank_accounts_company_default
+	Position      int            `json:"position" gorm:"not null;default:0"` // Display order within the company
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
+// BankAccountSnapshot is a copy of the bank details an invoice was issued
+// with, so later edits or deletion of the account don't change the invoice
+type BankAccountSnapshot struct {
+	BankName      string  `json:"bank_name"`
+	AccountName   string  `json:"account_name"`
+	AccountNumber string  `json:"account_number"`
+	SwiftCode     *string `json:"swift_code,omitempty"`
+	RoutingNumber *string `json:"routing_number,omitempty"`
+}
+
+// Snapshot copies the bank details of the account
+func (ba *BankAccount) Snapshot() *BankAccountSnapshot {
+	return &BankAccountSnapshot{
+		BankName:      ba.BankName,
+		AccountName:   ba.AccountName,
+		AccountNumber: ba.AccountNumber,
+		SwiftCode:     ba.SwiftCode,
+		RoutingNumber: ba.RoutingNumber,
+	}
+}
+
 type Company struct {
-	ID           uint           `json:"id" gorm:"primaryKey"`
-	UserID       uint           `json:"user_id" gorm:"not null;uniqueIndex"`
-	Name         string         `json:"name" gorm:"not null"`
-	Address      string         `json:"address" gorm:"not null"`
-	City         string         `json:"city" gorm:"not null"`
-	State        string         `json:"state" gorm:"not null"`
-	ZipCode      string         `json:"zip_code" gorm:"not null"`
-	Country      string         `json:"country" gorm:"not null"`
-	Email        string         `json:"email" gorm:"not null"`
-	Phone        string         `json:"phone" gorm:"not null"`
-	Website      string         `json:"website" gorm:"not null"`
-	Logo         string         `json:"logo" gorm:"type:text"` // base64 encoded image
-	BankAccounts []BankAccount  `json:"bank_accounts" gorm:"foreignKey:CompanyID"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	UserID           uint           `json:"user_id" gorm:"not null;index"` // User who created the company, see CompanyMember for access
+	Name             string         `json:"name" gorm:"not null"`
+	Address          string         `json:"address" gorm:"not null"`
+	City             string         `json:"city" gorm:"not null"`
+	State            string         `json:"state" gorm:"not null"`
+	ZipCode          string         `json:"zip_code" gorm:"not null"`
+	Country          string         `json:"country" gorm:"not null"`
+	Email            string         `json:"email" gorm:"not null"`
+	Phone            string         `json:"phone" gorm:"not null"`
+	Website          string         `json:"website" gorm:"not null"`
+	TaxID            string         `json:"tax_id" gorm:"not null;default:''"` // e.g. NPWP, VAT number
+	LogoAssetID      *uint          `json:"logo_asset_id" gorm:"index"`
+	LogoAsset        *Asset         `json:"logo_asset,omitempty" gorm:"foreignKey:LogoAssetID"`
+	LogoThumbAssetID *uint          `json:"logo_thumb_asset_id" gorm:"index"`
+	LogoThumbAsset   *Asset         `json:"logo_thumb_asset,omitempty" gorm:"foreignKey:LogoThumbAssetID"`
+	BankAccounts     []BankAccount  `json:"bank_accounts" gorm:"foreignKey:CompanyID"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// LogoURL returns the URL of the company logo, empty if it has none
+func (c *Company) LogoURL() string {
+	if c.LogoAsset == nil {
+		return ""
+	}
+	return c.LogoAsset.URL
+}
+
+// LogoThumbURL returns the URL of the logo thumbnail, empty if it has none
+func (c *Company) LogoThumbURL() string {
+	if c.LogoThumbAsset == nil {
+		return ""
+	}
+	return c.LogoThumbAsset.URL
 }
 
 type UpdateCompanyRequest struct {
@@ -50,10 +95,26 @@ type UpdateCompanyRequest struct {
 	Email   *string `json:"email,omitempty"`
 	Phone   *string `json:"phone,omitempty"`
 	Website *string `json:"website,omitempty"`
-	Logo    *string `json:"logo,omitempty"`
+	TaxID   *string `json:"tax_id,omitempty"`
+	Logo    *string `json:"logo,omitempty"` // base64 data URI, or empty to remove the logo
+}
+
+type CreateCompanyRequest struct {
+	Name    string `json:"name" validate:"required"`
+	Address string `json:"address"`
+	City    string `json:"city"`
+	State   string `json:"state"`
+	ZipCode string `json:"zip_code"`
+	Country string `json:"country"`
+	Email   string `json:"email"`
+	Phone   string `json:"phone"`
+	Website string `json:"website"`
+	TaxID   string `json:"tax_id"`
 }
 
 type CreateBankAccountRequest struct {
+	Country       string  `json:"country" validate:"omitempty,len=2,alpha"`
+	BankCode      *string `json:"bank_code,omitempty"`
 	BankName      string  `json:"bank_name" validate:"required"`
 	AccountName   string  `json:"account_name" validate:"required"`
 	AccountNumber string  `json:"account_number" validate:"required"`
@@ -62,6 +123,8 @@ type CreateBankAccountRequest struct {
 }
 
 type UpdateBankAccountRequest struct {
+	Country       *string `json:"country,omitempty" validate:"omitempty,len=2,alpha"`
+	BankCode      *string `json:"bank_code,omitempty"`
 	BankName      *string `json:"bank_name,omitempty"`
 	AccountName   *string `json:"account_name,omitempty"`
 	AccountNumber *string `json:"account_number,omitempty"`
@@ -69,18 +132,33 @@ type UpdateBankAccountRequest struct {
 	RoutingNumber *string `json:"routing_number,omitempty"`
 }
 
+// ReorderBankAccountsRequest lists every bank account of the company in the new display order
+type ReorderBankAccountsRequest struct {
+	IDs []string `json:"ids" validate:"required,min=1"`
+}
+
 // Response DTOs with string IDs to match frontend
 type BankAccountResponse struct {
 	ID            string  `json:"id"`
+	Country       string  `json:"country"`
+	BankCode      *string `json:"bank_code,omitempty"`
 	BankName      string  `json:"bank_name"`
 	AccountName   string  `json:"account_name"`
-	AccountNumber string  `json:"account_number"`
+	AccountNumber string  `json:"account_number"` // Masked, see BankAccountRevealResponse
 	SwiftCode     *string `json:"swift_code,omitempty"`
 	RoutingNumber *string `json:"routing_number,omitempty"`
 	IsDefault     bool    `json:"is_default"`
+	Position      int     `json:"position"`
+}
+
+// BankAccountRevealResponse holds the full account number of a bank account
+type BankAccountRevealResponse struct {
+	ID            string `json:"id"`
+	AccountNumber string `json:"account_number"`
 }
 
 type CompanyResponse struct {
+	ID           string                `json:"id"`
 	Name         string                `json:"name"`
 	Address      string                `json:"address"`
 	City         string                `json:"city"`
@@ -90,21 +168,50 @@ type CompanyResponse struct {
 	Email        string                `json:"email"`
 	Phone        string                `json:"phone"`
 	Website      string                `json:"website"`
+	TaxID        string                `json:"tax_id"`
 	Logo         string                `json:"logo"`
+	LogoThumb    string                `json:"logo_thumb"`
 	BankAccounts []BankAccountResponse `json:"bank_accounts"`
 }
 
+// CompanySummaryResponse lists a company the user belongs to
+type CompanySummaryResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Logo string `json:"logo"`
+	Role Role   `json:"role"`
+}
+
 // ToBankAccountResponse converts BankAccount to BankAccountResponse
 func (ba *BankAccount) ToBankAccountResponse() BankAccountResponse {
 	return BankAccountResponse{
 		ID:            convertUintToString(ba.ID),
+		Country:       ba.Country,
+		BankCode:      ba.BankCode,
 		BankName:      ba.BankName,
 		AccountName:   ba.AccountName,
-		AccountNumber: ba.AccountNumber,
+		AccountNumber: maskAccountNumber(ba.AccountNumber),
 		SwiftCode:     ba.SwiftCode,
 		RoutingNumber: ba.RoutingNumber,
 		IsDefault:     ba.IsDefault,
+		Position:      ba.Position,
+	}
+}
+
+// ToBankAccountRevealResponse converts BankAccount to BankAccountRevealResponse
+func (ba *BankAccount) ToBankAccountRevealResponse() BankAccountRevealResponse {
+	return BankAccountRevealResponse{
+		ID:            convertUintToString(ba.ID),
+		AccountNumber: ba.AccountNumber,
+	}
+}
+
+// maskAccountNumber hides all but the last four characters of an account number
+func maskAccountNumber(value string) string {
+	if len(value) <= 4 {
+		return strings.Repeat("*", len(value))
 	}
+	return strings.Repeat("*", len(value)-4) + value[len(value)-4:]
 }
 
 // ToCompanyResponse converts Company to CompanyResponse
@@ -115,6 +222,7 @@ func (c *Company) ToCompanyResponse() CompanyResponse {
 	}
 
 	return CompanyResponse{
+		ID:           convertUintToString(c.ID),
 		Name:         c.Name,
 		Address:      c.Address,
 		City:         c.City,
@@ -124,7 +232,9 @@ func (c *Company) ToCompanyResponse() CompanyResponse {
 		Email:        c.Email,
 		Phone:        c.Phone,
 		Website:      c.Website,
-		Logo:         c.Logo,
+		TaxID:        c.TaxID,
+		Logo:         c.LogoURL(),
+		LogoThumb:    c.LogoThumbURL(),
 		BankAccounts: bankAccounts,
 	}
 }
@@ -31,24 +31,26 @@ type InvoiceAdjustment struct {
 }
 
 type Invoice struct {
-	ID               uint                `json:"id" gorm:"primaryKey"`
-	UserID           uint                `json:"user_id" gorm:"not null;index"`
-	InvoiceNumber    string              `json:"invoice_number" gorm:"not null;uniqueIndex"`
-	CustomerName     string              `json:"customer_name" gorm:"not null"`
-	CustomerEmail    string              `json:"customer_email" gorm:"not null"`
-	DueDate          *time.Time          `json:"due_date"` // Optional
-	TaxRate          float64             `json:"tax_rate" gorm:"not null;default:0"`
-	Status           string              `json:"status" gorm:"not null;default:draft"` // "draft", "sent", "paid"
-	Subtotal         int                 `json:"subtotal" gorm:"not null"`             // Stored in smallest currency unit
-	TaxAmount        int                 `json:"tax_amount" gorm:"not null"`           // Stored in smallest currency unit
-	AdjustmentsTotal int                 `json:"adjustments_total" gorm:"not null"`    // Stored in smallest currency unit
-	Total            int                 `json:"total" gorm:"not null"`                // Stored in smallest currency unit
-	BankAccountID    *uint               `json:"bank_account_id" gorm:"index"`
-	Items            []InvoiceItem       `json:"items" gorm:"foreignKey:InvoiceID"`
-	Adjustments      []InvoiceAdjustment `json:"adjustments" gorm:"foreignKey:InvoiceID"`
-	CreatedAt        time.Time           `json:"created_at"`
-	UpdatedAt        time.Time           `json:"updated_at"`
-	DeletedAt        gorm.DeletedAt      `json:"deleted_at" gorm:"index"`
+	ID               uint                 `json:"id" gorm:"primaryKey"`
+	UserID           uint                 `json:"user_id" gorm:"not null;index"` // User who created the invoice
+	CompanyID        uint                 `json:"company_id" gorm:"uniqueIndex:idx_invoices_company_number"`
+	InvoiceNumber    string               `json:"invoice_number" gorm:"not null;uniqueIndex:idx_invoices_company_number"`
+	CustomerName     string               `json:"customer_name" gorm:"not null"`
+	CustomerEmail    string               `json:"customer_email" gorm:"not null"`
+	DueDate          *time.Time           `json:"due_date"` // Optional
+	TaxRate          float64              `json:"tax_rate" gorm:"not null;default:0"`
+	Status           string               `json:"status" gorm:"not null;default:draft"` // "draft", "sent", "paid"
+	Subtotal         int                  `json:"subtotal" gorm:"not null"`             // Stored in smallest currency unit
+	TaxAmount        int                  `json:"tax_amount" gorm:"not null"`           // Stored in smallest currency unit
+	AdjustmentsTotal int                  `json:"adjustments_total" gorm:"not null"`    // Stored in smallest currency unit
+	Total            int                  `json:"total" gorm:"not null"`                // Stored in smallest currency unit
+	BankAccountID    *uint                `json:"bank_account_id" gorm:"index"`
+	BankDetails      *BankAccountSnapshot `json:"bank_details" gorm:"serializer:json;type:text"` // Bank account as it was when assigned
+	Items            []InvoiceItem        `json:"items" gorm:"foreignKey:InvoiceID"`
+	Adjustments      []InvoiceAdjustment  `json:"adjustments" gorm:"foreignKey:InvoiceID"`
+	CreatedAt        time.Time            `json:"created_at"`
+	UpdatedAt        time.Time            `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt       `json:"deleted_at" gorm:"index"`
 }
 
 // Request DTOs
@@ -92,13 +94,13 @@ type UpdateInvoiceAdjustmentRequest struct {
 }
 
 type UpdateInvoiceRequest struct {
-	CustomerName  *string                          `json:"customer_name"`
-	CustomerEmail *string                          `json:"customer_email"`
+	CustomerName  *string                          `json:"customer_name" validate:"omitempty,min=1"`
+	CustomerEmail *string                          `json:"customer_email" validate:"omitempty,email"`
 	DueDate       *string                          `json:"due_date"`
 	TaxRate       *float64                         `json:"tax_rate"`
 	Status        *string                          `json:"status" validate:"omitempty,oneof=draft sent paid"`
-	Items         []UpdateInvoiceItemRequest       `json:"items"`
-	Adjustments   []UpdateInvoiceAdjustmentRequest `json:"adjustments"`
+	Items         []UpdateInvoiceItemRequest       `json:"items" validate:"omitempty,min=1,dive"` // Replaces the items when present
+	Adjustments   []UpdateInvoiceAdjustmentRequest `json:"adjustments" validate:"omitempty,dive"`
 	BankAccountID *string                          `json:"bank_account_id"`
 }
 
@@ -131,6 +133,7 @@ type InvoiceResponse struct {
 	AdjustmentsTotal float64                     `json:"adjustments_total"`
 	Total            float64                     `json:"total"`
 	BankAccountID    *string                     `json:"bank_account_id"`
+	BankAccount      *BankAccountSnapshot        `json:"bank_account"`
 	Items            []InvoiceItemResponse       `json:"items"`
 	Adjustments      []InvoiceAdjustmentResponse `json:"adjustments"`
 	CreatedAt        string                      `json:"created_at"`
@@ -146,6 +149,29 @@ func rupiahToCents(rupiah float64) int {
 	return int(rupiah * 100)
 }
 
+// CalculateTotals sets the subtotal, tax, adjustments and total from the
+// items, adjustments and tax rate
+func (i *Invoice) CalculateTotals() {
+	subtotal := 0
+	for _, item := range i.Items {
+		subtotal += item.Quantity * item.Price
+	}
+
+	adjustmentsTotal := 0
+	for _, adj := range i.Adjustments {
+		if adj.Type == "addition" {
+			adjustmentsTotal += adj.Amount
+		} else {
+			adjustmentsTotal -= adj.Amount
+		}
+	}
+
+	i.Subtotal = subtotal
+	i.TaxAmount = int(float64(subtotal) * i.TaxRate / 100.0)
+	i.AdjustmentsTotal = adjustmentsTotal
+	i.Total = subtotal + i.TaxAmount + adjustmentsTotal
+}
+
 func (i *Invoice) ToInvoiceResponse() InvoiceResponse {
 	items := make([]InvoiceItemResponse, len(i.Items))
 	for idx, item := range i.Items {
@@ -192,6 +218,7 @@ func (i *Invoice) ToInvoiceResponse() InvoiceResponse {
 		AdjustmentsTotal: centsToRupiah(i.AdjustmentsTotal),
 		Total:            centsToRupiah(i.Total),
 		BankAccountID:    bankAccountID,
+		BankAccount:      i.BankDetails,
 		Items:            items,
 		Adjustments:      adjustments,
 		CreatedAt:        i.CreatedAt.Format(time.RFC3339),
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Built-in invoice themes, each a different document layout
+const (
+	InvoiceThemeClassic = "classic"
+	InvoiceThemeModern  = "modern"
+	InvoiceThemeMinimal = "minimal"
+)
+
+var InvoiceThemes = []string{InvoiceThemeClassic, InvoiceThemeModern, InvoiceThemeMinimal}
+
+// InvoiceFontStacks maps the selectable invoice fonts to CSS font stacks.
+// Every stack ends in a generic family so documents render without web fonts.
+var InvoiceFontStacks = map[string]string{
+	"system":        `-apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif`,
+	"inter":         `Inter, "Helvetica Neue", Arial, sans-serif`,
+	"roboto":        `Roboto, "Helvetica Neue", Arial, sans-serif`,
+	"merriweather":  `Merriweather, Georgia, "Times New Roman", serif`,
+	"lora":          `Lora, Georgia, "Times New Roman", serif`,
+	"ibm-plex-mono": `"IBM Plex Mono", Menlo, Consolas, monospace`,
+}
+
+// InvoiceFonts lists the keys of InvoiceFontStacks in display order
+var InvoiceFonts = []string{"system", "inter", "roboto", "merriweather", "lora", "ibm-plex-mono"}
+
+const (
+	DefaultInvoiceTemplateName = "Default"
+	DefaultInvoiceAccentColor  = "#2563eb"
+)
+
+// InvoiceTemplate is a named set of presentation options. Companies can keep
+// several, e.g. one per language or client type.
+type InvoiceTemplate struct {
+	Name              string `json:"name" validate:"required,max=50"`
+	Theme             string `json:"theme" validate:"required,oneof=classic modern minimal"`
+	AccentColor       string `json:"accent_color" validate:"required,hexcolor"`
+	Font              string `json:"font" validate:"required,oneof=system inter roboto merriweather lora ibm-plex-mono"`
+	FooterText        string `json:"footer_text" validate:"max=500"`
+	PaymentTerms      string `json:"payment_terms" validate:"max=1000"`
+	Notes             string `json:"notes" validate:"max=2000"` // Default notes printed on every invoice
+	ShowSwiftCode     bool   `json:"show_swift_code"`
+	ShowRoutingNumber bool   `json:"show_routing_number"`
+	ShowTaxID         bool   `json:"show_tax_id"`
+}
+
+// FontStack returns the CSS font-family value of the template's font
+func (t InvoiceTemplate) FontStack() string {
+	if stack, ok := InvoiceFontStacks[t.Font]; ok {
+		return stack
+	}
+	return InvoiceFontStacks["system"]
+}
+
+// InvoiceSettings holds the invoice presentation settings of a company
+type InvoiceSettings struct {
+	ID              uint              `json:"id" gorm:"primaryKey"`
+	CompanyID       uint              `json:"company_id" gorm:"not null;uniqueIndex"`
+	DefaultTemplate string            `json:"default_template" gorm:"not null"`
+	Templates       []InvoiceTemplate `json:"templates" gorm:"serializer:json;type:text;not null"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// DefaultInvoiceSettings returns the settings used until a company saves its own
+func DefaultInvoiceSettings(companyID uint) *InvoiceSettings {
+	return &InvoiceSettings{
+		CompanyID:       companyID,
+		DefaultTemplate: DefaultInvoiceTemplateName,
+		Templates: []InvoiceTemplate{
+			{
+				Name:              DefaultInvoiceTemplateName,
+				Theme:             InvoiceThemeClassic,
+				AccentColor:       DefaultInvoiceAccentColor,
+				Font:              "system",
+				ShowSwiftCode:     true,
+				ShowRoutingNumber: true,
+				ShowTaxID:         true,
+			},
+		},
+	}
+}
+
+// Template returns the template with the given name (case-insensitive),
+// falling back to the default template when name is empty or unknown
+func (s *InvoiceSettings) Template(name string) InvoiceTemplate {
+	if t, ok := s.findTemplate(name); ok {
+		return t
+	}
+	if t, ok := s.findTemplate(s.DefaultTemplate); ok {
+		return t
+	}
+	if len(s.Templates) > 0 {
+		return s.Templates[0]
+	}
+	return DefaultInvoiceSettings(s.CompanyID).Templates[0]
+}
+
+func (s *InvoiceSettings) findTemplate(name string) (InvoiceTemplate, bool) {
+	if name == "" {
+		return InvoiceTemplate{}, false
+	}
+	for _, t := range s.Templates {
+		if strings.EqualFold(t.Name, name) {
+			return t, true
+		}
+	}
+	return InvoiceTemplate{}, false
+}
+
+// Request DTOs
+type UpdateInvoiceSettingsRequest struct {
+	DefaultTemplate string            `json:"default_template" validate:"required,max=50"`
+	Templates       []InvoiceTemplate `json:"templates" validate:"required,min=1,max=10,dive"`
+}
+
+// Response DTOs
+type InvoiceSettingsResponse struct {
+	DefaultTemplate string            `json:"default_template"`
+	Templates       []InvoiceTemplate `json:"templates"`
+	Themes          []string          `json:"themes"` // Available themes
+	Fonts           []string          `json:"fonts"`  // Available fonts
+}
+
+// ToInvoiceSettingsResponse converts InvoiceSettings to InvoiceSettingsResponse
+func (s *InvoiceSettings) ToInvoiceSettingsResponse() InvoiceSettingsResponse {
+	return InvoiceSettingsResponse{
+		DefaultTemplate: s.DefaultTemplate,
+		Templates:       s.Templates,
+		Themes:          InvoiceThemes,
+		Fonts:           InvoiceFonts,
+	}
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Role string
+
+const (
+	RoleOwner      Role = "owner"
+	RoleAdmin      Role = "admin"
+	RoleAccountant Role = "accountant"
+	RoleViewer     Role = "viewer"
+)
+
+type Permission string
+
+const (
+	PermissionCompanyRead       Permission = "company:read"
+	PermissionCompanyWrite      Permission = "company:write"
+	PermissionBankAccountWrite  Permission = "bank_account:write"
+	PermissionBankAccountReveal Permission = "bank_account:reveal" // See full account numbers
+	PermissionInvoiceRead       Permission = "invoice:read"
+	PermissionInvoiceWrite      Permission = "invoice:write"
+	PermissionInvoiceDelete     Permission = "invoice:delete"
+	PermissionMemberManage      Permission = "member:manage"
+	PermissionPlanManage        Permission = "plan:manage"
+	PermissionWebhookManage     Permission = "webhook:manage"
+	PermissionAuditRead         Permission = "audit:read"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleOwner: {
+		PermissionCompanyRead, PermissionCompanyWrite, PermissionBankAccountWrite, PermissionBankAccountReveal,
+		PermissionInvoiceRead, PermissionInvoiceWrite, PermissionInvoiceDelete,
+		PermissionMemberManage, PermissionPlanManage, PermissionWebhookManage, PermissionAuditRead,
+	},
+	RoleAdmin: {
+		PermissionCompanyRead, PermissionCompanyWrite, PermissionBankAccountWrite, PermissionBankAccountReveal,
+		PermissionInvoiceRead, PermissionInvoiceWrite, PermissionInvoiceDelete,
+		PermissionMemberManage, PermissionWebhookManage, PermissionAuditRead,
+	},
+	RoleAccountant: {
+		PermissionCompanyRead, PermissionBankAccountReveal,
+		PermissionInvoiceRead, PermissionInvoiceWrite, PermissionInvoiceDelete,
+		PermissionAuditRead,
+	},
+	RoleViewer: {
+		PermissionCompanyRead,
+		PermissionInvoiceRead,
+	},
+}
+
+// Can reports whether the role grants the given permission
+func (r Role) Can(permission Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// CompanyMember links a user to a company with a role. Rows are hard deleted
+// so a removed user can be invited again.
+type CompanyMember struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CompanyID uint      `json:"company_id" gorm:"not null;uniqueIndex:idx_company_members_company_user"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_company_members_company_user;index"`
+	Role      Role      `json:"role" gorm:"type:varchar(20);not null"`
+	User      *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Company   *Company  `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CompanyInvitation invites an email address to join a company. Only the
+// SHA-256 hash of the token is stored; the token itself is handed out once.
+type CompanyInvitation struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	CompanyID   uint           `json:"company_id" gorm:"not null;index"`
+	Email       string         `json:"email" gorm:"not null;index"`
+	Role        Role           `json:"role" gorm:"type:varchar(20);not null"`
+	TokenHash   string         `json:"-" gorm:"not null;uniqueIndex"`
+	InvitedByID uint           `json:"invited_by_id" gorm:"not null"`
+	ExpiresAt   time.Time      `json:"expires_at" gorm:"not null"`
+	AcceptedAt  *time.Time     `json:"accepted_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// Request DTOs
+type CreateInvitationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  Role   `json:"role" validate:"required,oneof=admin accountant viewer"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type UpdateMemberRequest struct {
+	Role Role `json:"role" validate:"required,oneof=admin accountant viewer"`
+}
+
+// Response DTOs
+type MemberResponse struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Role   Role   `json:"role"`
+}
+
+type InvitationResponse struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	Role      Role   `json:"role"`
+	ExpiresAt string `json:"expires_at"`
+	Token     string `json:"token,omitempty"` // Only returned when the invitation is created
+}
+
+// ToMemberResponse converts CompanyMember to MemberResponse
+func (m *CompanyMember) ToMemberResponse() MemberResponse {
+	resp := MemberResponse{
+		ID:     strconv.FormatUint(uint64(m.ID), 10),
+		UserID: strconv.FormatUint(uint64(m.UserID), 10),
+		Role:   m.Role,
+	}
+	if m.User != nil {
+		resp.Name = m.User.Name
+		resp.Email = m.User.Email
+	}
+	return resp
+}
+
+// ToInvitationResponse converts CompanyInvitation to InvitationResponse
+func (i *CompanyInvitation) ToInvitationResponse() InvitationResponse {
+	return InvitationResponse{
+		ID:        strconv.FormatUint(uint64(i.ID), 10),
+		Email:     i.Email,
+		Role:      i.Role,
+		ExpiresAt: i.ExpiresAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,44 @@
+package model
+
+import "testing"
+
+func TestRoleCan(t *testing.T) {
+	all := []Permission{
+		PermissionCompanyRead, PermissionCompanyWrite, PermissionBankAccountWrite, PermissionBankAccountReveal,
+		PermissionInvoiceRead, PermissionInvoiceWrite, PermissionInvoiceDelete,
+		PermissionMemberManage, PermissionPlanManage, PermissionWebhookManage, PermissionAuditRead,
+	}
+
+	tests := []struct {
+		role Role
+		want []Permission
+	}{
+		{RoleOwner, all},
+		{RoleAdmin, []Permission{
+			PermissionCompanyRead, PermissionCompanyWrite, PermissionBankAccountWrite, PermissionBankAccountReveal,
+			PermissionInvoiceRead, PermissionInvoiceWrite, PermissionInvoiceDelete,
+			PermissionMemberManage, PermissionWebhookManage, PermissionAuditRead,
+		}},
+		{RoleAccountant, []Permission{
+			PermissionCompanyRead, PermissionBankAccountReveal,
+			PermissionInvoiceRead, PermissionInvoiceWrite, PermissionInvoiceDelete,
+			PermissionAuditRead,
+		}},
+		{RoleViewer, []Permission{PermissionCompanyRead, PermissionInvoiceRead}},
+		{Role("guest"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			granted := map[Permission]bool{}
+			for _, p := range tt.want {
+				granted[p] = true
+			}
+			for _, p := range all {
+				if got := tt.role.Can(p); got != granted[p] {
+					t.Errorf("Can(%s) = %v, want %v", p, got, granted[p])
+				}
+			}
+		})
+	}
+}
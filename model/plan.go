@@ -15,7 +15,8 @@ const (
 
 type Plan struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
-	UserID    uint           `json:"user_id" gorm:"not null;uniqueIndex"`
+	CompanyID uint           `json:"company_id" gorm:"uniqueIndex"`
+	UserID    uint           `json:"user_id" gorm:"not null;index"` // User who last changed the plan
 	PlanType  PlanType       `json:"plan_type" gorm:"type:varchar(20);not null;default:'free'"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
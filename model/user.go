@@ -7,13 +7,27 @@ import (
 )
 
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Email     string         `json:"email" gorm:"uniqueIndex;not null"`
-	Name      string         `json:"name" gorm:"not null"`
-	Password  string         `json:"-" gorm:"not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID                   uint           `json:"id" gorm:"primaryKey"`
+	Email                string         `json:"email" gorm:"uniqueIndex;not null"`
+	Name                 string         `json:"name" gorm:"not null"`
+	Password             string         `json:"-" gorm:"not null"`
+	TOTPSecret           string         `json:"-" gorm:"type:text"` // Set on enrollment, only used once TOTPEnabled
+	TOTPEnabled          bool           `json:"totp_enabled" gorm:"not null;default:false"`
+	TOTPLastStep         int64          `json:"-" gorm:"not null;default:0"` // Last accepted time step, prevents code replay
+	TwoFactorAttempts    int            `json:"-" gorm:"not null;default:0"` // Second factor attempts since the last success, capped against guessing
+	TwoFactorAttemptedAt *time.Time     `json:"-"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// RecoveryCode is a single-use bcrypt-hashed code that can replace a TOTP code
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
 }
 
 type LoginRequest struct {
@@ -27,9 +41,43 @@ type RegisterRequest struct {
 	Name     string `json:"name" validate:"required"`
 }
 
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code"`          // TOTP code from the authenticator app
+	RecoveryCode   string `json:"recovery_code"` // Alternatively, one of the recovery codes
+}
+
+type TOTPConfirmRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TOTPDisableRequest struct {
+	Password     string `json:"password" validate:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
 type AuthResponse struct {
 	Token string `json:"token"`
 	Type  string `json:"type"`
 	User  User   `json:"user"`
 }
 
+// TwoFactorChallengeResponse is returned by Login instead of AuthResponse when
+// the user has 2FA enabled. The challenge token must be exchanged together with
+// a valid code for the full JWT.
+type TwoFactorChallengeResponse struct {
+	ChallengeToken string `json:"challenge_token"`
+	Type           string `json:"type"`
+	ExpiresIn      int    `json:"expires_in"` // Seconds
+}
+
+type TOTPEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	QRCode     string `json:"qr_code"` // PNG data URI
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,161 @@
+package model
+
+import (
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhookEvent string
+
+const (
+	WebhookEventInvoiceCreated  WebhookEvent = "invoice.created"
+	WebhookEventInvoiceUpdated  WebhookEvent = "invoice.updated"
+	WebhookEventInvoiceSent     WebhookEvent = "invoice.sent"
+	WebhookEventInvoicePaid     WebhookEvent = "invoice.paid"
+	WebhookEventInvoiceDeleted  WebhookEvent = "invoice.deleted"
+	WebhookEventPaymentRecorded WebhookEvent = "payment.recorded"
+)
+
+// WebhookEvents lists every event an endpoint can subscribe to
+var WebhookEvents = []WebhookEvent{
+	WebhookEventInvoiceCreated,
+	WebhookEventInvoiceUpdated,
+	WebhookEventInvoiceSent,
+	WebhookEventInvoicePaid,
+	WebhookEventInvoiceDeleted,
+	WebhookEventPaymentRecorded,
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookEndpoint is a URL registered by a company to receive events. It is
+// disabled automatically after too many consecutive failed attempts.
+type WebhookEndpoint struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	CompanyID    uint           `json:"company_id" gorm:"not null;index"`
+	URL          string         `json:"url" gorm:"not null"`
+	Secret       string         `json:"-" gorm:"not null"` // HMAC-SHA256 signing secret
+	Events       []WebhookEvent `json:"events" gorm:"serializer:json;type:text;not null"`
+	Enabled      bool           `json:"enabled" gorm:"not null;default:true"`
+	FailureCount int            `json:"failure_count" gorm:"not null;default:0"` // Consecutive failed attempts
+	DisabledAt   *time.Time     `json:"disabled_at"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// Subscribes reports whether the endpoint wants the given event
+func (e *WebhookEndpoint) Subscribes(event WebhookEvent) bool {
+	for _, ev := range e.Events {
+		if ev == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event queued for one endpoint. Pending deliveries are
+// picked up by the webhook worker once NextAttemptAt has passed.
+type WebhookDelivery struct {
+	ID             uint                  `json:"id" gorm:"primaryKey"`
+	EndpointID     uint                  `json:"endpoint_id" gorm:"not null;index"`
+	EventID        string                `json:"event_id" gorm:"not null;index"`
+	Event          WebhookEvent          `json:"event" gorm:"type:varchar(50);not null"`
+	Payload        string                `json:"payload" gorm:"type:text;not null"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"type:varchar(20);not null;default:pending;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int                   `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at" gorm:"not null;index:idx_webhook_deliveries_due,priority:2"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at"`
+	ResponseStatus int                   `json:"response_status"`
+	ResponseBody   string                `json:"-" gorm:"type:text"` // Excerpt for operators, never returned by the API
+	LastError      string                `json:"last_error" gorm:"type:text"`
+	Endpoint       *WebhookEndpoint      `json:"-" gorm:"foreignKey:EndpointID"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// Request DTOs
+type CreateWebhookEndpointRequest struct {
+	URL    string         `json:"url" validate:"required,url,max=2048"`
+	Events []WebhookEvent `json:"events" validate:"required,min=1"`
+}
+
+type UpdateWebhookEndpointRequest struct {
+	URL     *string        `json:"url" validate:"omitempty,url,max=2048"`
+	Events  []WebhookEvent `json:"events"`
+	Enabled *bool          `json:"enabled"` // Re-enabling resets the failure count
+}
+
+// Response DTOs
+type WebhookEndpointResponse struct {
+	ID           string         `json:"id"`
+	URL          string         `json:"url"`
+	Events       []WebhookEvent `json:"events"`
+	Enabled      bool           `json:"enabled"`
+	FailureCount int            `json:"failure_count"`
+	DisabledAt   *string        `json:"disabled_at"`
+	CreatedAt    string         `json:"created_at"`
+	Secret       string         `json:"secret,omitempty"` // Only returned when the endpoint is created
+}
+
+type WebhookDeliveryResponse struct {
+	ID             string                `json:"id"`
+	EventID        string                `json:"event_id"`
+	Event          WebhookEvent          `json:"event"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  string                `json:"next_attempt_at"`
+	LastAttemptAt  *string               `json:"last_attempt_at"`
+	ResponseStatus int                   `json:"response_status"`
+	LastError      string                `json:"last_error"`
+	CreatedAt      string                `json:"created_at"`
+}
+
+// ToWebhookEndpointResponse converts WebhookEndpoint to WebhookEndpointResponse
+func (e *WebhookEndpoint) ToWebhookEndpointResponse() WebhookEndpointResponse {
+	var disabledAt *string
+	if e.DisabledAt != nil {
+		s := e.DisabledAt.Format(time.RFC3339)
+		disabledAt = &s
+	}
+
+	return WebhookEndpointResponse{
+		ID:           strconv.FormatUint(uint64(e.ID), 10),
+		URL:          e.URL,
+		Events:       e.Events,
+		Enabled:      e.Enabled,
+		FailureCount: e.FailureCount,
+		DisabledAt:   disabledAt,
+		CreatedAt:    e.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// ToWebhookDeliveryResponse converts WebhookDelivery to WebhookDeliveryResponse
+func (d *WebhookDelivery) ToWebhookDeliveryResponse() WebhookDeliveryResponse {
+	var lastAttemptAt *string
+	if d.LastAttemptAt != nil {
+		s := d.LastAttemptAt.Format(time.RFC3339)
+		lastAttemptAt = &s
+	}
+
+	return WebhookDeliveryResponse{
+		ID:             strconv.FormatUint(uint64(d.ID), 10),
+		EventID:        d.EventID,
+		Event:          d.Event,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt.Format(time.RFC3339),
+		LastAttemptAt:  lastAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,241 @@
+package render
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/notblessy/bikinota-core/model"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// themes holds one parsed template set per built-in theme. Every set shares
+// the layout and partials, the theme file supplies its CSS and body.
+var themes = parseThemes()
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+func parseThemes() map[string]*template.Template {
+	parsed := make(map[string]*template.Template, len(model.InvoiceThemes))
+	for _, theme := range model.InvoiceThemes {
+		parsed[theme] = template.Must(template.New("layout.html").ParseFS(templateFS,
+			"templates/layout.html",
+			"templates/partials.html",
+			"templates/themes/"+theme+".html",
+		))
+	}
+	return parsed
+}
+
+// InvoiceDocument is everything needed to render an invoice
+type InvoiceDocument struct {
+	Invoice     *model.Invoice
+	Company     *model.Company
+	BankAccount *model.BankAccountSnapshot // Optional
+	Template    model.InvoiceTemplate
+}
+
+// HTML writes the invoice as a standalone, print-ready HTML document using
+// the theme and branding of doc.Template
+func HTML(w io.Writer, doc InvoiceDocument) error {
+	tmpl, ok := themes[doc.Template.Theme]
+	if !ok {
+		tmpl = themes[model.InvoiceThemeClassic]
+	}
+
+	if err := tmpl.ExecuteTemplate(w, "layout.html", newInvoiceView(doc)); err != nil {
+		return fmt.Errorf("failed to render invoice: %w", err)
+	}
+	return nil
+}
+
+type invoiceView struct {
+	Title        string
+	Accent       template.CSS
+	FontStack    template.CSS
+	Company      companyView
+	Number       string
+	Status       string
+	IssueDate    string
+	DueDate      string
+	CustomerName string
+	CustomerMail string
+	Items        []itemView
+	Adjustments  []adjustmentView
+	Subtotal     string
+	TaxRate      string
+	TaxAmount    string
+	Total        string
+	Bank         *bankView
+	PaymentTerms string
+	Notes        string
+	Footer       string
+}
+
+type companyView struct {
+	Name         string
+	AddressLines []string
+	Email        string
+	Phone        string
+	Website      string
+	TaxID        string
+	LogoURL      string
+}
+
+type itemView struct {
+	Name        string
+	Description string
+	Quantity    int
+	UnitPrice   string
+	Amount      string
+}
+
+type adjustmentView struct {
+	Description string
+	Amount      string
+}
+
+type bankView struct {
+	BankName      string
+	AccountName   string
+	AccountNumber string
+	SwiftCode     string
+	RoutingNumber string
+}
+
+func newInvoiceView(doc InvoiceDocument) invoiceView {
+	inv, company, t := doc.Invoice, doc.Company, doc.Template
+
+	// Accent colors are validated on save, keep the fallback for old rows
+	accent := t.AccentColor
+	if !hexColor.MatchString(accent) {
+		accent = model.DefaultInvoiceAccentColor
+	}
+
+	view := invoiceView{
+		Title:        "Invoice " + inv.InvoiceNumber,
+		Accent:       template.CSS(accent),
+		FontStack:    template.CSS(t.FontStack()),
+		Number:       inv.InvoiceNumber,
+		Status:       inv.Status,
+		IssueDate:    inv.CreatedAt.Format("2 January 2006"),
+		CustomerName: inv.CustomerName,
+		CustomerMail: inv.CustomerEmail,
+		Subtotal:     formatMoney(inv.Subtotal),
+		TaxRate:      strconv.FormatFloat(inv.TaxRate, 'f', -1, 64),
+		TaxAmount:    formatMoney(inv.TaxAmount),
+		Total:        formatMoney(inv.Total),
+		PaymentTerms: t.PaymentTerms,
+		Notes:        t.Notes,
+		Footer:       t.FooterText,
+	}
+
+	if inv.DueDate != nil {
+		view.DueDate = inv.DueDate.Format("2 January 2006")
+	}
+
+	if company != nil {
+		view.Company = companyView{
+			Name:         company.Name,
+			AddressLines: addressLines(company),
+			Email:        company.Email,
+			Phone:        company.Phone,
+			Website:      company.Website,
+			LogoURL:      company.LogoURL(),
+		}
+		if t.ShowTaxID {
+			view.Company.TaxID = company.TaxID
+		}
+	}
+
+	for _, item := range inv.Items {
+		view.Items = append(view.Items, itemView{
+			Name:        item.Name,
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			UnitPrice:   formatMoney(item.Price),
+			Amount:      formatMoney(item.Quantity * item.Price),
+		})
+	}
+
+	for _, adj := range inv.Adjustments {
+		amount := formatMoney(adj.Amount)
+		if adj.Type == "deduction" {
+			amount = "-" + amount
+		}
+		view.Adjustments = append(view.Adjustments, adjustmentView{
+			Description: adj.Description,
+			Amount:      amount,
+		})
+	}
+
+	if ba := doc.BankAccount; ba != nil {
+		view.Bank = &bankView{
+			BankName:      ba.BankName,
+			AccountName:   ba.AccountName,
+			AccountNumber: ba.AccountNumber,
+		}
+		if t.ShowSwiftCode && ba.SwiftCode != nil {
+			view.Bank.SwiftCode = *ba.SwiftCode
+		}
+		if t.ShowRoutingNumber && ba.RoutingNumber != nil {
+			view.Bank.RoutingNumber = *ba.RoutingNumber
+		}
+	}
+
+	return view
+}
+
+func addressLines(c *model.Company) []string {
+	var lines []string
+	if c.Address != "" {
+		lines = append(lines, c.Address)
+	}
+
+	cityLine := strings.Join(nonEmpty(c.City, c.State, c.ZipCode), ", ")
+	if cityLine != "" {
+		lines = append(lines, cityLine)
+	}
+
+	if c.Country != "" {
+		lines = append(lines, c.Country)
+	}
+	return lines
+}
+
+func nonEmpty(values ...string) []string {
+	var out []string
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// formatMoney formats an amount in the smallest currency unit as rupiah,
+// e.g. 150000050 becomes "Rp 1.500.000,50"
+func formatMoney(cents int) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	whole := strconv.Itoa(cents / 100)
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(digit)
+	}
+
+	return fmt.Sprintf("%sRp %s,%02d", sign, grouped.String(), cents%100)
+}
@@ -0,0 +1,137 @@
+package render
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func fixtureDocument(theme string) InvoiceDocument {
+	due := time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC)
+	swift := "BMRIIDJA"
+	routing := "008"
+
+	tmpl := model.DefaultInvoiceSettings(1).Templates[0]
+	tmpl.Theme = theme
+	tmpl.AccentColor = "#0f766e"
+	tmpl.Font = "inter"
+	tmpl.FooterText = "Thank you for your business."
+	tmpl.PaymentTerms = "Payment due within 30 days.\nLate payments incur a 2% fee."
+	tmpl.Notes = "Prices include <script>alert(1)</script> nothing executable."
+
+	return InvoiceDocument{
+		Invoice: &model.Invoice{
+			InvoiceNumber: "INV-2024-0042",
+			CustomerName:  "PT Maju & Jaya",
+			CustomerEmail: "finance@majujaya.co.id",
+			DueDate:       &due,
+			TaxRate:       11,
+			Status:        "sent",
+			Subtotal:      250000000,
+			TaxAmount:     27500000,
+			Total:         272500050,
+			Items: []model.InvoiceItem{
+				{Name: "Website redesign", Description: "Landing page and blog", Quantity: 1, Price: 200000000},
+				{Name: "Hosting", Quantity: 12, Price: 4166667},
+			},
+			Adjustments: []model.InvoiceAdjustment{
+				{Description: "Rush fee", Type: "addition", Amount: 5000050},
+				{Description: "Loyalty discount", Type: "deduction", Amount: 5000000},
+			},
+			CreatedAt: time.Date(2024, time.March, 1, 9, 30, 0, 0, time.UTC),
+		},
+		Company: &model.Company{
+			Name:      "Bikinota Studio",
+			Address:   "Jl. Sudirman No. 1",
+			City:      "Jakarta",
+			State:     "DKI Jakarta",
+			ZipCode:   "10220",
+			Country:   "Indonesia",
+			Email:     "hello@bikinota.com",
+			Phone:     "+62 21 555 0100",
+			Website:   "https://bikinota.com",
+			TaxID:     "01.234.567.8-901.000",
+			LogoAsset: &model.Asset{URL: "https://cdn.example.com/logo.png"},
+		},
+		BankAccount: &model.BankAccountSnapshot{
+			BankName:      "Bank Mandiri",
+			AccountName:   "Bikinota Studio",
+			AccountNumber: "1234567890",
+			SwiftCode:     &swift,
+			RoutingNumber: &routing,
+		},
+		Template: tmpl,
+	}
+}
+
+func TestHTMLGolden(t *testing.T) {
+	for _, theme := range model.InvoiceThemes {
+		t.Run(theme, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := HTML(&buf, fixtureDocument(theme)); err != nil {
+				t.Fatalf("HTML: %v", err)
+			}
+
+			golden := filepath.Join("testdata", theme+".golden.html")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("read golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("output differs from %s, run go test ./render -update and review the diff", golden)
+			}
+		})
+	}
+}
+
+func TestHTMLEscapesAndHidesOptionalFields(t *testing.T) {
+	doc := fixtureDocument(model.InvoiceThemeClassic)
+	doc.Template.ShowSwiftCode = false
+	doc.Template.ShowRoutingNumber = false
+	doc.Template.ShowTaxID = false
+	doc.Template.AccentColor = "red;}</style><script>"
+
+	var buf bytes.Buffer
+	if err := HTML(&buf, doc); err != nil {
+		t.Fatalf("HTML: %v", err)
+	}
+	out := buf.String()
+
+	for _, hidden := range []string{"BMRIIDJA", "Routing number", "01.234.567.8-901.000", "<script>"} {
+		if strings.Contains(out, hidden) {
+			t.Errorf("output should not contain %q", hidden)
+		}
+	}
+	if !strings.Contains(out, "--accent: "+model.DefaultInvoiceAccentColor) {
+		t.Error("invalid accent color should fall back to the default")
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	tests := map[int]string{
+		0:          "Rp 0,00",
+		5:          "Rp 0,05",
+		100000:     "Rp 1.000,00",
+		150000050:  "Rp 1.500.000,50",
+		-123456789: "-Rp 1.234.567,89",
+	}
+	for cents, want := range tests {
+		if got := formatMoney(cents); got != want {
+			t.Errorf("formatMoney(%d) = %q, want %q", cents, got, want)
+		}
+	}
+}
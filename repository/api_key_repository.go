@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"crypto/subtle"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"gorm.io/gorm"
+)
+
+// apiKeyTouchInterval limits how often last_used_at is written for busy keys
+const apiKeyTouchInterval = time.Minute
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, apiKey *model.APIKey, key string) error
+	FindByKey(ctx context.Context, prefix string, key string) (*model.APIKey, error)
+	ListByUserID(ctx context.Context, userID uint) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id uint, userID uint) error
+	Touch(ctx context.Context, id uint) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// Create stores the API key with the hash of the given plaintext key
+func (r *apiKeyRepository) Create(ctx context.Context, apiKey *model.APIKey, key string) error {
+	apiKey.KeyHash = hashToken(key)
+	return dbFor(ctx, r.db).Create(apiKey).Error
+}
+
+// FindByKey looks the key up by its prefix and verifies the full key hash
+func (r *apiKeyRepository) FindByKey(ctx context.Context, prefix string, key string) (*model.APIKey, error) {
+	var apiKey model.APIKey
+	err := dbFor(ctx, r.db).
+		Where("prefix = ?", prefix).
+		First(&apiKey).Error
+	if err != nil {
+		return nil, notFoundOr(err, "api key not found")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(hashToken(key))) != 1 {
+		return nil, NotFound("api key not found")
+	}
+
+	return &apiKey, nil
+}
+
+func (r *apiKeyRepository) ListByUserID(ctx context.Context, userID uint) ([]model.APIKey, error) {
+	var apiKeys []model.APIKey
+	err := dbFor(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&apiKeys).Error
+	return apiKeys, err
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uint, userID uint) error {
+	result := dbFor(ctx, r.db).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&model.APIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return NotFound("api key not found")
+	}
+	return nil
+}
+
+// Touch records that the key was used, at most once per apiKeyTouchInterval
+func (r *apiKeyRepository) Touch(ctx context.Context, id uint) error {
+	now := time.Now()
+	return dbFor(ctx, r.db).
+		Model(&model.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-apiKeyTouchInterval)).
+		Update("last_used_at", now).Error
+}
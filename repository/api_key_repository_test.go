@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/notblessy/bikinota-core/model"
+)
+
+func TestAPIKeyRepositoryFindByKey(t *testing.T) {
+	conn := newTestDB(t)
+	user, _ := newTestCompany(t, conn)
+	repo := NewAPIKeyRepository(conn)
+	ctx := context.Background()
+
+	const key = "bk_a1b2c3_secret"
+	apiKey := &model.APIKey{UserID: user.ID, Name: "CI", Prefix: "a1b2c3", Scopes: []model.Permission{model.PermissionInvoiceRead}}
+	if err := repo.Create(ctx, apiKey, key); err != nil {
+		t.Fatal(err)
+	}
+	if apiKey.KeyHash == "" || apiKey.KeyHash == key {
+		t.Fatalf("key hash = %q, want the hash of the key", apiKey.KeyHash)
+	}
+
+	found, err := repo.FindByKey(ctx, "a1b2c3", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != apiKey.ID || !found.HasScope(model.PermissionInvoiceRead) {
+		t.Errorf("found key %+v, want %d with invoice:read", found, apiKey.ID)
+	}
+
+	for _, tt := range []struct{ prefix, key string }{
+		{"a1b2c3", "bk_a1b2c3_other"},
+		{"d4e5f6", key},
+	} {
+		if _, err := repo.FindByKey(ctx, tt.prefix, tt.key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("FindByKey(%q, %q) error = %v, want not found", tt.prefix, tt.key, err)
+		}
+	}
+
+	if err := repo.Revoke(ctx, apiKey.ID, user.ID+1); err == nil {
+		t.Error("revoking another user's key succeeded")
+	}
+	if err := repo.Revoke(ctx, apiKey.ID, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.FindByKey(ctx, "a1b2c3", key); err == nil {
+		t.Error("revoked key still found")
+	}
+}
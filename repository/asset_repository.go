@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"gorm.io/gorm"
+)
+
+type AssetRepository interface {
+	Create(ctx context.Context, asset *model.Asset) error
+	FindOrphans(ctx context.Context, createdBefore time.Time, limit int) ([]model.Asset, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+type assetRepository struct {
+	db *gorm.DB
+}
+
+func NewAssetRepository(db *gorm.DB) AssetRepository {
+	return &assetRepository{db: db}
+}
+
+func (r *assetRepository) Create(ctx context.Context, asset *model.Asset) error {
+	return dbFor(ctx, r.db).Create(asset).Error
+}
+
+// FindOrphans returns assets created before createdBefore that no company
+// references anymore. Soft-deleted companies keep their assets.
+func (r *assetRepository) FindOrphans(ctx context.Context, createdBefore time.Time, limit int) ([]model.Asset, error) {
+	var assets []model.Asset
+	err := dbFor(ctx, r.db).
+		Where("created_at < ?", createdBefore).
+		Where("NOT EXISTS (SELECT 1 FROM companies c WHERE c.logo_asset_id = assets.id OR c.logo_thumb_asset_id = assets.id)").
+		Order("id ASC").
+		Limit(limit).
+		Find(&assets).Error
+	return assets, err
+}
+
+func (r *assetRepository) Delete(ctx context.Context, id uint) error {
+	return dbFor(ctx, r.db).Delete(&model.Asset{}, id).Error
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"gorm.io/gorm"
+)
+
+// AuditFilter selects audit entries of a company. Zero fields match all
+// entries.
+type AuditFilter struct {
+	CompanyID  uint
+	EntityType model.AuditEntity
+	EntityID   uint
+	UserID     uint
+	Action     model.AuditAction
+	From       time.Time // Inclusive
+	To         time.Time // Exclusive
+	BeforeID   uint      // Only entries older than this entry, for paging
+	Limit      int
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry *model.AuditEntry) error
+	List(ctx context.Context, filter AuditFilter) ([]model.AuditEntry, error)
+}
+
+type auditRepository struct {
+	db      *gorm.DB
+	replica *gorm.DB
+}
+
+// NewAuditRepository serves audit log queries from replica and writes entries
+// to db
+func NewAuditRepository(db, replica *gorm.DB) AuditRepository {
+	return &auditRepository{db: db, replica: replica}
+}
+
+func (r *auditRepository) Create(ctx context.Context, entry *model.AuditEntry) error {
+	return dbFor(ctx, r.db).Create(entry).Error
+}
+
+// List returns the matching entries with their actor, newest first
+func (r *auditRepository) List(ctx context.Context, filter AuditFilter) ([]model.AuditEntry, error) {
+	query := dbFor(ctx, r.replica).
+		Preload("User").
+		Where("company_id = ?", filter.CompanyID)
+
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.BeforeID != 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var entries []model.AuditEntry
+	err := query.Order("id DESC").Find(&entries).Error
+	return entries, err
+}
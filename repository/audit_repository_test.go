@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+)
+
+func TestAuditRepositoryList(t *testing.T) {
+	conn := newTestDB(t)
+	user, company := newTestCompany(t, conn)
+	repo := NewAuditRepository(conn, conn)
+	ctx := context.Background()
+
+	entries := []model.AuditEntry{
+		{CompanyID: company.ID, EntityType: model.AuditEntityInvoice, EntityID: 1, Action: model.AuditActionCreate},
+		{CompanyID: company.ID, EntityType: model.AuditEntityInvoice, EntityID: 1, Action: model.AuditActionUpdate},
+		{CompanyID: company.ID, EntityType: model.AuditEntityPlan, EntityID: 1, Action: model.AuditActionUpdate},
+		{CompanyID: company.ID + 1, EntityType: model.AuditEntityInvoice, EntityID: 1, Action: model.AuditActionCreate},
+	}
+	for i := range entries {
+		entries[i].UserID = user.ID
+		entries[i].IPAddress = "192.0.2.1"
+		entries[i].Changes = map[string]model.AuditChange{"status": {After: "draft"}}
+		if err := repo.Create(ctx, &entries[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter AuditFilter
+		want   []uint
+	}{
+		{"company", AuditFilter{CompanyID: company.ID}, []uint{entries[2].ID, entries[1].ID, entries[0].ID}},
+		{"entity", AuditFilter{CompanyID: company.ID, EntityType: model.AuditEntityInvoice, EntityID: 1}, []uint{entries[1].ID, entries[0].ID}},
+		{"action", AuditFilter{CompanyID: company.ID, Action: model.AuditActionCreate}, []uint{entries[0].ID}},
+		{"page", AuditFilter{CompanyID: company.ID, BeforeID: entries[2].ID, Limit: 1}, []uint{entries[1].ID}},
+		{"future", AuditFilter{CompanyID: company.ID, From: time.Now().Add(time.Hour)}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d", len(got), len(tt.want))
+			}
+			for i, e := range got {
+				if e.ID != tt.want[i] {
+					t.Errorf("entry %d = %d, want %d", i, e.ID, tt.want[i])
+				}
+				if e.User == nil || e.User.ID != user.ID {
+					t.Errorf("entry %d has user %+v, want %d", e.ID, e.User, user.ID)
+				}
+			}
+		})
+	}
+
+	got, err := repo.List(ctx, AuditFilter{CompanyID: company.ID, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change := got[0].Changes["status"]; change.After != "draft" {
+		t.Errorf("changes = %+v, want status set to draft", got[0].Changes)
+	}
+}
+
+func TestAuditEntriesAreAppendOnly(t *testing.T) {
+	conn := newTestDB(t)
+	user, company := newTestCompany(t, conn)
+
+	entry := &model.AuditEntry{
+		CompanyID:  company.ID,
+		UserID:     user.ID,
+		IPAddress:  "192.0.2.1",
+		EntityType: model.AuditEntityCompany,
+		EntityID:   company.ID,
+		Action:     model.AuditActionCreate,
+		Changes:    map[string]model.AuditChange{},
+	}
+	if err := NewAuditRepository(conn, conn).Create(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.Model(entry).Update("action", model.AuditActionDelete).Error; err == nil {
+		t.Error("updating an audit entry succeeded, want an error")
+	}
+	if err := conn.Delete(entry).Error; err == nil {
+		t.Error("deleting an audit entry succeeded, want an error")
+	}
+}
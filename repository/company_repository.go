@@ -6,20 +6,28 @@ import (
 
 	"github.com/notblessy/bikinota-core/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CompanyRepository interface {
-	FindByUserID(ctx context.Context, userID uint) (*model.Company, error)
+	FindByID(ctx context.Context, id uint) (*model.Company, error)
 	Create(ctx context.Context, company *model.Company) error
 	Update(ctx context.Context, company *model.Company) error
 	AddBankAccount(ctx context.Context, bankAccount *model.BankAccount) error
 	FindBankAccountByID(ctx context.Context, bankAccountID uint, companyID uint) (*model.BankAccount, error)
+	FindDefaultBankAccount(ctx context.Context, companyID uint) (*model.BankAccount, error)
 	UpdateBankAccount(ctx context.Context, bankAccount *model.BankAccount) error
 	DeleteBankAccount(ctx context.Context, bankAccountID uint, companyID uint) error
 	GetBankAccounts(ctx context.Context, companyID uint) ([]model.BankAccount, error)
 	SetDefaultBankAccount(ctx context.Context, bankAccountID uint, companyID uint) error
+	ReorderBankAccounts(ctx context.Context, companyID uint, bankAccountIDs []uint) error
 }
 
+var (
+	ErrBankAccountNotFound     = NotFound("bank account not found")
+	ErrInvalidBankAccountOrder = Validation("order must list every bank account of the company exactly once")
+)
+
 type companyRepository struct {
 	db *gorm.DB
 }
@@ -28,115 +36,238 @@ func NewCompanyRepository(db *gorm.DB) CompanyRepository {
 	return &companyRepository{db: db}
 }
 
-func (r *companyRepository) FindByUserID(ctx context.Context, userID uint) (*model.Company, error) {
+func (r *companyRepository) FindByID(ctx context.Context, id uint) (*model.Company, error) {
 	var company model.Company
-	err := r.db.WithContext(ctx).
-		Preload("BankAccounts").
-		Where("user_id = ?", userID).
-		First(&company).Error
+	err := dbFor(ctx, r.db).
+		Preload("BankAccounts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC, id ASC")
+		}).
+		Preload("LogoAsset").
+		Preload("LogoThumbAsset").
+		First(&company, id).Error
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil // Return nil if not found (not an error, just no company yet)
-		}
-		return nil, err
+		return nil, notFoundOr(err, "company not found")
 	}
 	return &company, nil
 }
 
+// Create creates the company and makes its creator the owner
 func (r *companyRepository) Create(ctx context.Context, company *model.Company) error {
-	return r.db.WithContext(ctx).Create(company).Error
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(company).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&model.CompanyMember{
+			CompanyID: company.ID,
+			UserID:    company.UserID,
+			Role:      model.RoleOwner,
+		}).Error
+	})
 }
 
 func (r *companyRepository) Update(ctx context.Context, company *model.Company) error {
-	return r.db.WithContext(ctx).Save(company).Error
+	// Logo assets are created separately, only their IDs are saved here
+	return dbFor(ctx, r.db).Omit("LogoAsset", "LogoThumbAsset").Save(company).Error
 }
 
+// AddBankAccount adds a bank account at the end of the company's list. The
+// first account of a company becomes its default.
 func (r *companyRepository) AddBankAccount(ctx context.Context, bankAccount *model.BankAccount) error {
-	// Check if this is the first bank account, make it default
-	var count int64
-	r.db.WithContext(ctx).Model(&model.BankAccount{}).
-		Where("company_id = ?", bankAccount.CompanyID).
-		Count(&count)
-	
-	if count == 0 {
-		bankAccount.IsDefault = true
-	}
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := lockCompany(tx, bankAccount.CompanyID); err != nil {
+			return err
+		}
+
+		var stats struct {
+			Defaults     int64
+			NextPosition int
+		}
+		err := tx.Model(&model.BankAccount{}).
+			Select("COUNT(CASE WHEN is_default THEN 1 END) AS defaults, COALESCE(MAX(position) + 1, 0) AS next_position").
+			Where("company_id = ?", bankAccount.CompanyID).
+			Scan(&stats).Error
+		if err != nil {
+			return err
+		}
 
-	return r.db.WithContext(ctx).Create(bankAccount).Error
+		bankAccount.IsDefault = stats.Defaults == 0
+		bankAccount.Position = stats.NextPosition
+		return tx.Create(bankAccount).Error
+	})
 }
 
 func (r *companyRepository) FindBankAccountByID(ctx context.Context, bankAccountID uint, companyID uint) (*model.BankAccount, error) {
 	var bankAccount model.BankAccount
-	err := r.db.WithContext(ctx).
+	err := dbFor(ctx, r.db).
 		Where("id = ? AND company_id = ?", bankAccountID, companyID).
 		First(&bankAccount).Error
+	if err != nil {
+		return nil, notFoundOr(err, ErrBankAccountNotFound.Error())
+	}
+	return &bankAccount, nil
+}
+
+// FindDefaultBankAccount returns the default bank account of a company, nil
+// if it has none
+func (r *companyRepository) FindDefaultBankAccount(ctx context.Context, companyID uint) (*model.BankAccount, error) {
+	var bankAccount model.BankAccount
+	err := dbFor(ctx, r.db).
+		Where("company_id = ? AND is_default = ?", companyID, true).
+		First(&bankAccount).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("bank account not found")
+			return nil, nil
 		}
 		return nil, err
 	}
 	return &bankAccount, nil
 }
 
+// UpdateBankAccount saves the details of a bank account. The default flag and
+// position are only changed through SetDefaultBankAccount and
+// ReorderBankAccounts.
 func (r *companyRepository) UpdateBankAccount(ctx context.Context, bankAccount *model.BankAccount) error {
-	return r.db.WithContext(ctx).Save(bankAccount).Error
+	return dbFor(ctx, r.db).Omit("IsDefault", "Position").Save(bankAccount).Error
 }
 
+// DeleteBankAccount deletes a bank account. When it was the default, the next
+// account in display order becomes the default.
 func (r *companyRepository) DeleteBankAccount(ctx context.Context, bankAccountID uint, companyID uint) error {
-	// Check if this is the default account
-	bankAccount, err := r.FindBankAccountByID(ctx, bankAccountID, companyID)
-	if err != nil {
-		return err
-	}
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := lockCompany(tx, companyID); err != nil {
+			return err
+		}
 
-	// Delete the account
-	err = r.db.WithContext(ctx).Delete(&model.BankAccount{}, bankAccountID).Error
-	if err != nil {
-		return err
-	}
+		var bankAccount model.BankAccount
+		err := tx.Where("id = ? AND company_id = ?", bankAccountID, companyID).First(&bankAccount).Error
+		if err != nil {
+			return notFoundOr(err, ErrBankAccountNotFound.Error())
+		}
 
-	// If it was the default, set the first remaining account as default
-	if bankAccount.IsDefault {
-		var remainingAccounts []model.BankAccount
-		r.db.WithContext(ctx).
-			Where("company_id = ?", companyID).
-			Order("created_at ASC").
-			Limit(1).
-			Find(&remainingAccounts)
-		
-		if len(remainingAccounts) > 0 {
-			remainingAccounts[0].IsDefault = true
-			r.db.WithContext(ctx).Save(&remainingAccounts[0])
+		// Update clears the flag on bankAccount as well
+		wasDefault := bankAccount.IsDefault
+
+		// Deleted rows keep no default flag so restoring one can't clash
+		// with the account promoted below
+		err = tx.Model(&bankAccount).Update("is_default", false).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&bankAccount).Error; err != nil {
+			return err
+		}
+
+		if !wasDefault {
+			return nil
+		}
+
+		var next model.BankAccount
+		err = tx.Where("company_id = ?", companyID).
+			Order("position ASC, id ASC").
+			First(&next).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
 		}
-	}
 
-	return nil
+		return tx.Model(&next).Update("is_default", true).Error
+	})
 }
 
+// GetBankAccounts returns the bank accounts of a company in display order
 func (r *companyRepository) GetBankAccounts(ctx context.Context, companyID uint) ([]model.BankAccount, error) {
 	var bankAccounts []model.BankAccount
-	err := r.db.WithContext(ctx).
+	err := dbFor(ctx, r.db).
 		Where("company_id = ?", companyID).
+		Order("position ASC, id ASC").
 		Find(&bankAccounts).Error
 	return bankAccounts, err
 }
 
+// SetDefaultBankAccount makes the bank account the only default of its company
 func (r *companyRepository) SetDefaultBankAccount(ctx context.Context, bankAccountID uint, companyID uint) error {
-	// First, unset all default accounts for this company
-	err := r.db.WithContext(ctx).
-		Model(&model.BankAccount{}).
-		Where("company_id = ?", companyID).
-		Update("is_default", false).Error
-	if err != nil {
-		return err
-	}
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := lockCompany(tx, companyID); err != nil {
+			return err
+		}
 
-	// Then set the specified account as default
-	err = r.db.WithContext(ctx).
-		Model(&model.BankAccount{}).
-		Where("id = ? AND company_id = ?", bankAccountID, companyID).
-		Update("is_default", true).Error
-	return err
+		var count int64
+		err := tx.Model(&model.BankAccount{}).
+			Where("id = ? AND company_id = ?", bankAccountID, companyID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrBankAccountNotFound
+		}
+
+		// Unset first, the unique index allows one default per company
+		err = tx.Model(&model.BankAccount{}).
+			Where("company_id = ? AND is_default = ? AND id <> ?", companyID, true, bankAccountID).
+			Update("is_default", false).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&model.BankAccount{}).
+			Where("id = ? AND company_id = ?", bankAccountID, companyID).
+			Update("is_default", true).Error
+	})
 }
 
+// ReorderBankAccounts sets the display order of a company's bank accounts.
+// bankAccountIDs must list every bank account of the company exactly once.
+func (r *companyRepository) ReorderBankAccounts(ctx context.Context, companyID uint, bankAccountIDs []uint) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := lockCompany(tx, companyID); err != nil {
+			return err
+		}
+
+		var existing []uint
+		err := tx.Model(&model.BankAccount{}).
+			Where("company_id = ?", companyID).
+			Pluck("id", &existing).Error
+		if err != nil {
+			return err
+		}
+
+		if len(existing) != len(bankAccountIDs) {
+			return ErrInvalidBankAccountOrder
+		}
+		remaining := make(map[uint]bool, len(existing))
+		for _, id := range existing {
+			remaining[id] = true
+		}
+		for _, id := range bankAccountIDs {
+			if !remaining[id] {
+				return ErrInvalidBankAccountOrder
+			}
+			delete(remaining, id)
+		}
+
+		for position, id := range bankAccountIDs {
+			err := tx.Model(&model.BankAccount{}).
+				Where("id = ?", id).
+				Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// lockCompany locks the company row until the end of the transaction,
+// serializing changes to the company's bank accounts
+func lockCompany(tx *gorm.DB, companyID uint) error {
+	var company model.Company
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&company, companyID).Error
+	return notFoundOr(err, "company not found")
+}
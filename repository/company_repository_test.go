@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/notblessy/bikinota-core/model"
+	"gorm.io/gorm"
+)
+
+// addTestBankAccounts adds n bank accounts to the company
+func addTestBankAccounts(t *testing.T, repo CompanyRepository, companyID uint, n int) []model.BankAccount {
+	t.Helper()
+
+	accounts := make([]model.BankAccount, n)
+	for i := range accounts {
+		accounts[i] = model.BankAccount{CompanyID: companyID, Country: "ID", BankName: "BCA", AccountName: "Acme", AccountNumber: "1234567890"}
+		if err := repo.AddBankAccount(context.Background(), &accounts[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return accounts
+}
+
+// defaultBankAccounts returns the IDs of the company's default accounts,
+// including soft-deleted ones
+func defaultBankAccounts(t *testing.T, conn *gorm.DB, companyID uint) []uint {
+	t.Helper()
+
+	var ids []uint
+	err := conn.Unscoped().Model(&model.BankAccount{}).
+		Where("company_id = ? AND is_default = ?", companyID, true).
+		Pluck("id", &ids).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func TestBankAccountDefault(t *testing.T) {
+	conn := newTestDB(t)
+	_, company := newTestCompany(t, conn)
+	repo := NewCompanyRepository(conn)
+	ctx := context.Background()
+
+	accounts := addTestBankAccounts(t, repo, company.ID, 3)
+	if got := defaultBankAccounts(t, conn, company.ID); len(got) != 1 || got[0] != accounts[0].ID {
+		t.Fatalf("defaults after adding = %v, want only the first account %d", got, accounts[0].ID)
+	}
+	for i, a := range accounts {
+		if a.Position != i {
+			t.Errorf("account %d has position %d, want %d", a.ID, a.Position, i)
+		}
+	}
+
+	if err := repo.SetDefaultBankAccount(ctx, accounts[2].ID, company.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := defaultBankAccounts(t, conn, company.ID); len(got) != 1 || got[0] != accounts[2].ID {
+		t.Fatalf("defaults after setting = %v, want only %d", got, accounts[2].ID)
+	}
+
+	// Another company's account cannot become the default
+	if err := repo.SetDefaultBankAccount(ctx, accounts[1].ID, company.ID+1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("setting the default of another company: %v, want not found", err)
+	}
+
+	// Deleting a non-default account keeps the default
+	if err := repo.DeleteBankAccount(ctx, accounts[1].ID, company.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := defaultBankAccounts(t, conn, company.ID); len(got) != 1 || got[0] != accounts[2].ID {
+		t.Fatalf("defaults after deleting another account = %v, want only %d", got, accounts[2].ID)
+	}
+
+	// Deleting the default promotes the first remaining account
+	if err := repo.DeleteBankAccount(ctx, accounts[2].ID, company.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := defaultBankAccounts(t, conn, company.ID); len(got) != 1 || got[0] != accounts[0].ID {
+		t.Fatalf("defaults after deleting the default = %v, want only %d", got, accounts[0].ID)
+	}
+
+	if err := repo.DeleteBankAccount(ctx, accounts[0].ID, company.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := defaultBankAccounts(t, conn, company.ID); len(got) != 0 {
+		t.Fatalf("defaults after deleting every account = %v, want none", got)
+	}
+
+	// The next account added becomes the default again
+	added := addTestBankAccounts(t, repo, company.ID, 1)
+	if got := defaultBankAccounts(t, conn, company.ID); len(got) != 1 || got[0] != added[0].ID {
+		t.Fatalf("defaults after adding again = %v, want only %d", got, added[0].ID)
+	}
+
+	if err := repo.DeleteBankAccount(ctx, accounts[0].ID, company.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting a deleted account: %v, want not found", err)
+	}
+}
+
+func TestReorderBankAccounts(t *testing.T) {
+	conn := newTestDB(t)
+	_, company := newTestCompany(t, conn)
+	repo := NewCompanyRepository(conn)
+	ctx := context.Background()
+
+	accounts := addTestBankAccounts(t, repo, company.ID, 3)
+	other := &model.Company{UserID: company.UserID, Name: "Globex"}
+	if err := repo.Create(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	foreign := addTestBankAccounts(t, repo, other.ID, 1)
+
+	tests := []struct {
+		name string
+		ids  []uint
+	}{
+		{"missing", []uint{accounts[2].ID, accounts[0].ID}},
+		{"duplicate", []uint{accounts[2].ID, accounts[0].ID, accounts[0].ID}},
+		{"foreign", []uint{accounts[2].ID, accounts[0].ID, foreign[0].ID}},
+		{"unknown", []uint{accounts[2].ID, accounts[0].ID, accounts[1].ID, 9999}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := repo.ReorderBankAccounts(ctx, company.ID, tt.ids); !errors.Is(err, ErrValidation) {
+				t.Errorf("reorder %v: %v, want %v", tt.ids, err, ErrInvalidBankAccountOrder)
+			}
+		})
+	}
+
+	// Rejected orders change nothing
+	got, err := repo.GetBankAccounts(ctx, company.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, a := range got {
+		if a.ID != accounts[i].ID {
+			t.Fatalf("order after rejected reorders: account %d at %d, want %d", a.ID, i, accounts[i].ID)
+		}
+	}
+
+	order := []uint{accounts[2].ID, accounts[0].ID, accounts[1].ID}
+	if err := repo.ReorderBankAccounts(ctx, company.ID, order); err != nil {
+		t.Fatal(err)
+	}
+	got, err = repo.GetBankAccounts(ctx, company.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, a := range got {
+		if a.ID != order[i] {
+			t.Errorf("account %d at %d, want %d", a.ID, i, order[i])
+		}
+	}
+	if got := defaultBankAccounts(t, conn, company.ID); len(got) != 1 || got[0] != accounts[0].ID {
+		t.Errorf("reordering changed the default to %v, want %d", got, accounts[0].ID)
+	}
+}
+
+func TestBankAccountDefaultIsUnique(t *testing.T) {
+	conn := newTestDB(t)
+	_, company := newTestCompany(t, conn)
+	accounts := addTestBankAccounts(t, NewCompanyRepository(conn), company.ID, 2)
+
+	// The partial unique index rejects a second default, whatever writes it
+	err := conn.Model(&model.BankAccount{}).Where("id = ?", accounts[1].ID).Update("is_default", true).Error
+	if err == nil {
+		t.Error("a second default bank account was accepted")
+	}
+}
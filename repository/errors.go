@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of domain errors. Match them with errors.Is; any other error returned
+// by a repository is an unexpected failure such as a database outage.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrForbidden  = errors.New("forbidden")
+	ErrValidation = errors.New("validation failed")
+)
+
+// Error is a domain error with a message that can be shown to clients
+type Error struct {
+	Kind    error  // One of ErrNotFound, ErrConflict, ErrForbidden or ErrValidation
+	Message string // Client facing description
+	Err     error  // Optional underlying error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is makes errors.Is(err, ErrNotFound) and the like match the error kind
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NotFound returns an error for a missing record
+func NotFound(message string) error {
+	return &Error{Kind: ErrNotFound, Message: message}
+}
+
+// Conflict returns an error for a change clashing with existing data
+func Conflict(message string) error {
+	return &Error{Kind: ErrConflict, Message: message}
+}
+
+// Forbidden returns an error for an action the caller may not perform
+func Forbidden(message string) error {
+	return &Error{Kind: ErrForbidden, Message: message}
+}
+
+// Validation returns an error for invalid input
+func Validation(message string) error {
+	return &Error{Kind: ErrValidation, Message: message}
+}
+
+// notFoundOr turns gorm.ErrRecordNotFound into a NotFound error with message
+// and returns other errors unchanged
+func notFoundOr(err error, message string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &Error{Kind: ErrNotFound, Message: message, Err: err}
+	}
+	return err
+}
+
+// conflictOr turns unique constraint violations into a Conflict error with
+// message and returns other errors unchanged
+func conflictOr(err error, message string) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return &Error{Kind: ErrConflict, Message: message, Err: err}
+	}
+	return err
+}
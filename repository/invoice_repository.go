@@ -4,34 +4,40 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/notblessy/bikinota-core/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type InvoiceRepository interface {
-	FindByUserID(ctx context.Context, userID uint) ([]*model.Invoice, error)
+	FindByCompanyID(ctx context.Context, companyID uint) ([]*model.Invoice, error)
 	FindByID(ctx context.Context, id uint) (*model.Invoice, error)
 	Create(ctx context.Context, invoice *model.Invoice) error
 	Update(ctx context.Context, invoice *model.Invoice) error
 	Delete(ctx context.Context, id uint) error
+	Renumber(ctx context.Context, companyID uint) (int, error)
 }
 
 type invoiceRepository struct {
-	db *gorm.DB
+	db      *gorm.DB
+	replica *gorm.DB
 }
 
-func NewInvoiceRepository(db *gorm.DB) InvoiceRepository {
-	return &invoiceRepository{db: db}
+// NewInvoiceRepository reads invoice lists from replica, which may lag behind
+// db. Without a read replica both are the same connection.
+func NewInvoiceRepository(db, replica *gorm.DB) InvoiceRepository {
+	return &invoiceRepository{db: db, replica: replica}
 }
 
-func (r *invoiceRepository) FindByUserID(ctx context.Context, userID uint) ([]*model.Invoice, error) {
+func (r *invoiceRepository) FindByCompanyID(ctx context.Context, companyID uint) ([]*model.Invoice, error) {
 	var invoices []*model.Invoice
-	err := r.db.WithContext(ctx).
+	err := dbFor(ctx, r.replica).
 		Preload("Items").
 		Preload("Adjustments").
-		Where("user_id = ?", userID).
+		Where("company_id = ?", companyID).
 		Order("created_at DESC").
 		Find(&invoices).Error
 	return invoices, err
@@ -39,36 +45,60 @@ func (r *invoiceRepository) FindByUserID(ctx context.Context, userID uint) ([]*m
 
 func (r *invoiceRepository) FindByID(ctx context.Context, id uint) (*model.Invoice, error) {
 	var invoice model.Invoice
-	err := r.db.WithContext(ctx).
+	err := dbFor(ctx, r.db).
 		Preload("Items").
 		Preload("Adjustments").
 		First(&invoice, id).Error
 	if err != nil {
-		return nil, err
+		return nil, notFoundOr(err, "invoice not found")
 	}
 	return &invoice, nil
 }
 
 func (r *invoiceRepository) Create(ctx context.Context, invoice *model.Invoice) error {
-	// Generate invoice number
-	year := time.Now().Year()
-	month := int(time.Now().Month())
-	
-	// Count invoices for this user this month
-	var count int64
-	r.db.WithContext(ctx).Model(&model.Invoice{}).
-		Where("user_id = ? AND EXTRACT(YEAR FROM created_at) = ? AND EXTRACT(MONTH FROM created_at) = ?", 
-			invoice.UserID, year, month).
-		Count(&count)
-	
-	invoice.InvoiceNumber = fmt.Sprintf("INV-%d%02d-%03d", year, month, count+1)
-	
-	return r.db.WithContext(ctx).Create(invoice).Error
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Creating and renumbering invoices of the company take turns, so
+		// two invoices never get the same number
+		if err := lockCompany(tx, invoice.CompanyID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		number, err := nextInvoiceNumber(tx, invoice.CompanyID, now.Year(), int(now.Month()))
+		if err != nil {
+			return fmt.Errorf("failed to number invoice: %w", err)
+		}
+		invoice.InvoiceNumber = number
+
+		return tx.Create(invoice).Error
+	})
+}
+
+// nextInvoiceNumber follows the highest number the company used in the
+// month. Deleted invoices count, their numbers stay taken.
+func nextInvoiceNumber(tx *gorm.DB, companyID uint, year, month int) (string, error) {
+	prefix := invoiceNumberPrefix(year, month)
+
+	var numbers []string
+	err := tx.Unscoped().Model(&model.Invoice{}).
+		Where("company_id = ? AND invoice_number LIKE ?", companyID, prefix+"%").
+		Pluck("invoice_number", &numbers).Error
+	if err != nil {
+		return "", err
+	}
+
+	last := 0
+	for _, number := range numbers {
+		if seq, err := strconv.Atoi(strings.TrimPrefix(number, prefix)); err == nil && seq > last {
+			last = seq
+		}
+	}
+	return invoiceNumber(year, month, last+1), nil
 }
 
 func (r *invoiceRepository) Update(ctx context.Context, invoice *model.Invoice) error {
 	// Use transaction to ensure atomicity
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
 		// Get existing items and adjustments
 		var existingItems []model.InvoiceItem
 		var existingAdjustments []model.InvoiceAdjustment
@@ -160,7 +190,80 @@ func (r *invoiceRepository) Update(ctx context.Context, invoice *model.Invoice)
 }
 
 func (r *invoiceRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&model.Invoice{}, id).Error
+	return dbFor(ctx, r.db).Delete(&model.Invoice{}, id).Error
+}
+
+// Renumber gives the company's invoices consecutive numbers per month in
+// creation order, the way Create numbers new invoices. Deleted invoices keep
+// their place in the sequence. It returns how many invoices got a new number.
+func (r *invoiceRepository) Renumber(ctx context.Context, companyID uint) (int, error) {
+	renumbered := 0
+	err := dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := lockCompany(tx, companyID); err != nil {
+			return err
+		}
+
+		var invoices []model.Invoice
+		err := tx.Unscoped().
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "invoice_number", "created_at").
+			Where("company_id = ?", companyID).
+			Order("created_at, id").
+			Find(&invoices).Error
+		if err != nil {
+			return err
+		}
+
+		numbers := map[uint]string{}
+		var ids []uint
+		year, month, seq := 0, 0, 0
+		for _, invoice := range invoices {
+			if invoice.CreatedAt.Year() != year || int(invoice.CreatedAt.Month()) != month {
+				year, month, seq = invoice.CreatedAt.Year(), int(invoice.CreatedAt.Month()), 0
+			}
+			seq++
+
+			if number := invoiceNumber(year, month, seq); number != invoice.InvoiceNumber {
+				numbers[invoice.ID] = number
+				ids = append(ids, invoice.ID)
+			}
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		// Free the old numbers first, a new number may still be held by
+		// another invoice of the company
+		err = tx.Unscoped().Model(&model.Invoice{}).
+			Where("id IN ?", ids).
+			Update("invoice_number", gorm.Expr("'renumber-' || id")).Error
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			err := tx.Unscoped().Model(&model.Invoice{}).
+				Where("id = ?", id).
+				Update("invoice_number", numbers[id]).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		renumbered = len(ids)
+		return nil
+	})
+	return renumbered, err
+}
+
+// invoiceNumber formats the seq-th invoice number of a month, e.g. INV-202401-007
+func invoiceNumber(year, month, seq int) string {
+	return fmt.Sprintf("%s%03d", invoiceNumberPrefix(year, month), seq)
+}
+
+// invoiceNumberPrefix is shared by the invoice numbers of a month, e.g. INV-202401-
+func invoiceNumberPrefix(year, month int) string {
+	return fmt.Sprintf("INV-%d%02d-", year, month)
 }
 
 // Helper function to convert string ID to uint
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+)
+
+func TestInvoiceRepositoryNumbering(t *testing.T) {
+	conn := newTestDB(t)
+	user, company := newTestCompany(t, conn)
+	repo := NewInvoiceRepository(conn, conn)
+	ctx := context.Background()
+
+	var invoices []*model.Invoice
+	for i := 0; i < 3; i++ {
+		invoice := &model.Invoice{UserID: user.ID, CompanyID: company.ID, CustomerName: "Customer", Status: "draft"}
+		if err := repo.Create(ctx, invoice); err != nil {
+			t.Fatal(err)
+		}
+		invoices = append(invoices, invoice)
+	}
+
+	now := time.Now()
+	for i, invoice := range invoices {
+		if want := invoiceNumber(now.Year(), int(now.Month()), i+1); invoice.InvoiceNumber != want {
+			t.Errorf("invoice %d is numbered %s, want %s", i, invoice.InvoiceNumber, want)
+		}
+	}
+
+	// Scramble the numbers, renumbering restores the creation order
+	for i, invoice := range invoices {
+		number := invoiceNumber(2000, 1, len(invoices)-i)
+		if err := conn.Model(invoice).Update("invoice_number", number).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	renumbered, err := repo.Renumber(ctx, company.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renumbered != len(invoices) {
+		t.Errorf("renumbered %d invoices, want %d", renumbered, len(invoices))
+	}
+
+	listed, err := repo.FindByCompanyID(ctx, company.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Listed newest first
+	for i, invoice := range listed {
+		if want := invoiceNumber(now.Year(), int(now.Month()), len(listed)-i); invoice.InvoiceNumber != want {
+			t.Errorf("invoice %d is numbered %s after renumbering, want %s", invoice.ID, invoice.InvoiceNumber, want)
+		}
+	}
+}
+
+// createTestInvoices creates n invoices of the company
+func createTestInvoices(t *testing.T, repo InvoiceRepository, company *model.Company, n int) []*model.Invoice {
+	t.Helper()
+
+	invoices := make([]*model.Invoice, n)
+	for i := range invoices {
+		invoices[i] = &model.Invoice{UserID: company.UserID, CompanyID: company.ID, CustomerName: "Customer", Status: "draft"}
+		if err := repo.Create(context.Background(), invoices[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return invoices
+}
+
+func TestInvoiceRepositoryNumberingSkipsDeletedNumbers(t *testing.T) {
+	conn := newTestDB(t)
+	_, company := newTestCompany(t, conn)
+	repo := NewInvoiceRepository(conn, conn)
+	ctx := context.Background()
+	now := time.Now()
+
+	invoices := createTestInvoices(t, repo, company, 3)
+
+	// The deleted invoices still hold their numbers, so counting the
+	// remaining ones would hand out INV-...-002 a second time
+	for _, invoice := range invoices[1:] {
+		if err := repo.Delete(ctx, invoice.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	created := createTestInvoices(t, repo, company, 1)[0]
+	if want := invoiceNumber(now.Year(), int(now.Month()), 4); created.InvoiceNumber != want {
+		t.Errorf("invoice created after deleting is numbered %s, want %s", created.InvoiceNumber, want)
+	}
+
+	// Renumbering keeps the deleted invoices' places, the next invoice
+	// follows the last of them
+	if _, err := repo.Renumber(ctx, company.ID); err != nil {
+		t.Fatal(err)
+	}
+	created = createTestInvoices(t, repo, company, 1)[0]
+	if want := invoiceNumber(now.Year(), int(now.Month()), 5); created.InvoiceNumber != want {
+		t.Errorf("invoice created after renumbering is numbered %s, want %s", created.InvoiceNumber, want)
+	}
+}
+
+func TestInvoiceRepositoryNumberingAfterRenumber(t *testing.T) {
+	conn := newTestDB(t)
+	_, company := newTestCompany(t, conn)
+	repo := NewInvoiceRepository(conn, conn)
+	ctx := context.Background()
+	now := time.Now()
+
+	// Numbers out of line with the creation order, one of them far ahead
+	invoices := createTestInvoices(t, repo, company, 2)
+	for i, number := range []string{invoiceNumber(now.Year(), int(now.Month()), 9), invoiceNumber(2000, 1, 1)} {
+		if err := conn.Model(invoices[i]).Update("invoice_number", number).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	created := createTestInvoices(t, repo, company, 1)[0]
+	if want := invoiceNumber(now.Year(), int(now.Month()), 10); created.InvoiceNumber != want {
+		t.Errorf("invoice is numbered %s, want %s after the highest number", created.InvoiceNumber, want)
+	}
+
+	if _, err := repo.Renumber(ctx, company.ID); err != nil {
+		t.Fatal(err)
+	}
+	created = createTestInvoices(t, repo, company, 1)[0]
+	if want := invoiceNumber(now.Year(), int(now.Month()), 4); created.InvoiceNumber != want {
+		t.Errorf("invoice created after renumbering is numbered %s, want %s", created.InvoiceNumber, want)
+	}
+}
+
+func TestInvoiceRepositoryCreateUnknownCompany(t *testing.T) {
+	conn := newTestDB(t)
+	user, company := newTestCompany(t, conn)
+
+	invoice := &model.Invoice{UserID: user.ID, CompanyID: company.ID + 1, CustomerName: "Customer", Status: "draft"}
+	if err := NewInvoiceRepository(conn, conn).Create(context.Background(), invoice); !errors.Is(err, ErrNotFound) {
+		t.Errorf("creating an invoice of an unknown company: %v, want not found", err)
+	}
+}
+
+func TestInvoiceRepositoryUpdateDiffsLines(t *testing.T) {
+	conn := newTestDB(t)
+	user, company := newTestCompany(t, conn)
+	repo := NewInvoiceRepository(conn, conn)
+	ctx := context.Background()
+
+	// An invoice of another company, whose lines must never be touched
+	other := &model.Company{UserID: user.ID, Name: "Globex"}
+	if err := NewCompanyRepository(conn).Create(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	foreign := &model.Invoice{
+		UserID: user.ID, CompanyID: other.ID, CustomerName: "Foreign", Status: "draft",
+		Items: []model.InvoiceItem{{Name: "Foreign item", Quantity: 1, Price: 100}},
+	}
+	if err := repo.Create(ctx, foreign); err != nil {
+		t.Fatal(err)
+	}
+
+	invoice := &model.Invoice{
+		UserID: user.ID, CompanyID: company.ID, CustomerName: "Customer", Status: "draft",
+		Items: []model.InvoiceItem{
+			{Name: "Kept", Quantity: 1, Price: 1000},
+			{Name: "Dropped", Quantity: 1, Price: 2000},
+		},
+		Adjustments: []model.InvoiceAdjustment{{Description: "Travel", Type: "addition", Amount: 500}},
+	}
+	if err := repo.Create(ctx, invoice); err != nil {
+		t.Fatal(err)
+	}
+	kept, dropped := invoice.Items[0].ID, invoice.Items[1].ID
+
+	tests := []struct {
+		name        string
+		items       []model.InvoiceItem
+		adjustments []model.InvoiceAdjustment
+		want        []string // Item names after the update, in ID order
+		keep        []uint   // Item IDs that must survive the update
+		gone        []uint   // Item IDs that must no longer exist
+	}{
+		{
+			name: "modifies, drops and adds items",
+			items: []model.InvoiceItem{
+				{ID: kept, Name: "Kept and renamed", Quantity: 2, Price: 1000},
+				{Name: "Added", Quantity: 1, Price: 3000},
+			},
+			want: []string{"Kept and renamed", "Added"},
+			keep: []uint{kept},
+			gone: []uint{dropped},
+		},
+		{
+			name: "treats foreign and unknown IDs as new items",
+			items: []model.InvoiceItem{
+				{ID: kept, Name: "Kept and renamed", Quantity: 2, Price: 1000},
+				{ID: foreign.Items[0].ID, Name: "Foreign ID", Quantity: 1, Price: 10},
+				{ID: 9999, Name: "Unknown ID", Quantity: 1, Price: 10},
+			},
+			want: []string{"Kept and renamed", "Foreign ID", "Unknown ID"},
+			keep: []uint{kept},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice.Items = tt.items
+			invoice.Adjustments = tt.adjustments
+			if err := repo.Update(ctx, invoice); err != nil {
+				t.Fatal(err)
+			}
+
+			stored, err := repo.FindByID(ctx, invoice.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			ids := map[uint]bool{}
+			for _, item := range stored.Items {
+				names = append(names, item.Name)
+				ids[item.ID] = true
+			}
+			if len(names) != len(tt.want) {
+				t.Fatalf("items = %v, want %v", names, tt.want)
+			}
+			for i := range names {
+				if names[i] != tt.want[i] {
+					t.Fatalf("items = %v, want %v", names, tt.want)
+				}
+			}
+			for _, id := range tt.keep {
+				if !ids[id] {
+					t.Errorf("item %d was replaced, want it updated in place", id)
+				}
+			}
+			for _, id := range tt.gone {
+				if ids[id] {
+					t.Errorf("item %d is still stored", id)
+				}
+			}
+
+			// Dropping every adjustment deletes them
+			if len(stored.Adjustments) != 0 {
+				t.Errorf("adjustments = %+v, want none", stored.Adjustments)
+			}
+		})
+	}
+
+	stored, err := repo.FindByID(ctx, foreign.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Items) != 1 || stored.Items[0].Name != "Foreign item" {
+		t.Errorf("items of the other company's invoice = %+v, want them unchanged", stored.Items)
+	}
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/notblessy/bikinota-core/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InvoiceSettingsRepository interface {
+	FindByCompanyID(ctx context.Context, companyID uint) (*model.InvoiceSettings, error)
+	Save(ctx context.Context, settings *model.InvoiceSettings) error
+}
+
+type invoiceSettingsRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceSettingsRepository(db *gorm.DB) InvoiceSettingsRepository {
+	return &invoiceSettingsRepository{db: db}
+}
+
+// FindByCompanyID returns the saved settings of a company, or the defaults
+// when it has none yet
+func (r *invoiceSettingsRepository) FindByCompanyID(ctx context.Context, companyID uint) (*model.InvoiceSettings, error) {
+	var settings model.InvoiceSettings
+	err := dbFor(ctx, r.db).
+		Where("company_id = ?", companyID).
+		First(&settings).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.DefaultInvoiceSettings(companyID), nil
+		}
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// Save creates or replaces the settings of the company
+func (r *invoiceSettingsRepository) Save(ctx context.Context, settings *model.InvoiceSettings) error {
+	return dbFor(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "company_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"default_template", "templates", "updated_at"}),
+		}).
+		Create(settings).Error
+}
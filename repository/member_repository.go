@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"gorm.io/gorm"
+)
+
+type MemberRepository interface {
+	FindDefaultByUserID(ctx context.Context, userID uint) (*model.CompanyMember, error)
+	FindByCompanyAndUser(ctx context.Context, companyID uint, userID uint) (*model.CompanyMember, error)
+	ListByUserID(ctx context.Context, userID uint) ([]model.CompanyMember, error)
+	FindByID(ctx context.Context, id uint, companyID uint) (*model.CompanyMember, error)
+	ListByCompanyID(ctx context.Context, companyID uint) ([]model.CompanyMember, error)
+	UpdateRole(ctx context.Context, member *model.CompanyMember) error
+	Delete(ctx context.Context, id uint, companyID uint) error
+	CreateInvitation(ctx context.Context, invitation *model.CompanyInvitation, token string) error
+	ListInvitations(ctx context.Context, companyID uint) ([]model.CompanyInvitation, error)
+	DeleteInvitation(ctx context.Context, id uint, companyID uint) error
+	FindInvitationByToken(ctx context.Context, token string) (*model.CompanyInvitation, error)
+	AcceptInvitation(ctx context.Context, invitation *model.CompanyInvitation, userID uint) (*model.CompanyMember, error)
+}
+
+type memberRepository struct {
+	db *gorm.DB
+}
+
+func NewMemberRepository(db *gorm.DB) MemberRepository {
+	return &memberRepository{db: db}
+}
+
+// FindDefaultByUserID returns the oldest membership of the user, used when no
+// company is selected explicitly
+func (r *memberRepository) FindDefaultByUserID(ctx context.Context, userID uint) (*model.CompanyMember, error) {
+	var member model.CompanyMember
+	err := dbFor(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil if not found (not an error, user has no company yet)
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *memberRepository) FindByCompanyAndUser(ctx context.Context, companyID uint, userID uint) (*model.CompanyMember, error) {
+	var member model.CompanyMember
+	err := dbFor(ctx, r.db).
+		Where("company_id = ? AND user_id = ?", companyID, userID).
+		First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil if not found (user is not a member)
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ListByUserID returns all memberships of the user with their companies
+func (r *memberRepository) ListByUserID(ctx context.Context, userID uint) ([]model.CompanyMember, error) {
+	var members []model.CompanyMember
+	err := dbFor(ctx, r.db).
+		Preload("Company.LogoAsset").
+		Joins("JOIN companies ON companies.id = company_members.company_id AND companies.deleted_at IS NULL").
+		Where("company_members.user_id = ?", userID).
+		Order("company_members.created_at ASC").
+		Find(&members).Error
+	return members, err
+}
+
+func (r *memberRepository) FindByID(ctx context.Context, id uint, companyID uint) (*model.CompanyMember, error) {
+	var member model.CompanyMember
+	err := dbFor(ctx, r.db).
+		Preload("User").
+		Where("id = ? AND company_id = ?", id, companyID).
+		First(&member).Error
+	if err != nil {
+		return nil, notFoundOr(err, "member not found")
+	}
+	return &member, nil
+}
+
+func (r *memberRepository) ListByCompanyID(ctx context.Context, companyID uint) ([]model.CompanyMember, error) {
+	var members []model.CompanyMember
+	err := dbFor(ctx, r.db).
+		Preload("User").
+		Where("company_id = ?", companyID).
+		Order("created_at ASC").
+		Find(&members).Error
+	return members, err
+}
+
+func (r *memberRepository) UpdateRole(ctx context.Context, member *model.CompanyMember) error {
+	return dbFor(ctx, r.db).
+		Model(&model.CompanyMember{}).
+		Where("id = ?", member.ID).
+		Update("role", member.Role).Error
+}
+
+func (r *memberRepository) Delete(ctx context.Context, id uint, companyID uint) error {
+	return dbFor(ctx, r.db).
+		Where("id = ? AND company_id = ?", id, companyID).
+		Delete(&model.CompanyMember{}).Error
+}
+
+// CreateInvitation stores the invitation with the hash of the given token
+func (r *memberRepository) CreateInvitation(ctx context.Context, invitation *model.CompanyInvitation, token string) error {
+	invitation.TokenHash = hashToken(token)
+	return dbFor(ctx, r.db).Create(invitation).Error
+}
+
+// ListInvitations returns pending invitations of a company
+func (r *memberRepository) ListInvitations(ctx context.Context, companyID uint) ([]model.CompanyInvitation, error) {
+	var invitations []model.CompanyInvitation
+	err := dbFor(ctx, r.db).
+		Where("company_id = ? AND accepted_at IS NULL AND expires_at > ?", companyID, time.Now()).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+func (r *memberRepository) DeleteInvitation(ctx context.Context, id uint, companyID uint) error {
+	result := dbFor(ctx, r.db).
+		Where("id = ? AND company_id = ?", id, companyID).
+		Delete(&model.CompanyInvitation{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return NotFound("invitation not found")
+	}
+	return nil
+}
+
+// FindInvitationByToken returns a pending, unexpired invitation for the token
+func (r *memberRepository) FindInvitationByToken(ctx context.Context, token string) (*model.CompanyInvitation, error) {
+	var invitation model.CompanyInvitation
+	err := dbFor(ctx, r.db).
+		Where("token_hash = ? AND accepted_at IS NULL AND expires_at > ?", hashToken(token), time.Now()).
+		First(&invitation).Error
+	if err != nil {
+		return nil, notFoundOr(err, "invitation not found")
+	}
+	return &invitation, nil
+}
+
+// AcceptInvitation marks the invitation as accepted and creates the membership
+// in a single transaction
+func (r *memberRepository) AcceptInvitation(ctx context.Context, invitation *model.CompanyInvitation, userID uint) (*model.CompanyMember, error) {
+	member := &model.CompanyMember{
+		CompanyID: invitation.CompanyID,
+		UserID:    userID,
+		Role:      invitation.Role,
+	}
+
+	err := dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.CompanyInvitation{}).
+			Where("id = ? AND accepted_at IS NULL", invitation.ID).
+			Update("accepted_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return Conflict("invitation already accepted")
+		}
+
+		return tx.Create(member).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -9,7 +9,7 @@ import (
 )
 
 type PlanRepository interface {
-	FindByUserID(ctx context.Context, userID uint) (*model.Plan, error)
+	FindByCompanyID(ctx context.Context, companyID uint) (*model.Plan, error)
 	Create(ctx context.Context, plan *model.Plan) error
 	Update(ctx context.Context, plan *model.Plan) error
 }
@@ -22,10 +22,10 @@ func NewPlanRepository(db *gorm.DB) PlanRepository {
 	return &planRepository{db: db}
 }
 
-func (r *planRepository) FindByUserID(ctx context.Context, userID uint) (*model.Plan, error) {
+func (r *planRepository) FindByCompanyID(ctx context.Context, companyID uint) (*model.Plan, error) {
 	var plan model.Plan
-	err := r.db.WithContext(ctx).
-		Where("user_id = ?", userID).
+	err := dbFor(ctx, r.db).
+		Where("company_id = ?", companyID).
 		First(&plan).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -37,9 +37,9 @@ func (r *planRepository) FindByUserID(ctx context.Context, userID uint) (*model.
 }
 
 func (r *planRepository) Create(ctx context.Context, plan *model.Plan) error {
-	return r.db.WithContext(ctx).Create(plan).Error
+	return dbFor(ctx, r.db).Create(plan).Error
 }
 
 func (r *planRepository) Update(ctx context.Context, plan *model.Plan) error {
-	return r.db.WithContext(ctx).Save(plan).Error
+	return dbFor(ctx, r.db).Save(plan).Error
 }
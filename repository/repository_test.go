@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/notblessy/bikinota-core/config"
+	"github.com/notblessy/bikinota-core/db"
+	"github.com/notblessy/bikinota-core/model"
+	"gorm.io/gorm"
+)
+
+// newTestDB returns a migrated in-memory SQLite database
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	ctx := context.Background()
+
+	conn, err := db.Open(ctx, config.DatabaseConfig{Driver: db.DriverSQLite, URL: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := conn.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	migrator, err := db.NewMigrator(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// newTestCompany creates a user owning a company
+func newTestCompany(t *testing.T, conn *gorm.DB) (*model.User, *model.Company) {
+	t.Helper()
+	ctx := context.Background()
+
+	user := &model.User{Email: "owner@example.com", Name: "Owner", Password: "secret123"}
+	if err := NewUserRepository(conn).Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	company := &model.Company{UserID: user.ID, Name: "Acme"}
+	if err := NewCompanyRepository(conn).Create(ctx, company); err != nil {
+		t.Fatal(err)
+	}
+	return user, company
+}
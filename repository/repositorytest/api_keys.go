@@ -0,0 +1,88 @@
+package repositorytest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+// APIKeyRepository is an in-memory repository.APIKeyRepository. Revoked keys
+// are removed.
+type APIKeyRepository struct {
+	mu      sync.Mutex
+	nextID  uint
+	apiKeys map[uint]model.APIKey
+}
+
+var _ repository.APIKeyRepository = (*APIKeyRepository)(nil)
+
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{apiKeys: map[uint]model.APIKey{}}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, apiKey *model.APIKey, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	now := time.Now()
+	apiKey.ID = r.nextID
+	apiKey.KeyHash = hashToken(key)
+	apiKey.CreatedAt, apiKey.UpdatedAt = now, now
+	r.apiKeys[apiKey.ID] = *apiKey
+	return nil
+}
+
+func (r *APIKeyRepository) FindByKey(ctx context.Context, prefix string, key string) (*model.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, k := range r.apiKeys {
+		if k.Prefix == prefix && k.KeyHash == hashToken(key) {
+			return &k, nil
+		}
+	}
+	return nil, repository.NotFound("api key not found")
+}
+
+func (r *APIKeyRepository) ListByUserID(ctx context.Context, userID uint) ([]model.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var apiKeys []model.APIKey
+	for _, k := range r.apiKeys {
+		if k.UserID == userID {
+			apiKeys = append(apiKeys, k)
+		}
+	}
+	sort.Slice(apiKeys, func(i, j int) bool { return apiKeys[i].ID > apiKeys[j].ID })
+	return apiKeys, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id uint, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k, ok := r.apiKeys[id]
+	if !ok || k.UserID != userID {
+		return repository.NotFound("api key not found")
+	}
+	delete(r.apiKeys, id)
+	return nil
+}
+
+func (r *APIKeyRepository) Touch(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if k, ok := r.apiKeys[id]; ok {
+		now := time.Now()
+		k.LastUsedAt = &now
+		r.apiKeys[id] = k
+	}
+	return nil
+}
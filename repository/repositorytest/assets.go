@@ -0,0 +1,47 @@
+package repositorytest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+// AssetRepository is an in-memory repository.AssetRepository. It does not
+// know which assets are referenced, so FindOrphans never returns any.
+type AssetRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	assets map[uint]model.Asset
+}
+
+var _ repository.AssetRepository = (*AssetRepository)(nil)
+
+func NewAssetRepository() *AssetRepository {
+	return &AssetRepository{assets: map[uint]model.Asset{}}
+}
+
+func (r *AssetRepository) Create(ctx context.Context, asset *model.Asset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	asset.ID = r.nextID
+	asset.CreatedAt = time.Now()
+	r.assets[asset.ID] = *asset
+	return nil
+}
+
+func (r *AssetRepository) FindOrphans(ctx context.Context, createdBefore time.Time, limit int) ([]model.Asset, error) {
+	return nil, nil
+}
+
+func (r *AssetRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.assets, id)
+	return nil
+}
@@ -0,0 +1,64 @@
+package repositorytest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+// AuditRepository is an in-memory repository.AuditRepository
+type AuditRepository struct {
+	mu      sync.Mutex
+	entries []model.AuditEntry // In insertion order
+
+	Err error // Returned by Create when set, to fail recording entries
+}
+
+var _ repository.AuditRepository = (*AuditRepository)(nil)
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry *model.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.Err != nil {
+		return r.Err
+	}
+	entry.ID = uint(len(r.entries) + 1)
+	entry.CreatedAt = time.Now()
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+// List returns the matching entries newest first, without their user
+func (r *AuditRepository) List(ctx context.Context, filter repository.AuditFilter) ([]model.AuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var entries []model.AuditEntry
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		e := r.entries[i]
+		switch {
+		case e.CompanyID != filter.CompanyID,
+			filter.EntityType != "" && e.EntityType != filter.EntityType,
+			filter.EntityID != 0 && e.EntityID != filter.EntityID,
+			filter.UserID != 0 && e.UserID != filter.UserID,
+			filter.Action != "" && e.Action != filter.Action,
+			!filter.From.IsZero() && e.CreatedAt.Before(filter.From),
+			!filter.To.IsZero() && !e.CreatedAt.Before(filter.To),
+			filter.BeforeID != 0 && e.ID >= filter.BeforeID:
+			continue
+		}
+		if filter.Limit > 0 && len(entries) == filter.Limit {
+			break
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
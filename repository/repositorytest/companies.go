@@ -0,0 +1,220 @@
+package repositorytest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+// CompanyRepository is an in-memory repository.CompanyRepository. Unlike the
+// database-backed repository, Create does not make the creator a member;
+// tests set up memberships themselves.
+type CompanyRepository struct {
+	mu            sync.Mutex
+	nextID        uint
+	nextAccountID uint
+	companies     map[uint]model.Company
+	bankAccounts  map[uint]model.BankAccount
+}
+
+var _ repository.CompanyRepository = (*CompanyRepository)(nil)
+
+func NewCompanyRepository() *CompanyRepository {
+	return &CompanyRepository{
+		companies:    map[uint]model.Company{},
+		bankAccounts: map[uint]model.BankAccount{},
+	}
+}
+
+func (r *CompanyRepository) FindByID(ctx context.Context, id uint) (*model.Company, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	company, ok := r.companies[id]
+	if !ok {
+		return nil, repository.NotFound("company not found")
+	}
+	company.BankAccounts = r.bankAccountsOf(id)
+	return &company, nil
+}
+
+func (r *CompanyRepository) Create(ctx context.Context, company *model.Company) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	now := time.Now()
+	company.ID = r.nextID
+	company.CreatedAt, company.UpdatedAt = now, now
+	r.companies[company.ID] = *company
+	return nil
+}
+
+func (r *CompanyRepository) Update(ctx context.Context, company *model.Company) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.companies[company.ID]; !ok {
+		return repository.NotFound("company not found")
+	}
+	company.UpdatedAt = time.Now()
+	stored := *company
+	stored.BankAccounts = nil
+	r.companies[company.ID] = stored
+	return nil
+}
+
+// AddBankAccount appends the account; the company's first account becomes
+// its default
+func (r *CompanyRepository) AddBankAccount(ctx context.Context, bankAccount *model.BankAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing := r.bankAccountsOf(bankAccount.CompanyID)
+	bankAccount.IsDefault = true
+	bankAccount.Position = 0
+	for _, account := range existing {
+		if account.IsDefault {
+			bankAccount.IsDefault = false
+		}
+		if account.Position >= bankAccount.Position {
+			bankAccount.Position = account.Position + 1
+		}
+	}
+
+	r.nextAccountID++
+	now := time.Now()
+	bankAccount.ID = r.nextAccountID
+	bankAccount.CreatedAt, bankAccount.UpdatedAt = now, now
+	r.bankAccounts[bankAccount.ID] = *bankAccount
+	return nil
+}
+
+func (r *CompanyRepository) FindBankAccountByID(ctx context.Context, bankAccountID uint, companyID uint) (*model.BankAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.bankAccounts[bankAccountID]
+	if !ok || account.CompanyID != companyID {
+		return nil, repository.ErrBankAccountNotFound
+	}
+	return &account, nil
+}
+
+// FindDefaultBankAccount returns nil without error when the company has no
+// default account
+func (r *CompanyRepository) FindDefaultBankAccount(ctx context.Context, companyID uint) (*model.BankAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, account := range r.bankAccountsOf(companyID) {
+		if account.IsDefault {
+			return &account, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *CompanyRepository) UpdateBankAccount(ctx context.Context, bankAccount *model.BankAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.bankAccounts[bankAccount.ID]
+	if !ok {
+		return repository.ErrBankAccountNotFound
+	}
+
+	// The default flag and position are managed separately
+	bankAccount.IsDefault = stored.IsDefault
+	bankAccount.Position = stored.Position
+	bankAccount.UpdatedAt = time.Now()
+	r.bankAccounts[bankAccount.ID] = *bankAccount
+	return nil
+}
+
+func (r *CompanyRepository) DeleteBankAccount(ctx context.Context, bankAccountID uint, companyID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.bankAccounts[bankAccountID]
+	if !ok || account.CompanyID != companyID {
+		return repository.ErrBankAccountNotFound
+	}
+	delete(r.bankAccounts, bankAccountID)
+
+	if remaining := r.bankAccountsOf(companyID); account.IsDefault && len(remaining) > 0 {
+		next := remaining[0]
+		next.IsDefault = true
+		r.bankAccounts[next.ID] = next
+	}
+	return nil
+}
+
+func (r *CompanyRepository) GetBankAccounts(ctx context.Context, companyID uint) ([]model.BankAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.bankAccountsOf(companyID), nil
+}
+
+func (r *CompanyRepository) SetDefaultBankAccount(ctx context.Context, bankAccountID uint, companyID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if account, ok := r.bankAccounts[bankAccountID]; !ok || account.CompanyID != companyID {
+		return repository.ErrBankAccountNotFound
+	}
+	for _, account := range r.bankAccountsOf(companyID) {
+		account.IsDefault = account.ID == bankAccountID
+		r.bankAccounts[account.ID] = account
+	}
+	return nil
+}
+
+func (r *CompanyRepository) ReorderBankAccounts(ctx context.Context, companyID uint, bankAccountIDs []uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing := r.bankAccountsOf(companyID)
+	if len(existing) != len(bankAccountIDs) {
+		return repository.ErrInvalidBankAccountOrder
+	}
+	remaining := make(map[uint]bool, len(existing))
+	for _, account := range existing {
+		remaining[account.ID] = true
+	}
+	for _, id := range bankAccountIDs {
+		if !remaining[id] {
+			return repository.ErrInvalidBankAccountOrder
+		}
+		delete(remaining, id)
+	}
+
+	for position, id := range bankAccountIDs {
+		account := r.bankAccounts[id]
+		account.Position = position
+		r.bankAccounts[id] = account
+	}
+	return nil
+}
+
+// bankAccountsOf returns the company's bank accounts in display order
+func (r *CompanyRepository) bankAccountsOf(companyID uint) []model.BankAccount {
+	var accounts []model.BankAccount
+	for _, account := range r.bankAccounts {
+		if account.CompanyID == companyID {
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Position != accounts[j].Position {
+			return accounts[i].Position < accounts[j].Position
+		}
+		return accounts[i].ID < accounts[j].ID
+	})
+	return accounts
+}
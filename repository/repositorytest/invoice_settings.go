@@ -0,0 +1,51 @@
+package repositorytest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+// InvoiceSettingsRepository is an in-memory repository.InvoiceSettingsRepository
+type InvoiceSettingsRepository struct {
+	mu       sync.Mutex
+	nextID   uint
+	settings map[uint]model.InvoiceSettings // By company ID
+}
+
+var _ repository.InvoiceSettingsRepository = (*InvoiceSettingsRepository)(nil)
+
+func NewInvoiceSettingsRepository() *InvoiceSettingsRepository {
+	return &InvoiceSettingsRepository{settings: map[uint]model.InvoiceSettings{}}
+}
+
+// FindByCompanyID returns the defaults when the company saved no settings
+func (r *InvoiceSettingsRepository) FindByCompanyID(ctx context.Context, companyID uint) (*model.InvoiceSettings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	settings, ok := r.settings[companyID]
+	if !ok {
+		return model.DefaultInvoiceSettings(companyID), nil
+	}
+	return &settings, nil
+}
+
+func (r *InvoiceSettingsRepository) Save(ctx context.Context, settings *model.InvoiceSettings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := r.settings[settings.CompanyID]; ok {
+		settings.ID, settings.CreatedAt = existing.ID, existing.CreatedAt
+	} else {
+		r.nextID++
+		settings.ID, settings.CreatedAt = r.nextID, now
+	}
+	settings.UpdatedAt = now
+	r.settings[settings.CompanyID] = *settings
+	return nil
+}
@@ -0,0 +1,201 @@
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+// InvoiceRepository is an in-memory repository.InvoiceRepository
+type InvoiceRepository struct {
+	mu       sync.Mutex
+	nextID   uint
+	nextLine uint // IDs of items and adjustments
+	invoices map[uint]model.Invoice
+	deleted  map[uint]model.Invoice // Soft-deleted invoices keep their number
+}
+
+var _ repository.InvoiceRepository = (*InvoiceRepository)(nil)
+
+func NewInvoiceRepository() *InvoiceRepository {
+	return &InvoiceRepository{invoices: map[uint]model.Invoice{}, deleted: map[uint]model.Invoice{}}
+}
+
+// FindByCompanyID returns the company's invoices, newest first
+func (r *InvoiceRepository) FindByCompanyID(ctx context.Context, companyID uint) ([]*model.Invoice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var invoices []*model.Invoice
+	for _, invoice := range r.invoices {
+		if invoice.CompanyID == companyID {
+			invoices = append(invoices, copyInvoice(invoice))
+		}
+	}
+	sort.Slice(invoices, func(i, j int) bool {
+		return invoices[i].ID > invoices[j].ID
+	})
+	return invoices, nil
+}
+
+func (r *InvoiceRepository) FindByID(ctx context.Context, id uint) (*model.Invoice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invoice, ok := r.invoices[id]
+	if !ok {
+		return nil, repository.NotFound("invoice not found")
+	}
+	return copyInvoice(invoice), nil
+}
+
+// Create numbers the invoice within its company and month like the
+// database-backed repository, after the highest number used so far
+func (r *InvoiceRepository) Create(ctx context.Context, invoice *model.Invoice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	prefix := fmt.Sprintf("INV-%d%02d-", now.Year(), int(now.Month()))
+	last := 0
+	for _, existing := range r.all() {
+		if existing.CompanyID != invoice.CompanyID || !strings.HasPrefix(existing.InvoiceNumber, prefix) {
+			continue
+		}
+		if seq, err := strconv.Atoi(strings.TrimPrefix(existing.InvoiceNumber, prefix)); err == nil && seq > last {
+			last = seq
+		}
+	}
+
+	r.nextID++
+	invoice.ID = r.nextID
+	invoice.InvoiceNumber = fmt.Sprintf("%s%03d", prefix, last+1)
+	invoice.CreatedAt, invoice.UpdatedAt = now, now
+	r.assignLineIDs(invoice, nil)
+	r.invoices[invoice.ID] = *copyInvoice(*invoice)
+	return nil
+}
+
+// Update saves the invoice. Items and adjustments keep their ID when it
+// belongs to the stored invoice and get a new one otherwise.
+func (r *InvoiceRepository) Update(ctx context.Context, invoice *model.Invoice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.invoices[invoice.ID]
+	if !ok {
+		return repository.NotFound("invoice not found")
+	}
+
+	invoice.UpdatedAt = time.Now()
+	r.assignLineIDs(invoice, &stored)
+	r.invoices[invoice.ID] = *copyInvoice(*invoice)
+	return nil
+}
+
+func (r *InvoiceRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if invoice, ok := r.invoices[id]; ok {
+		r.deleted[id] = invoice
+		delete(r.invoices, id)
+	}
+	return nil
+}
+
+// Renumber gives the company's invoices consecutive numbers per month in
+// creation order. Deleted invoices keep their place in the sequence.
+func (r *InvoiceRepository) Renumber(ctx context.Context, companyID uint) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var invoices []model.Invoice
+	for _, invoice := range r.all() {
+		if invoice.CompanyID == companyID {
+			invoices = append(invoices, invoice)
+		}
+	}
+	sort.Slice(invoices, func(i, j int) bool {
+		if !invoices[i].CreatedAt.Equal(invoices[j].CreatedAt) {
+			return invoices[i].CreatedAt.Before(invoices[j].CreatedAt)
+		}
+		return invoices[i].ID < invoices[j].ID
+	})
+
+	renumbered := 0
+	year, month, seq := 0, time.Month(0), 0
+	for _, invoice := range invoices {
+		if invoice.CreatedAt.Year() != year || invoice.CreatedAt.Month() != month {
+			year, month, seq = invoice.CreatedAt.Year(), invoice.CreatedAt.Month(), 0
+		}
+		seq++
+
+		if number := fmt.Sprintf("INV-%d%02d-%03d", year, int(month), seq); number != invoice.InvoiceNumber {
+			invoice.InvoiceNumber = number
+			if _, ok := r.deleted[invoice.ID]; ok {
+				r.deleted[invoice.ID] = invoice
+			} else {
+				r.invoices[invoice.ID] = invoice
+			}
+			renumbered++
+		}
+	}
+	return renumbered, nil
+}
+
+// all returns the stored invoices, deleted ones included
+func (r *InvoiceRepository) all() []model.Invoice {
+	invoices := make([]model.Invoice, 0, len(r.invoices)+len(r.deleted))
+	for _, invoice := range r.invoices {
+		invoices = append(invoices, invoice)
+	}
+	for _, invoice := range r.deleted {
+		invoices = append(invoices, invoice)
+	}
+	return invoices
+}
+
+// assignLineIDs links items and adjustments to the invoice, keeping the IDs of
+// those already stored with it
+func (r *InvoiceRepository) assignLineIDs(invoice *model.Invoice, stored *model.Invoice) {
+	itemIDs := map[uint]bool{}
+	adjustmentIDs := map[uint]bool{}
+	if stored != nil {
+		for _, item := range stored.Items {
+			itemIDs[item.ID] = true
+		}
+		for _, adj := range stored.Adjustments {
+			adjustmentIDs[adj.ID] = true
+		}
+	}
+
+	for i := range invoice.Items {
+		invoice.Items[i].InvoiceID = invoice.ID
+		if !itemIDs[invoice.Items[i].ID] {
+			r.nextLine++
+			invoice.Items[i].ID = r.nextLine
+		}
+	}
+	for i := range invoice.Adjustments {
+		invoice.Adjustments[i].InvoiceID = invoice.ID
+		if !adjustmentIDs[invoice.Adjustments[i].ID] {
+			r.nextLine++
+			invoice.Adjustments[i].ID = r.nextLine
+		}
+	}
+}
+
+// copyInvoice copies the invoice so callers can't modify the stored one
+func copyInvoice(invoice model.Invoice) *model.Invoice {
+	invoice.Items = append([]model.InvoiceItem(nil), invoice.Items...)
+	invoice.Adjustments = append([]model.InvoiceAdjustment(nil), invoice.Adjustments...)
+	return &invoice
+}
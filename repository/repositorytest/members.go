@@ -0,0 +1,202 @@
+package repositorytest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+// MemberRepository is an in-memory repository.MemberRepository
+type MemberRepository struct {
+	mu               sync.Mutex
+	nextID           uint
+	nextInvitationID uint
+	members          map[uint]model.CompanyMember
+	invitations      map[uint]model.CompanyInvitation
+}
+
+var _ repository.MemberRepository = (*MemberRepository)(nil)
+
+func NewMemberRepository() *MemberRepository {
+	return &MemberRepository{
+		members:     map[uint]model.CompanyMember{},
+		invitations: map[uint]model.CompanyInvitation{},
+	}
+}
+
+// Add stores a membership directly, for setting up tests
+func (r *MemberRepository) Add(member *model.CompanyMember) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	member.ID = r.nextID
+	member.CreatedAt = time.Now()
+	r.members[member.ID] = *member
+}
+
+func (r *MemberRepository) FindDefaultByUserID(ctx context.Context, userID uint) (*model.CompanyMember, error) {
+	members, _ := r.ListByUserID(ctx, userID)
+	if len(members) == 0 {
+		return nil, nil
+	}
+	return &members[0], nil
+}
+
+func (r *MemberRepository) FindByCompanyAndUser(ctx context.Context, companyID uint, userID uint) (*model.CompanyMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, m := range r.members {
+		if m.CompanyID == companyID && m.UserID == userID {
+			return &m, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemberRepository) ListByUserID(ctx context.Context, userID uint) ([]model.CompanyMember, error) {
+	return r.list(func(m model.CompanyMember) bool { return m.UserID == userID }), nil
+}
+
+func (r *MemberRepository) FindByID(ctx context.Context, id uint, companyID uint) (*model.CompanyMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.members[id]
+	if !ok || m.CompanyID != companyID {
+		return nil, repository.NotFound("member not found")
+	}
+	return &m, nil
+}
+
+func (r *MemberRepository) ListByCompanyID(ctx context.Context, companyID uint) ([]model.CompanyMember, error) {
+	return r.list(func(m model.CompanyMember) bool { return m.CompanyID == companyID }), nil
+}
+
+func (r *MemberRepository) UpdateRole(ctx context.Context, member *model.CompanyMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m, ok := r.members[member.ID]; ok {
+		m.Role = member.Role
+		r.members[member.ID] = m
+	}
+	return nil
+}
+
+func (r *MemberRepository) Delete(ctx context.Context, id uint, companyID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m, ok := r.members[id]; ok && m.CompanyID == companyID {
+		delete(r.members, id)
+	}
+	return nil
+}
+
+func (r *MemberRepository) CreateInvitation(ctx context.Context, invitation *model.CompanyInvitation, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextInvitationID++
+	now := time.Now()
+	invitation.ID = r.nextInvitationID
+	invitation.TokenHash = hashToken(token)
+	invitation.CreatedAt, invitation.UpdatedAt = now, now
+	r.invitations[invitation.ID] = *invitation
+	return nil
+}
+
+func (r *MemberRepository) ListInvitations(ctx context.Context, companyID uint) ([]model.CompanyInvitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var invitations []model.CompanyInvitation
+	for _, inv := range r.invitations {
+		if inv.CompanyID == companyID && pending(inv) {
+			invitations = append(invitations, inv)
+		}
+	}
+	sort.Slice(invitations, func(i, j int) bool { return invitations[i].ID > invitations[j].ID })
+	return invitations, nil
+}
+
+func (r *MemberRepository) DeleteInvitation(ctx context.Context, id uint, companyID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inv, ok := r.invitations[id]
+	if !ok || inv.CompanyID != companyID {
+		return repository.NotFound("invitation not found")
+	}
+	delete(r.invitations, id)
+	return nil
+}
+
+func (r *MemberRepository) FindInvitationByToken(ctx context.Context, token string) (*model.CompanyInvitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hash := hashToken(token)
+	for _, inv := range r.invitations {
+		if inv.TokenHash == hash && pending(inv) {
+			return &inv, nil
+		}
+	}
+	return nil, repository.NotFound("invitation not found")
+}
+
+func (r *MemberRepository) AcceptInvitation(ctx context.Context, invitation *model.CompanyInvitation, userID uint) (*model.CompanyMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inv, ok := r.invitations[invitation.ID]
+	if !ok || inv.AcceptedAt != nil {
+		return nil, repository.Conflict("invitation already accepted")
+	}
+	now := time.Now()
+	inv.AcceptedAt = &now
+	r.invitations[inv.ID] = inv
+
+	r.nextID++
+	member := model.CompanyMember{
+		ID:        r.nextID,
+		CompanyID: inv.CompanyID,
+		UserID:    userID,
+		Role:      inv.Role,
+		CreatedAt: now,
+	}
+	r.members[member.ID] = member
+	return &member, nil
+}
+
+// list returns the members matching keep, oldest first
+func (r *MemberRepository) list(keep func(model.CompanyMember) bool) []model.CompanyMember {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var members []model.CompanyMember
+	for _, m := range r.members {
+		if keep(m) {
+			members = append(members, m)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+	return members
+}
+
+func pending(inv model.CompanyInvitation) bool {
+	return inv.AcceptedAt == nil && inv.ExpiresAt.After(time.Now())
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
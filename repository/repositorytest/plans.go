@@ -0,0 +1,60 @@
+package repositorytest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+// PlanRepository is an in-memory repository.PlanRepository
+type PlanRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	plans  map[uint]model.Plan // By company ID
+}
+
+var _ repository.PlanRepository = (*PlanRepository)(nil)
+
+func NewPlanRepository() *PlanRepository {
+	return &PlanRepository{plans: map[uint]model.Plan{}}
+}
+
+// FindByCompanyID returns nil without error when the company has no plan
+func (r *PlanRepository) FindByCompanyID(ctx context.Context, companyID uint) (*model.Plan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	plan, ok := r.plans[companyID]
+	if !ok {
+		return nil, nil
+	}
+	return &plan, nil
+}
+
+func (r *PlanRepository) Create(ctx context.Context, plan *model.Plan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.plans[plan.CompanyID]; ok {
+		return repository.Conflict("company already has a plan")
+	}
+
+	r.nextID++
+	now := time.Now()
+	plan.ID = r.nextID
+	plan.CreatedAt, plan.UpdatedAt = now, now
+	r.plans[plan.CompanyID] = *plan
+	return nil
+}
+
+func (r *PlanRepository) Update(ctx context.Context, plan *model.Plan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	plan.UpdatedAt = time.Now()
+	r.plans[plan.CompanyID] = *plan
+	return nil
+}
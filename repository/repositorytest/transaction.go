@@ -0,0 +1,17 @@
+package repositorytest
+
+import (
+	"context"
+
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+// Transactor runs functions without a transaction. The in-memory
+// repositories apply changes right away and cannot roll them back.
+type Transactor struct{}
+
+var _ repository.Transactor = Transactor{}
+
+func (Transactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
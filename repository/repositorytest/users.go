@@ -0,0 +1,188 @@
+// Package repositorytest provides in-memory implementations of the
+// repositories for handler tests. They keep copies of the stored models and
+// return the same typed errors as the database-backed repositories.
+package repositorytest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserRepository is an in-memory repository.UserRepository
+type UserRepository struct {
+	mu            sync.Mutex
+	nextID        uint
+	users         map[uint]model.User
+	recoveryCodes map[uint][]model.RecoveryCode
+}
+
+var _ repository.UserRepository = (*UserRepository)(nil)
+
+func NewUserRepository() *UserRepository {
+	return &UserRepository{
+		users:         map[uint]model.User{},
+		recoveryCodes: map[uint][]model.RecoveryCode{},
+	}
+}
+
+// Create hashes the password with the minimum bcrypt cost to keep tests fast
+func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return repository.Conflict("email already registered")
+		}
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.MinCost)
+	if err != nil {
+		return err
+	}
+
+	r.nextID++
+	now := time.Now()
+	user.ID = r.nextID
+	user.Password = string(hashed)
+	user.CreatedAt, user.UpdatedAt = now, now
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, repository.NotFound("user not found")
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id uint) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, repository.NotFound("user not found")
+	}
+	return &user, nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Like the database, keep the attempt counter of the stored user
+	stored := r.users[user.ID]
+	user.TwoFactorAttempts, user.TwoFactorAttemptedAt = stored.TwoFactorAttempts, stored.TwoFactorAttemptedAt
+	user.UpdatedAt = time.Now()
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *UserRepository) SetPassword(ctx context.Context, userID uint, password string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return repository.NotFound("user not found")
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		return err
+	}
+	user.Password = string(hashed)
+	r.users[userID] = user
+	return nil
+}
+
+func (r *UserRepository) ClaimTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok || user.TOTPLastStep >= step {
+		return false, nil
+	}
+	user.TOTPLastStep = step
+	r.users[userID] = user
+	return true, nil
+}
+
+func (r *UserRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codes []string) error {
+	recoveryCodes := make([]model.RecoveryCode, len(codes))
+	for i, code := range codes {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.MinCost)
+		if err != nil {
+			return err
+		}
+		recoveryCodes[i] = model.RecoveryCode{UserID: userID, CodeHash: string(hashed)}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recoveryCodes[userID] = recoveryCodes
+	return nil
+}
+
+func (r *UserRepository) UseRecoveryCode(ctx context.Context, userID uint, code string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, rc := range r.recoveryCodes[userID] {
+		if rc.UsedAt != nil || bcrypt.CompareHashAndPassword([]byte(rc.CodeHash), []byte(code)) != nil {
+			continue
+		}
+		now := time.Now()
+		r.recoveryCodes[userID][i].UsedAt = &now
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *UserRepository) ReserveTwoFactorAttempt(ctx context.Context, userID uint, limit int, window time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return false, nil
+	}
+
+	now := time.Now()
+	expired := user.TwoFactorAttemptedAt == nil || user.TwoFactorAttemptedAt.Before(now.Add(-window))
+	switch {
+	case expired:
+		user.TwoFactorAttempts = 1
+	case user.TwoFactorAttempts < limit:
+		user.TwoFactorAttempts++
+	default:
+		return false, nil
+	}
+	user.TwoFactorAttemptedAt = &now
+	r.users[userID] = user
+	return true, nil
+}
+
+func (r *UserRepository) ResetTwoFactorAttempts(ctx context.Context, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user, ok := r.users[userID]; ok {
+		user.TwoFactorAttempts, user.TwoFactorAttemptedAt = 0, nil
+		r.users[userID] = user
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/notblessy/bikinota-core/model"
+)
+
+func TestTransactionStoresChangesWithTheirAuditEntries(t *testing.T) {
+	conn := newTestDB(t)
+	_, company := newTestCompany(t, conn)
+	companies := NewCompanyRepository(conn)
+	audits := NewAuditRepository(conn, conn)
+	transactor := NewTransactor(conn)
+	ctx := context.Background()
+
+	// change adds a bank account and audits it, like the handlers do
+	change := func(fail error) error {
+		return transactor.Transaction(ctx, func(ctx context.Context) error {
+			account := &model.BankAccount{CompanyID: company.ID, Country: "ID", BankName: "BCA", AccountName: "Acme", AccountNumber: "1234567890"}
+			if err := companies.AddBankAccount(ctx, account); err != nil {
+				return err
+			}
+			entry := &model.AuditEntry{CompanyID: company.ID, EntityType: model.AuditEntityBankAccount, EntityID: account.ID, Action: model.AuditActionCreate}
+			if err := audits.Create(ctx, entry); err != nil {
+				return err
+			}
+			return fail
+		})
+	}
+	stored := func() (int, int) {
+		t.Helper()
+
+		accounts, err := companies.GetBankAccounts(ctx, company.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, err := audits.List(ctx, AuditFilter{CompanyID: company.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(accounts), len(entries)
+	}
+
+	// A failed audit rolls the change back
+	errAudit := errors.New("audit log unavailable")
+	if err := change(errAudit); !errors.Is(err, errAudit) {
+		t.Fatalf("transaction error = %v, want %v", err, errAudit)
+	}
+	if accounts, entries := stored(); accounts != 0 || entries != 0 {
+		t.Fatalf("after rollback: %d accounts and %d audit entries, want none", accounts, entries)
+	}
+
+	if err := change(nil); err != nil {
+		t.Fatal(err)
+	}
+	if accounts, entries := stored(); accounts != 1 || entries != 1 {
+		t.Fatalf("after commit: %d accounts and %d audit entries, want 1 of each", accounts, entries)
+	}
+}
@@ -2,7 +2,7 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"time"
 
 	"github.com/notblessy/bikinota-core/model"
 	"golang.org/x/crypto/bcrypt"
@@ -13,6 +13,13 @@ type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	FindByEmail(ctx context.Context, email string) (*model.User, error)
 	FindByID(ctx context.Context, id uint) (*model.User, error)
+	Update(ctx context.Context, user *model.User) error
+	SetPassword(ctx context.Context, userID uint, password string) error
+	ClaimTOTPStep(ctx context.Context, userID uint, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, codes []string) error
+	UseRecoveryCode(ctx context.Context, userID uint, code string) (bool, error)
+	ReserveTwoFactorAttempt(ctx context.Context, userID uint, limit int, window time.Duration) (bool, error)
+	ResetTwoFactorAttempts(ctx context.Context, userID uint) error
 }
 
 type userRepository struct {
@@ -31,33 +38,155 @@ func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 	}
 	user.Password = string(hashedPassword)
 
-	return r.db.WithContext(ctx).Create(user).Error
+	return conflictOr(dbFor(ctx, r.db).Create(user).Error, "email already registered")
 }
 
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	err := dbFor(ctx, r.db).Where("email = ?", email).First(&user).Error
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
+		return nil, notFoundOr(err, "user not found")
 	}
 	return &user, nil
 }
 
 func (r *userRepository) FindByID(ctx context.Context, id uint) (*model.User, error) {
 	var user model.User
-	err := r.db.WithContext(ctx).First(&user, id).Error
+	err := dbFor(ctx, r.db).First(&user, id).Error
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
+		return nil, notFoundOr(err, "user not found")
 	}
 	return &user, nil
 }
 
+// Update saves the user. The second factor attempt counter is only changed
+// by its own methods, so a stale copy of the user can't reset it.
+func (r *userRepository) Update(ctx context.Context, user *model.User) error {
+	return dbFor(ctx, r.db).
+		Omit("two_factor_attempts", "two_factor_attempted_at").
+		Save(user).Error
+}
+
+// SetPassword hashes and stores a new password for the user
+func (r *userRepository) SetPassword(ctx context.Context, userID uint, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	result := dbFor(ctx, r.db).
+		Model(&model.User{}).
+		Where("id = ?", userID).
+		Update("password", string(hashedPassword))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return NotFound("user not found")
+	}
+	return nil
+}
+
+// ClaimTOTPStep records the time step of an accepted TOTP code. It returns false
+// if a code for this step (or a later one) was already used, so each code can
+// only be used once even under concurrent requests.
+func (r *userRepository) ClaimTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := dbFor(ctx, r.db).
+		Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReplaceRecoveryCodes hashes the given codes and replaces any existing ones
+func (r *userRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codes []string) error {
+	recoveryCodes := make([]model.RecoveryCode, len(codes))
+	for i, code := range codes {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		recoveryCodes[i] = model.RecoveryCode{
+			UserID:   userID,
+			CodeHash: string(hashed),
+		}
+	}
+
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(recoveryCodes) == 0 {
+			return nil
+		}
+		return tx.Create(&recoveryCodes).Error
+	})
+}
+
+// UseRecoveryCode marks a matching unused recovery code as used
+func (r *userRepository) UseRecoveryCode(ctx context.Context, userID uint, code string) (bool, error) {
+	var recoveryCodes []model.RecoveryCode
+	err := dbFor(ctx, r.db).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Find(&recoveryCodes).Error
+	if err != nil {
+		return false, err
+	}
+
+	for _, rc := range recoveryCodes {
+		if bcrypt.CompareHashAndPassword([]byte(rc.CodeHash), []byte(code)) != nil {
+			continue
+		}
+
+		// Guard against the same code being redeemed twice concurrently
+		result := dbFor(ctx, r.db).
+			Model(&model.RecoveryCode{}).
+			Where("id = ? AND used_at IS NULL", rc.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return false, result.Error
+		}
+		return result.RowsAffected == 1, nil
+	}
+
+	return false, nil
+}
+
+// ReserveTwoFactorAttempt counts an attempt to enter a second factor before it
+// is checked. It returns false while limit attempts were made within window of
+// the last one, so concurrent guesses can't exceed the limit either.
+func (r *userRepository) ReserveTwoFactorAttempt(ctx context.Context, userID uint, limit int, window time.Duration) (bool, error) {
+	now := time.Now()
+	expired := now.Add(-window)
+
+	result := dbFor(ctx, r.db).
+		Model(&model.User{}).
+		Where("id = ? AND (two_factor_attempts < ? OR two_factor_attempted_at IS NULL OR two_factor_attempted_at < ?)", userID, limit, expired).
+		Updates(map[string]interface{}{
+			"two_factor_attempts": gorm.Expr(
+				"CASE WHEN two_factor_attempted_at IS NULL OR two_factor_attempted_at < ? THEN 1 ELSE two_factor_attempts + 1 END", expired),
+			"two_factor_attempted_at": now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ResetTwoFactorAttempts clears the attempt counter after a successful attempt
+func (r *userRepository) ResetTwoFactorAttempts(ctx context.Context, userID uint) error {
+	return dbFor(ctx, r.db).
+		Model(&model.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"two_factor_attempts":     0,
+			"two_factor_attempted_at": nil,
+		}).Error
+}
+
 func VerifyPassword(hashedPassword, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+)
+
+func TestUserRepositoryDuplicateEmail(t *testing.T) {
+	conn := newTestDB(t)
+	repo := NewUserRepository(conn)
+	ctx := context.Background()
+
+	if err := repo.Create(ctx, &model.User{Email: "a@example.com", Name: "A", Password: "secret123"}); err != nil {
+		t.Fatal(err)
+	}
+	err := repo.Create(ctx, &model.User{Email: "a@example.com", Name: "B", Password: "secret123"})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("err = %v, want a conflict", err)
+	}
+
+	if _, err := repo.FindByEmail(ctx, "missing@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want not found", err)
+	}
+}
+
+func TestUserRepositoryTwoFactor(t *testing.T) {
+	conn := newTestDB(t)
+	repo := NewUserRepository(conn)
+	ctx := context.Background()
+
+	user := &model.User{Email: "a@example.com", Name: "A", Password: "secret123"}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("codes are single use", func(t *testing.T) {
+		for _, tt := range []struct {
+			step int64
+			want bool
+		}{{100, true}, {100, false}, {99, false}, {101, true}} {
+			ok, err := repo.ClaimTOTPStep(ctx, user.ID, tt.step)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.want {
+				t.Errorf("ClaimTOTPStep(%d) = %v, want %v", tt.step, ok, tt.want)
+			}
+		}
+	})
+
+	t.Run("recovery codes are single use", func(t *testing.T) {
+		if err := repo.ReplaceRecoveryCodes(ctx, user.ID, []string{"aaaaa-bbbbb", "ccccc-ddddd"}); err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range []struct {
+			code string
+			want bool
+		}{{"aaaaa-bbbbb", true}, {"aaaaa-bbbbb", false}, {"zzzzz-zzzzz", false}, {"ccccc-ddddd", true}} {
+			ok, err := repo.UseRecoveryCode(ctx, user.ID, tt.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.want {
+				t.Errorf("UseRecoveryCode(%s) = %v, want %v", tt.code, ok, tt.want)
+			}
+		}
+	})
+
+	t.Run("attempts are capped", func(t *testing.T) {
+		reserve := func(window time.Duration) bool {
+			t.Helper()
+			ok, err := repo.ReserveTwoFactorAttempt(ctx, user.ID, 3, window)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return ok
+		}
+
+		for i := 0; i < 3; i++ {
+			if !reserve(time.Hour) {
+				t.Fatalf("attempt %d refused, want allowed", i+1)
+			}
+		}
+
+		// Saving a stale copy of the user must not reset the counter
+		if err := repo.Update(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		if reserve(time.Hour) {
+			t.Fatal("attempt over the limit allowed")
+		}
+
+		// Counting starts over once the window has passed
+		if !reserve(time.Nanosecond) {
+			t.Fatal("attempt after the window refused")
+		}
+
+		if err := repo.ResetTwoFactorAttempts(ctx, user.ID); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			if !reserve(time.Hour) {
+				t.Fatalf("attempt %d after reset refused, want allowed", i+1)
+			}
+		}
+	})
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, endpoint *model.WebhookEndpoint) error
+	FindEndpointByID(ctx context.Context, id uint, companyID uint) (*model.WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, companyID uint) ([]model.WebhookEndpoint, error)
+	FindSubscribedEndpoints(ctx context.Context, companyID uint, event model.WebhookEvent) ([]model.WebhookEndpoint, error)
+	UpdateEndpoint(ctx context.Context, endpoint *model.WebhookEndpoint) error
+	DeleteEndpoint(ctx context.Context, id uint, companyID uint) error
+	RecordEndpointSuccess(ctx context.Context, endpointID uint) error
+	RecordEndpointFailure(ctx context.Context, endpointID uint, disableAfter int) (bool, error)
+	CreateDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	FindDeliveryByID(ctx context.Context, id uint, endpointID uint) (*model.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, endpointID uint, limit int) ([]model.WebhookDelivery, error)
+}
+
+type webhookRepository struct {
+	db      *gorm.DB
+	replica *gorm.DB
+}
+
+// NewWebhookRepository serves delivery logs from replica, everything else
+// from db
+func NewWebhookRepository(db, replica *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db, replica: replica}
+}
+
+func (r *webhookRepository) CreateEndpoint(ctx context.Context, endpoint *model.WebhookEndpoint) error {
+	return dbFor(ctx, r.db).Create(endpoint).Error
+}
+
+func (r *webhookRepository) FindEndpointByID(ctx context.Context, id uint, companyID uint) (*model.WebhookEndpoint, error) {
+	var endpoint model.WebhookEndpoint
+	err := dbFor(ctx, r.db).
+		Where("id = ? AND company_id = ?", id, companyID).
+		First(&endpoint).Error
+	if err != nil {
+		return nil, notFoundOr(err, "webhook endpoint not found")
+	}
+	return &endpoint, nil
+}
+
+func (r *webhookRepository) ListEndpoints(ctx context.Context, companyID uint) ([]model.WebhookEndpoint, error) {
+	var endpoints []model.WebhookEndpoint
+	err := dbFor(ctx, r.db).
+		Where("company_id = ?", companyID).
+		Order("created_at ASC").
+		Find(&endpoints).Error
+	return endpoints, err
+}
+
+// FindSubscribedEndpoints returns the enabled endpoints of a company that
+// subscribe to the event
+func (r *webhookRepository) FindSubscribedEndpoints(ctx context.Context, companyID uint, event model.WebhookEvent) ([]model.WebhookEndpoint, error) {
+	var endpoints []model.WebhookEndpoint
+	err := dbFor(ctx, r.db).
+		Where("company_id = ? AND enabled = ?", companyID, true).
+		Find(&endpoints).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Events are stored as JSON, filtering in Go keeps this portable
+	subscribed := make([]model.WebhookEndpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if endpoint.Subscribes(event) {
+			subscribed = append(subscribed, endpoint)
+		}
+	}
+	return subscribed, nil
+}
+
+func (r *webhookRepository) UpdateEndpoint(ctx context.Context, endpoint *model.WebhookEndpoint) error {
+	return dbFor(ctx, r.db).Save(endpoint).Error
+}
+
+// DeleteEndpoint removes the endpoint and drops its pending deliveries
+func (r *webhookRepository) DeleteEndpoint(ctx context.Context, id uint, companyID uint) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND company_id = ?", id, companyID).Delete(&model.WebhookEndpoint{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return NotFound("webhook endpoint not found")
+		}
+
+		return tx.Model(&model.WebhookDelivery{}).
+			Where("endpoint_id = ? AND status = ?", id, model.WebhookDeliveryPending).
+			Updates(map[string]interface{}{
+				"status":     model.WebhookDeliveryFailed,
+				"last_error": "endpoint deleted",
+			}).Error
+	})
+}
+
+func (r *webhookRepository) RecordEndpointSuccess(ctx context.Context, endpointID uint) error {
+	return dbFor(ctx, r.db).
+		Model(&model.WebhookEndpoint{}).
+		Where("id = ? AND failure_count > 0", endpointID).
+		Update("failure_count", 0).Error
+}
+
+// RecordEndpointFailure increments the consecutive failure count and disables
+// the endpoint once it reaches disableAfter. It reports whether the endpoint
+// was disabled by this call.
+func (r *webhookRepository) RecordEndpointFailure(ctx context.Context, endpointID uint, disableAfter int) (bool, error) {
+	disabled := false
+	err := dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.WebhookEndpoint{}).
+			Where("id = ?", endpointID).
+			Update("failure_count", gorm.Expr("failure_count + 1")).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.WebhookEndpoint{}).
+			Where("id = ? AND enabled = ? AND failure_count >= ?", endpointID, true, disableAfter).
+			Updates(map[string]interface{}{
+				"enabled":     false,
+				"disabled_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		disabled = result.RowsAffected == 1
+		return nil
+	})
+	return disabled, err
+}
+
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return dbFor(ctx, r.db).Create(&deliveries).Error
+}
+
+// ClaimDueDeliveries locks up to limit pending deliveries that are due and
+// pushes their next attempt out by lease, so concurrent workers (or replicas)
+// never send the same delivery twice. A worker that crashes mid-delivery
+// simply lets the lease expire and the delivery is retried.
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+
+		return tx.Model(&model.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	// Load endpoints including soft-deleted ones so the worker can fail them
+	endpointIDs := make([]uint, len(deliveries))
+	for i, d := range deliveries {
+		endpointIDs[i] = d.EndpointID
+	}
+
+	var endpoints []model.WebhookEndpoint
+	err = dbFor(ctx, r.db).Unscoped().Where("id IN ?", endpointIDs).Find(&endpoints).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]*model.WebhookEndpoint, len(endpoints))
+	for i := range endpoints {
+		byID[endpoints[i].ID] = &endpoints[i]
+	}
+	for i := range deliveries {
+		deliveries[i].Endpoint = byID[deliveries[i].EndpointID]
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return dbFor(ctx, r.db).Omit("Endpoint").Save(delivery).Error
+}
+
+func (r *webhookRepository) FindDeliveryByID(ctx context.Context, id uint, endpointID uint) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := dbFor(ctx, r.db).
+		Where("id = ? AND endpoint_id = ?", id, endpointID).
+		First(&delivery).Error
+	if err != nil {
+		return nil, notFoundOr(err, "webhook delivery not found")
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries returns the most recent deliveries of an endpoint
+func (r *webhookRepository) ListDeliveries(ctx context.Context, endpointID uint, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := dbFor(ctx, r.replica).
+		Where("endpoint_id = ?", endpointID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
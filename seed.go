@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// runSeed creates a demo user owning a company with a bank account and a few
+// invoices. It does nothing when the demo user already exists.
+func runSeed(args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	email := fs.String("email", "demo@bikinota.com", "email address of the demo user")
+	password := fs.String("password", "demo1234", "password of the demo user")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	a := newApp(loadConfig(nil))
+
+	_, err := a.userRepo.FindByEmail(ctx, *email)
+	if err == nil {
+		fmt.Printf("demo user <%s> already exists, nothing to seed\n", *email)
+		return
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		logrus.Fatalf("Failed to find demo user: %v", err)
+	}
+
+	user := &model.User{Email: *email, Name: "Demo User", Password: *password}
+	if err := a.userRepo.Create(ctx, user); err != nil {
+		logrus.Fatalf("Failed to create demo user: %v", err)
+	}
+
+	company := &model.Company{
+		UserID:  user.ID,
+		Name:    "Studio Kopi Senja",
+		Address: "Jl. Kemang Raya No. 12",
+		City:    "Jakarta Selatan",
+		State:   "DKI Jakarta",
+		ZipCode: "12730",
+		Country: "Indonesia",
+		Email:   "halo@kopisenja.id",
+		Phone:   "+62 21 7190 1234",
+		Website: "https://kopisenja.id",
+		TaxID:   "01.234.567.8-012.000",
+	}
+	if err := a.companyRepo.Create(ctx, company); err != nil {
+		logrus.Fatalf("Failed to create demo company: %v", err)
+	}
+
+	bankCode := "014"
+	swiftCode := "CENAIDJA"
+	bankAccount := &model.BankAccount{
+		CompanyID:     company.ID,
+		Country:       "ID",
+		BankCode:      &bankCode,
+		BankName:      "BCA",
+		AccountName:   "PT Studio Kopi Senja",
+		AccountNumber: "1234567890",
+		SwiftCode:     &swiftCode,
+	}
+	if err := a.companyRepo.AddBankAccount(ctx, bankAccount); err != nil {
+		logrus.Fatalf("Failed to create demo bank account: %v", err)
+	}
+
+	plan := &model.Plan{CompanyID: company.ID, UserID: user.ID, PlanType: model.PlanFree}
+	if err := a.planRepo.Create(ctx, plan); err != nil {
+		logrus.Fatalf("Failed to create demo plan: %v", err)
+	}
+
+	dueDate := time.Now().AddDate(0, 0, 14)
+	invoices := []*model.Invoice{
+		{
+			CustomerName:  "PT Maju Bersama",
+			CustomerEmail: "finance@majubersama.co.id",
+			Status:        "paid",
+			TaxRate:       11,
+			Items: []model.InvoiceItem{
+				{Name: "Logo design", Quantity: 1, Price: 350000000},
+				{Name: "Brand guideline", Description: "24 pages", Quantity: 1, Price: 150000000},
+			},
+		},
+		{
+			CustomerName:  "CV Cahaya Abadi",
+			CustomerEmail: "admin@cahayaabadi.com",
+			DueDate:       &dueDate,
+			Status:        "sent",
+			TaxRate:       11,
+			Items: []model.InvoiceItem{
+				{Name: "Landing page", Quantity: 1, Price: 500000000},
+				{Name: "Monthly maintenance", Quantity: 3, Price: 75000000},
+			},
+			Adjustments: []model.InvoiceAdjustment{
+				{Description: "Loyalty discount", Type: "deduction", Amount: 50000000},
+			},
+		},
+		{
+			CustomerName:  "Toko Buku Nusantara",
+			CustomerEmail: "owner@bukunusantara.id",
+			DueDate:       &dueDate,
+			Status:        "draft",
+			Items: []model.InvoiceItem{
+				{Name: "Product photography", Description: "40 photos", Quantity: 40, Price: 5000000},
+			},
+			Adjustments: []model.InvoiceAdjustment{
+				{Description: "Travel", Type: "addition", Amount: 25000000},
+			},
+		},
+	}
+
+	for _, invoice := range invoices {
+		invoice.UserID = user.ID
+		invoice.CompanyID = company.ID
+		invoice.BankAccountID = &bankAccount.ID
+		invoice.BankDetails = bankAccount.Snapshot()
+		invoice.CalculateTotals()
+
+		if err := a.invoiceRepo.Create(ctx, invoice); err != nil {
+			logrus.Fatalf("Failed to create demo invoice: %v", err)
+		}
+	}
+
+	fmt.Printf("seeded demo user <%s> with password %q, company %d and %d invoices\n", user.Email, *password, company.ID, len(invoices))
+}
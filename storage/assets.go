@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+	"github.com/notblessy/bikinota-core/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// assetGracePeriod keeps fresh uploads out of the sweep until the request
+	// that created them had the chance to reference them
+	assetGracePeriod = time.Hour
+	sweepBatchSize   = 100
+)
+
+// AssetStore saves files to a BlobStore and records them as assets so they
+// can be referenced by ID and garbage collected once unused
+type AssetStore struct {
+	store     BlobStore
+	assetRepo repository.AssetRepository
+}
+
+func NewAssetStore(store BlobStore, assetRepo repository.AssetRepository) *AssetStore {
+	return &AssetStore{
+		store:     store,
+		assetRepo: assetRepo,
+	}
+}
+
+// Save stores data under a new random key below prefix and records the asset
+func (s *AssetStore) Save(ctx context.Context, companyID, userID uint, prefix string, data []byte, contentType string) (*model.Asset, error) {
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return nil, fmt.Errorf("failed to generate asset key: %w", err)
+	}
+	key := fmt.Sprintf("%s/%d/%s%s", prefix, companyID, hex.EncodeToString(name), extension(contentType))
+
+	url, err := s.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := sha256.Sum256(data)
+	asset := &model.Asset{
+		CompanyID:   companyID,
+		UserID:      userID,
+		Key:         key,
+		URL:         url,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Checksum:    hex.EncodeToString(checksum[:]),
+	}
+
+	if err := s.assetRepo.Create(ctx, asset); err != nil {
+		// Nothing references the blob yet, remove it right away
+		if delErr := s.store.Delete(ctx, key); delErr != nil {
+			logrus.Warnf("Failed to delete unrecorded blob %s: %v", key, delErr)
+		}
+		return nil, err
+	}
+
+	return asset, nil
+}
+
+// Discard deletes the blobs of assets whose rows were rolled back with the
+// transaction that saved them. Sweep only finds assets that have a row.
+func (s *AssetStore) Discard(ctx context.Context, assets []*model.Asset) {
+	for _, asset := range assets {
+		if err := s.store.Delete(ctx, asset.Key); err != nil {
+			logrus.Warnf("Failed to delete discarded blob %s: %v", asset.Key, err)
+		}
+	}
+}
+
+// Sweep deletes orphaned assets from the blob store and the database and
+// returns how many were removed
+func (s *AssetStore) Sweep(ctx context.Context) (int, error) {
+	assets, err := s.assetRepo.FindOrphans(ctx, time.Now().Add(-assetGracePeriod), sweepBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, asset := range assets {
+		// Delete the blob first so a failure leaves the row for the next sweep
+		if err := s.store.Delete(ctx, asset.Key); err != nil {
+			logrus.Warnf("Failed to delete orphaned asset %d: %v", asset.ID, err)
+			continue
+		}
+		if err := s.assetRepo.Delete(ctx, asset.ID); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// RunSweeper sweeps orphaned assets every interval until ctx is cancelled
+func (s *AssetStore) RunSweeper(ctx context.Context, interval time.Duration) {
+	logrus.Info("Asset sweeper started")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Asset sweeper stopped")
+			return
+		case <-ticker.C:
+		}
+
+		sweepCtx, span := tracing.StartJob(ctx, "job.asset_sweep")
+		removed, err := s.Sweep(sweepCtx)
+		tracing.End(span, err)
+		if err != nil {
+			logrus.Errorf("Error sweeping orphaned assets: %v", err)
+			continue
+		}
+		if removed > 0 {
+			logrus.Infof("Removed %d orphaned assets", removed)
+		}
+	}
+}
+
+func extension(contentType string) string {
+	switch contentType {
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	}
+	return ""
+}
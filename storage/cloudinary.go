@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/sirupsen/logrus"
+)
+
+// CloudinaryStore keeps objects as Cloudinary images. Keys are used as public
+// IDs below folder. Logos uploaded before storage became pluggable are keyed
+// by the ID of the uploading user, e.g. "company-logos/company-logo-7" with
+// the default folder; KeyForURL reads the key from their URL.
+type CloudinaryStore struct {
+	cld    *cloudinary.Cloudinary
+	folder string
+	client *http.Client
+}
+
+func NewCloudinaryStore(cloudinaryURL, folder string) (*CloudinaryStore, error) {
+	if cloudinaryURL == "" {
+		return nil, fmt.Errorf("CLOUDINARY_URL environment variable is not set")
+	}
+
+	cld, err := cloudinary.NewFromURL(cloudinaryURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize cloudinary: %w", err)
+	}
+	cld.Config.URL.Secure = true
+
+	return &CloudinaryStore{
+		cld:    cld,
+		folder: folder,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (s *CloudinaryStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) (string, error) {
+	publicID, err := s.publicID(key)
+	if err != nil {
+		return "", err
+	}
+
+	overwrite := true
+	uploadResult, err := s.cld.Upload.Upload(ctx, body, uploader.UploadParams{
+		PublicID:       publicID,
+		AllowedFormats: []string{"jpg", "jpeg", "png", "gif", "webp"},
+		ResourceType:   "image",
+		Overwrite:      &overwrite,
+	})
+	if err != nil {
+		logrus.Errorf("Cloudinary upload error: %v", err)
+		return "", fmt.Errorf("failed to upload image: %w", err)
+	}
+	if uploadResult.Error.Message != "" {
+		return "", fmt.Errorf("failed to upload image: %s", uploadResult.Error.Message)
+	}
+
+	return uploadResult.SecureURL, nil
+}
+
+func (s *CloudinaryStore) Get(ctx context.Context, key string) (*Object, error) {
+	url, err := s.SignedURL(ctx, key, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download image: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download image: status %d", resp.StatusCode)
+	}
+
+	return &Object{
+		Body:        resp.Body,
+		ContentType: resp.Header.Get("Content-Type"),
+		Size:        resp.ContentLength,
+	}, nil
+}
+
+func (s *CloudinaryStore) Delete(ctx context.Context, key string) error {
+	publicID, err := s.publicID(key)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.cld.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID:     publicID,
+		ResourceType: "image",
+	})
+	if err != nil {
+		logrus.Errorf("Cloudinary delete error: %v", err)
+		return fmt.Errorf("failed to delete image: %w", err)
+	}
+
+	return nil
+}
+
+// SignedURL returns the delivery URL. Cloudinary uploads are public, so the
+// URL does not expire.
+func (s *CloudinaryStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	publicID, err := s.publicID(key)
+	if err != nil {
+		return "", err
+	}
+
+	image, err := s.cld.Image(publicID)
+	if err != nil {
+		return "", err
+	}
+	return image.String()
+}
+
+// KeyForURL reads the public ID from a delivery URL such as
+// https://res.cloudinary.com/<cloud>/image/upload/v1700000000/bikinota/company-logos/company-logo-7.png
+// and returns it without the folder
+func (s *CloudinaryStore) KeyForURL(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	rest, ok := strings.CutPrefix(u.Path, "/"+s.cld.Config.Cloud.CloudName+"/image/upload/")
+	if !ok {
+		return "", false
+	}
+
+	// The version segment is optional, Put uploads without transformations
+	segments := strings.Split(rest, "/")
+	if len(segments) > 1 && isVersion(segments[0]) {
+		segments = segments[1:]
+	}
+	publicID := strings.Join(segments, "/")
+	publicID = strings.TrimSuffix(publicID, path.Ext(publicID))
+
+	if s.folder != "" {
+		if publicID, ok = strings.CutPrefix(publicID, s.folder+"/"); !ok {
+			return "", false
+		}
+	}
+	key, err := cleanKey(publicID)
+	return key, err == nil
+}
+
+// isVersion tells the version segment of a delivery URL, e.g. v1700000000
+func isVersion(segment string) bool {
+	digits, ok := strings.CutPrefix(segment, "v")
+	if !ok || digits == "" {
+		return false
+	}
+	_, err := strconv.ParseUint(digits, 10, 64)
+	return err == nil
+}
+
+func (s *CloudinaryStore) publicID(key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if s.folder == "" {
+		return key, nil
+	}
+	return s.folder + "/" + key, nil
+}
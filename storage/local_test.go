@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir(), "http://example.test/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url, err := store.Put(ctx, "company-logos/logo.png", strings.NewReader("png-bytes"), 9, "image/png")
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if url != "http://example.test/files/company-logos/logo.png" {
+		t.Fatalf("unexpected url %q", url)
+	}
+
+	obj, err := store.Get(ctx, "company-logos/logo.png")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, _ := io.ReadAll(obj.Body)
+	obj.Body.Close()
+	if string(body) != "png-bytes" || obj.ContentType != "image/png" || obj.Size != 9 {
+		t.Fatalf("got %q (%s, %d bytes)", body, obj.ContentType, obj.Size)
+	}
+
+	if err := store.Delete(ctx, "company-logos/logo.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, "company-logos/logo.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after delete: %v", err)
+	}
+	if err := store.Delete(ctx, "company-logos/logo.png"); err != nil {
+		t.Fatalf("deleting a missing key: %v", err)
+	}
+}
+
+func TestCleanKeyRejectsTraversal(t *testing.T) {
+	for _, key := range []string{"", "../etc/passwd", "/abs", "a/../../b", "a//b", "a/"} {
+		if _, err := cleanKey(key); err == nil {
+			t.Errorf("cleanKey(%q) accepted", key)
+		}
+	}
+}
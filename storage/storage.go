@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/notblessy/bikinota-core/config"
+)
+
+// Supported values of STORAGE_DRIVER
+const (
+	DriverCloudinary = "cloudinary"
+	DriverLocal      = "local"
+	DriverS3         = "s3"
+)
+
+// ErrNotFound is returned when no object exists under a key
+var ErrNotFound = errors.New("object not found")
+
+// Object is a stored blob returned by Get. Callers must close Body.
+type Object struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+}
+
+// BlobStore stores binary objects such as company logos under slash
+// separated keys like "company-logos/company-logo-1"
+type BlobStore interface {
+	// Put stores the object, replacing any existing one, and returns its URL
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) (string, error)
+	Get(ctx context.Context, key string) (*Object, error)
+	// Delete removes the object; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL granting read access to the object for expiry
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// URLKeyer is implemented by stores that can tell the key of an object from
+// the URL Put returned for it
+type URLKeyer interface {
+	// KeyForURL reports false for URLs the store did not hand out
+	KeyForURL(rawURL string) (string, bool)
+}
+
+// KeyForURL returns the key of the object store serves at rawURL, e.g. to
+// adopt files whose keys were never recorded
+func KeyForURL(store BlobStore, rawURL string) (string, bool) {
+	keyer, ok := Unwrap(store).(URLKeyer)
+	if !ok {
+		return "", false
+	}
+	return keyer.KeyForURL(rawURL)
+}
+
+// New creates the BlobStore selected by cfg.Driver. When it is empty,
+// Cloudinary is used if a Cloudinary URL is configured and the local
+// filesystem otherwise, so uploads work without any external service. Calls
+// to the returned store are traced, Unwrap gives the driver itself.
+func New(cfg config.StorageConfig) (BlobStore, error) {
+	driver := cfg.Driver
+	if driver == "" {
+		driver = DriverLocal
+		if cfg.CloudinaryURL != "" {
+			driver = DriverCloudinary
+		}
+	}
+
+	var (
+		store BlobStore
+		err   error
+	)
+	switch driver {
+	case DriverCloudinary:
+		store, err = NewCloudinaryStore(cfg.CloudinaryURL, cfg.CloudinaryFolder)
+	case DriverLocal:
+		store, err = NewLocalStore(cfg.LocalDir, cfg.PublicURL)
+	case DriverS3:
+		store, err = NewS3Store(S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretKey,
+			UseSSL:          cfg.S3UseSSL,
+			PublicURL:       cfg.S3PublicURL,
+		})
+	default:
+		err = fmt.Errorf("unknown storage driver %q", driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &tracedStore{store: store, driver: driver}, nil
+}
+
+// cleanKey rejects keys that could escape the store's namespace
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if key == "" || cleaned != key {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return cleaned, nil
+}
+
+// keyAfterPrefix returns the key following prefix in rawURL
+func keyAfterPrefix(rawURL, prefix string) (string, bool) {
+	rest, ok := strings.CutPrefix(rawURL, prefix)
+	if !ok {
+		return "", false
+	}
+	key, err := cleanKey(rest)
+	return key, err == nil
+}
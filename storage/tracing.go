@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/notblessy/bikinota-core/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedStore wraps each call to a blob store in a span
+type tracedStore struct {
+	store  BlobStore
+	driver string
+}
+
+func (s *tracedStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) (string, error) {
+	ctx, span := s.start(ctx, "put", key)
+	url, err := s.store.Put(ctx, key, body, size, contentType)
+	end(span, err)
+	return url, err
+}
+
+func (s *tracedStore) Get(ctx context.Context, key string) (*Object, error) {
+	ctx, span := s.start(ctx, "get", key)
+	obj, err := s.store.Get(ctx, key)
+	end(span, err)
+	return obj, err
+}
+
+func (s *tracedStore) Delete(ctx context.Context, key string) error {
+	ctx, span := s.start(ctx, "delete", key)
+	err := s.store.Delete(ctx, key)
+	end(span, err)
+	return err
+}
+
+func (s *tracedStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	ctx, span := s.start(ctx, "signed_url", key)
+	url, err := s.store.SignedURL(ctx, key, expiry)
+	end(span, err)
+	return url, err
+}
+
+// Unwrap returns the instrumented store
+func (s *tracedStore) Unwrap() BlobStore {
+	return s.store
+}
+
+func (s *tracedStore) start(ctx context.Context, operation, key string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "storage."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("storage.driver", s.driver),
+			attribute.String("storage.key", key),
+		),
+	)
+}
+
+// end finishes the span, a missing object is not a failure of the store
+func end(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Unwrap returns the driver behind instrumentation added by New, e.g. to
+// check for a *LocalStore
+func Unwrap(store BlobStore) BlobStore {
+	for {
+		wrapper, ok := store.(interface{ Unwrap() BlobStore })
+		if !ok {
+			return store
+		}
+		store = wrapper.Unwrap()
+	}
+}
@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddlewareContinuesTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/api/invoice/:id", func(c echo.Context) error {
+		if !trace.SpanContextFromContext(c.Request().Context()).IsValid() {
+			t.Error("handler context carries no span")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/api/invoice/42", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("%d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /api/invoice/:id" {
+		t.Errorf("span name = %q", span.Name())
+	}
+	if span.SpanContext().TraceID().String() != traceID {
+		t.Errorf("trace ID = %s, want the caller's %s", span.SpanContext().TraceID(), traceID)
+	}
+	if span.SpanKind() != trace.SpanKindServer || span.Status().Code != codes.Error {
+		t.Errorf("kind %v, status %v", span.SpanKind(), span.Status())
+	}
+}
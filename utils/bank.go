@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FieldError reports a field breaking a validation rule. Param holds the
+// rule's parameter, e.g. the expected length, for building the message.
+type FieldError struct {
+	Field string
+	Rule  string
+	Param string
+}
+
+// BankAccountDetails are the bank account fields checked by ValidateBankAccount.
+// Country is an ISO 3166-1 alpha-2 code, empty skips country specific rules.
+type BankAccountDetails struct {
+	Country       string
+	BankCode      string
+	AccountNumber string
+	SwiftCode     string
+	RoutingNumber string
+}
+
+// IndonesianBank describes a bank by its three digit clearing code
+type IndonesianBank struct {
+	Name           string
+	AccountLengths []int
+}
+
+// IndonesianBanks maps bank codes to the account number lengths each bank issues
+var IndonesianBanks = map[string]IndonesianBank{
+	"002": {Name: "BRI", AccountLengths: []int{15}},
+	"008": {Name: "Bank Mandiri", AccountLengths: []int{13}},
+	"009": {Name: "BNI", AccountLengths: []int{10}},
+	"011": {Name: "Bank Danamon", AccountLengths: []int{10}},
+	"013": {Name: "Bank Permata", AccountLengths: []int{10}},
+	"014": {Name: "BCA", AccountLengths: []int{10}},
+	"016": {Name: "Maybank Indonesia", AccountLengths: []int{10}},
+	"019": {Name: "Panin Bank", AccountLengths: []int{10}},
+	"022": {Name: "CIMB Niaga", AccountLengths: []int{12, 13, 14}},
+	"028": {Name: "OCBC NISP", AccountLengths: []int{12}},
+	"147": {Name: "Bank Muamalat", AccountLengths: []int{10}},
+	"200": {Name: "BTN", AccountLengths: []int{16}},
+	"213": {Name: "Bank BTPN", AccountLengths: []int{11}},
+	"426": {Name: "Bank Mega", AccountLengths: []int{15}},
+	"451": {Name: "Bank Syariah Indonesia", AccountLengths: []int{10}},
+	"535": {Name: "SeaBank", AccountLengths: []int{12}},
+	"542": {Name: "Bank Jago", AccountLengths: []int{12}},
+}
+
+// ibanLengths lists the IBAN length of countries using IBANs for domestic accounts
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AT": 20, "BE": 16, "BG": 22, "BH": 22, "CH": 21, "CY": 28,
+	"CZ": 24, "DE": 22, "DK": 18, "EE": 20, "ES": 24, "FI": 18, "FO": 18, "FR": 27,
+	"GB": 22, "GI": 23, "GL": 18, "GR": 27, "HR": 21, "HU": 28, "IE": 22, "IL": 23,
+	"IS": 26, "IT": 27, "JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LI": 21, "LT": 20,
+	"LU": 20, "LV": 21, "MC": 27, "MT": 31, "NL": 18, "NO": 15, "PK": 24, "PL": 28,
+	"PS": 29, "PT": 25, "QA": 29, "RO": 24, "SA": 24, "SE": 24, "SI": 19, "SK": 24,
+	"SM": 27, "TN": 24, "TR": 26, "UA": 29, "VG": 24,
+}
+
+var (
+	swiftPattern  = regexp.MustCompile(`^[A-Z]{4}[A-Z]{2}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+	ibanPattern   = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]+$`)
+	digitsPattern = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// NormalizeAccountNumber removes the spaces and dashes people use to group
+// account numbers and upper-cases letters, as found in IBANs
+func NormalizeAccountNumber(value string) string {
+	value = strings.NewReplacer(" ", "", "-", "", ".", "").Replace(value)
+	return strings.ToUpper(value)
+}
+
+// UsesIBAN reports whether accounts in the country are identified by IBAN
+func UsesIBAN(country string) bool {
+	_, ok := ibanLengths[country]
+	return ok
+}
+
+// ValidateIBAN checks the structure, country length and mod-97 checksum of a
+// normalized IBAN
+func ValidateIBAN(iban string) error {
+	if len(iban) < 15 || len(iban) > 34 || !ibanPattern.MatchString(iban) {
+		return fmt.Errorf("must be a valid IBAN")
+	}
+	if length, ok := ibanLengths[iban[:2]]; ok && len(iban) != length {
+		return fmt.Errorf("%s IBANs are %d characters long", iban[:2], length)
+	}
+
+	// Move the first four characters to the end and turn letters into numbers, A=10 ... Z=35
+	var digits strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		if r >= 'A' && r <= 'Z' {
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	if new(big.Int).Mod(n, big.NewInt(97)).Int64() != 1 {
+		return fmt.Errorf("IBAN checksum is invalid")
+	}
+	return nil
+}
+
+// ValidateSWIFT checks the structure of a SWIFT/BIC code: bank, country,
+// location and an optional branch
+func ValidateSWIFT(code string) error {
+	if !swiftPattern.MatchString(code) {
+		return fmt.Errorf("must be an 8 or 11 character SWIFT/BIC code")
+	}
+	return nil
+}
+
+// ValidateABARouting checks the length and checksum of a US ABA routing number
+func ValidateABARouting(routing string) error {
+	if len(routing) != 9 || !digitsPattern.MatchString(routing) {
+		return fmt.Errorf("must be a 9 digit ABA routing number")
+	}
+
+	weights := []int{3, 7, 1}
+	sum := 0
+	for i, r := range routing {
+		sum += int(r-'0') * weights[i%3]
+	}
+	if sum%10 != 0 {
+		return fmt.Errorf("ABA routing number checksum is invalid")
+	}
+	return nil
+}
+
+// ValidateBankAccount applies the rules of the account's country and returns
+// one error per broken rule. Values are expected to be normalized.
+func ValidateBankAccount(d BankAccountDetails) []FieldError {
+	var errs []FieldError
+	add := func(field, rule, param string) {
+		errs = append(errs, FieldError{Field: field, Rule: rule, Param: param})
+	}
+
+	if d.SwiftCode != "" {
+		if ValidateSWIFT(d.SwiftCode) != nil {
+			add("swift_code", "swift", "")
+		} else if d.Country != "" && d.SwiftCode[4:6] != d.Country {
+			add("swift_code", "swift_country", d.Country)
+		}
+	}
+
+	switch {
+	case d.Country == "ID":
+		if !digitsPattern.MatchString(d.AccountNumber) {
+			add("account_number", "numeric", "")
+			break
+		}
+		if d.BankCode == "" {
+			break
+		}
+		bank, ok := IndonesianBanks[d.BankCode]
+		if !ok {
+			add("bank_code", "bank_code", "")
+			break
+		}
+		if !containsInt(bank.AccountLengths, len(d.AccountNumber)) {
+			add("account_number", "account_length", joinInts(bank.AccountLengths))
+		}
+
+	case d.Country == "US":
+		if d.RoutingNumber == "" {
+			add("routing_number", "required", "")
+		} else if ValidateABARouting(d.RoutingNumber) != nil {
+			add("routing_number", "aba_routing", "")
+		}
+		if !digitsPattern.MatchString(d.AccountNumber) || len(d.AccountNumber) < 4 || len(d.AccountNumber) > 17 {
+			add("account_number", "account_length", "4-17")
+		}
+
+	case UsesIBAN(d.Country):
+		if ValidateIBAN(d.AccountNumber) != nil {
+			add("account_number", "iban", "")
+		} else if d.AccountNumber[:2] != d.Country {
+			add("account_number", "iban_country", d.Country)
+		}
+	}
+
+	return errs
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// joinInts lists lengths as e.g. 12/13/14
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,64 @@
+package utils
+
+import "testing"
+
+func TestValidateIBAN(t *testing.T) {
+	valid := []string{"GB82WEST12345698765432", "DE89370400440532013000", "NL91ABNA0417164300"}
+	for _, iban := range valid {
+		if err := ValidateIBAN(iban); err != nil {
+			t.Errorf("ValidateIBAN(%s): %v", iban, err)
+		}
+	}
+
+	invalid := []string{"GB82WEST12345698765431", "DE8937040044053201300", "XX00", "gb82west12345698765432"}
+	for _, iban := range invalid {
+		if ValidateIBAN(iban) == nil {
+			t.Errorf("ValidateIBAN(%s) should fail", iban)
+		}
+	}
+}
+
+func TestValidateABARouting(t *testing.T) {
+	if err := ValidateABARouting("021000021"); err != nil {
+		t.Errorf("valid routing number rejected: %v", err)
+	}
+	for _, routing := range []string{"021000022", "12345678", "02100002A"} {
+		if ValidateABARouting(routing) == nil {
+			t.Errorf("ValidateABARouting(%s) should fail", routing)
+		}
+	}
+}
+
+func TestValidateBankAccount(t *testing.T) {
+	tests := []struct {
+		name   string
+		d      BankAccountDetails
+		fields []string
+	}{
+		{"no country", BankAccountDetails{AccountNumber: "anything"}, nil},
+		{"bca", BankAccountDetails{Country: "ID", BankCode: "014", AccountNumber: "1234567890", SwiftCode: "CENAIDJA"}, nil},
+		{"bca wrong length", BankAccountDetails{Country: "ID", BankCode: "014", AccountNumber: "123456789"}, []string{"account_number"}},
+		{"unknown bank code", BankAccountDetails{Country: "ID", BankCode: "999", AccountNumber: "123"}, []string{"bank_code"}},
+		{"swift from other country", BankAccountDetails{Country: "ID", AccountNumber: "123", SwiftCode: "DEUTDEFF"}, []string{"swift_code"}},
+		{"swift and iban from other country", BankAccountDetails{Country: "DE", AccountNumber: "GB82WEST12345698765432", SwiftCode: "CENAIDJA"}, []string{"swift_code", "account_number"}},
+		{"malformed swift", BankAccountDetails{SwiftCode: "DEUT"}, []string{"swift_code"}},
+		{"us", BankAccountDetails{Country: "US", AccountNumber: "000123456789", RoutingNumber: "021000021"}, nil},
+		{"us missing routing", BankAccountDetails{Country: "US", AccountNumber: "12"}, []string{"routing_number", "account_number"}},
+		{"germany", BankAccountDetails{Country: "DE", AccountNumber: "DE89370400440532013000"}, nil},
+		{"germany with uk iban", BankAccountDetails{Country: "DE", AccountNumber: "GB82WEST12345698765432"}, []string{"account_number"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateBankAccount(tt.d)
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("got %v, want errors for %v", errs, tt.fields)
+			}
+			for i, field := range tt.fields {
+				if errs[i].Field != field {
+					t.Errorf("error %d is for %s, want %s", i, errs[i].Field, field)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// Logo processing limits. Logos are printed in invoice headers, so anything
+// larger than LogoMaxWidth x LogoMaxHeight is scaled down.
+const (
+	LogoMaxWidth       = 800
+	LogoMaxHeight      = 400
+	LogoThumbnailSize  = 128
+	maxImageDimension  = 8000
+	maxImagePixels     = 40_000_000 // Rejects decompression bombs before decoding
+	logoJPEGQuality    = 90
+	trimColorTolerance = 12
+)
+
+var (
+	ErrUnsupportedImage = errors.New("file must be a png, jpeg, gif or webp image")
+	ErrMalformedImage   = errors.New("image could not be decoded")
+	ErrImageTooLarge    = errors.New("image dimensions are too large")
+)
+
+// EncodedImage is a re-encoded image ready to be stored
+type EncodedImage struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// ProcessedLogo holds the normalized logo and its thumbnail
+type ProcessedLogo struct {
+	Logo      EncodedImage
+	Thumbnail EncodedImage
+}
+
+// ProcessLogo validates an uploaded logo by its content rather than its name
+// or declared type, trims uniform borders, scales it down to invoice-friendly
+// dimensions and re-encodes it. Re-encoding drops all metadata such as EXIF;
+// the EXIF orientation of JPEG photos is applied first. Images with
+// transparency become PNG, everything else JPEG.
+func ProcessLogo(data []byte) (*ProcessedLogo, error) {
+	format, err := sniffImageFormat(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the declared dimensions before allocating the full image
+	cfg, err := decodeImageConfig(format, data)
+	if err != nil {
+		return nil, ErrMalformedImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrMalformedImage
+	}
+	if cfg.Width > maxImageDimension || cfg.Height > maxImageDimension || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, err := decodeImage(format, data)
+	if err != nil {
+		return nil, ErrMalformedImage
+	}
+
+	if format == "jpeg" {
+		src = applyOrientation(src, jpegOrientation(data))
+	}
+
+	opaque := isOpaque(src)
+	src = trimBorders(src)
+
+	logo, err := encodeImage(fit(src, LogoMaxWidth, LogoMaxHeight), opaque)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnail, err := encodeImage(fit(src, LogoThumbnailSize, LogoThumbnailSize), opaque)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProcessedLogo{Logo: *logo, Thumbnail: *thumbnail}, nil
+}
+
+// sniffImageFormat detects the format from the file's magic bytes
+func sniffImageFormat(data []byte) (string, error) {
+	switch http.DetectContentType(data) {
+	case "image/png":
+		return "png", nil
+	case "image/jpeg":
+		return "jpeg", nil
+	case "image/gif":
+		return "gif", nil
+	case "image/webp":
+		return "webp", nil
+	}
+	return "", ErrUnsupportedImage
+}
+
+func decodeImageConfig(format string, data []byte) (image.Config, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case "png":
+		return png.DecodeConfig(r)
+	case "jpeg":
+		return jpeg.DecodeConfig(r)
+	case "gif":
+		return gif.DecodeConfig(r)
+	default:
+		return webp.DecodeConfig(r)
+	}
+}
+
+func decodeImage(format string, data []byte) (image.Image, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case "png":
+		return png.Decode(r)
+	case "jpeg":
+		return jpeg.Decode(r)
+	case "gif":
+		// Only the first frame of animated GIFs is kept
+		return gif.Decode(r)
+	default:
+		return webp.Decode(r)
+	}
+}
+
+func encodeImage(img image.Image, opaque bool) (*EncodedImage, error) {
+	var buf bytes.Buffer
+	contentType := "image/png"
+
+	if opaque {
+		contentType = "image/jpeg"
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: logoJPEGQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode image: %w", err)
+		}
+	} else {
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		if err := encoder.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("failed to encode image: %w", err)
+		}
+	}
+
+	bounds := img.Bounds()
+	return &EncodedImage{
+		Data:        buf.Bytes(),
+		ContentType: contentType,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+	}, nil
+}
+
+// fit scales img down, keeping its aspect ratio, so it fits in maxW x maxH.
+// Smaller images are returned as they are.
+func fit(img image.Image, maxW, maxH int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= maxW && h <= maxH {
+		return toRGBA(img)
+	}
+
+	scale := min(float64(maxW)/float64(w), float64(maxH)/float64(h))
+	dstW := max(1, int(float64(w)*scale+0.5))
+	dstH := max(1, int(float64(h)*scale+0.5))
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, xdraw.Src, nil)
+	return dst
+}
+
+// trimBorders crops away margins that have the same color as the top-left
+// pixel (typically white or fully transparent padding around a logo)
+func trimBorders(img image.Image) image.Image {
+	bounds := img.Bounds()
+	background := img.At(bounds.Min.X, bounds.Min.Y)
+
+	crop := image.Rectangle{Min: bounds.Max, Max: bounds.Min}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if !similarColor(img.At(x, y), background) {
+				crop.Min.X = min(crop.Min.X, x)
+				crop.Min.Y = min(crop.Min.Y, y)
+				crop.Max.X = max(crop.Max.X, x+1)
+				crop.Max.Y = max(crop.Max.Y, y+1)
+			}
+		}
+	}
+
+	// A blank image has nothing to trim to
+	if crop.Empty() || crop == bounds {
+		return img
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, crop.Dx(), crop.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, crop.Min, draw.Src)
+	return dst
+}
+
+func similarColor(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	// Fully transparent pixels match regardless of their color channels
+	if aa == 0 && ba == 0 {
+		return true
+	}
+	tolerance := uint32(trimColorTolerance) << 8
+	return absDiff(ar, br) <= tolerance && absDiff(ag, bg) <= tolerance &&
+		absDiff(ab, bb) <= tolerance && absDiff(aa, ba) <= tolerance
+}
+
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
+
+// jpegOrientation reads the EXIF orientation tag (1-8) of a JPEG, returning 1
+// when it is missing or unreadable
+func jpegOrientation(data []byte) int {
+	// Walk the JPEG segments looking for the APP1 Exif block
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[offset:]))
+	for n := 0; n < entries; n++ {
+		entry := offset + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+	return 1
+}
+
+// applyOrientation rotates and flips img so it displays upright once the EXIF
+// orientation tag has been dropped
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Mirrored horizontally
+				dx, dy = w-1-x, y
+			case 3: // Rotated 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // Mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // Mirrored along the top-left diagonal
+				dx, dy = y, x
+			case 6: // Rotated 90 clockwise
+				dx, dy = h-1-y, x
+			case 7: // Mirrored along the top-right diagonal
+				dx, dy = h-1-y, w-1-x
+			case 8: // Rotated 90 counter-clockwise
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// DecodeImageData decodes a base64 image, either plain or as a data URI such
+// as "data:image/png;base64,iVBOR..."
+func DecodeImageData(value string) ([]byte, error) {
+	if strings.HasPrefix(value, "data:") {
+		header, payload, ok := strings.Cut(value, ",")
+		if !ok || !strings.HasSuffix(header, ";base64") {
+			return nil, ErrMalformedImage
+		}
+		value = payload
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, ErrMalformedImage
+	}
+	return data, nil
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessLogoResizesAndTrims(t *testing.T) {
+	// A 2000x1000 transparent canvas with an opaque 1600x800 block in the middle
+	img := image.NewNRGBA(image.Rect(0, 0, 2000, 1000))
+	for y := 100; y < 900; y++ {
+		for x := 200; x < 1800; x++ {
+			img.Set(x, y, color.NRGBA{R: 200, A: 255})
+		}
+	}
+
+	logo, err := ProcessLogo(encodePNG(t, img))
+	if err != nil {
+		t.Fatalf("ProcessLogo: %v", err)
+	}
+
+	if logo.Logo.ContentType != "image/png" {
+		t.Errorf("transparent logo encoded as %s", logo.Logo.ContentType)
+	}
+	if logo.Logo.Width != LogoMaxWidth || logo.Logo.Height != LogoMaxHeight {
+		t.Errorf("logo is %dx%d, want %dx%d", logo.Logo.Width, logo.Logo.Height, LogoMaxWidth, LogoMaxHeight)
+	}
+	if logo.Thumbnail.Width != LogoThumbnailSize || logo.Thumbnail.Height != LogoThumbnailSize/2 {
+		t.Errorf("thumbnail is %dx%d", logo.Thumbnail.Width, logo.Thumbnail.Height)
+	}
+}
+
+func TestProcessLogoRejectsNonImages(t *testing.T) {
+	// Claims to be an image by name only
+	_, err := ProcessLogo([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	if !errors.Is(err, ErrUnsupportedImage) {
+		t.Fatalf("got %v, want ErrUnsupportedImage", err)
+	}
+
+	// Valid signature, truncated body
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, 10, 10)))
+	_, err = ProcessLogo(data[:40])
+	if !errors.Is(err, ErrMalformedImage) {
+		t.Fatalf("got %v, want ErrMalformedImage", err)
+	}
+}
+
+func TestProcessLogoRejectsDecompressionBombs(t *testing.T) {
+	// A PNG header declaring 50000x50000 pixels; decoding it would need ~10GB
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], 50000)
+	binary.BigEndian.PutUint32(ihdr[4:], 50000)
+	ihdr[8], ihdr[9] = 8, 6 // 8-bit RGBA
+	binary.Write(&buf, binary.BigEndian, uint32(len(ihdr)))
+	chunk := append([]byte("IHDR"), ihdr...)
+	buf.Write(chunk)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+
+	if _, err := ProcessLogo(buf.Bytes()); !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("got %v, want ErrImageTooLarge", err)
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// 2x1 image: red, blue
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	img.Set(1, 0, color.RGBA{B: 255, A: 255})
+
+	rotated := applyOrientation(img, 6)
+	if b := rotated.Bounds(); b.Dx() != 1 || b.Dy() != 2 {
+		t.Fatalf("rotated bounds %v", b)
+	}
+	if r, _, _, _ := rotated.At(0, 0).RGBA(); r == 0 {
+		t.Error("expected red on top after rotating 90 clockwise")
+	}
+}
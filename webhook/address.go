@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// ErrPrivateAddress is returned for endpoints on addresses the worker must not
+// reach, so that webhooks cannot be used to probe the internal network
+var ErrPrivateAddress = errors.New("address is not publicly routable")
+
+// CheckIP rejects loopback, private, link-local, multicast and unspecified
+// addresses
+func CheckIP(ip net.IP) error {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%s: %w", ip, ErrPrivateAddress)
+	}
+	return nil
+}
+
+// CheckHost resolves the host of an endpoint URL and checks every address it
+// resolves to. The worker checks again when connecting, as DNS answers may
+// change in between.
+func CheckHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+
+	for _, addr := range addrs {
+		if err := CheckIP(addr.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dialControl is a net.Dialer Control function that refuses connections to
+// the addresses rejected by CheckIP, after DNS resolution
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%s: %w", host, ErrPrivateAddress)
+	}
+	return CheckIP(ip)
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/notblessy/bikinota-core/config"
+	"github.com/notblessy/bikinota-core/model"
+)
+
+func TestCheckIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"::ffff:127.0.0.1", false},
+		{"224.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		err := CheckIP(net.ParseIP(tt.ip))
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("CheckIP(%s) = %v, want allowed %v", tt.ip, err, tt.allowed)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	for _, host := range []string{"localhost", "127.0.0.1", "169.254.169.254"} {
+		if err := CheckHost(context.Background(), host); !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("CheckHost(%s) = %v, want %v", host, err, ErrPrivateAddress)
+		}
+	}
+	if err := CheckHost(context.Background(), "93.184.216.34"); err != nil {
+		t.Errorf("CheckHost of a public address: %v", err)
+	}
+}
+
+func TestSendRefusesPrivateAddresses(t *testing.T) {
+	called := false
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer receiver.Close()
+
+	// A host may resolve to a public address when the endpoint is saved and to
+	// an internal one later, so the worker checks every connection
+	w := NewWorker(nil, config.WebhookConfig{PollInterval: time.Second})
+	_, _, err := w.send(context.Background(),
+		&model.WebhookEndpoint{URL: receiver.URL, Secret: "s"},
+		&model.WebhookDelivery{Payload: "{}"})
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("send to %s: %v, want %v", receiver.URL, err, ErrPrivateAddress)
+	}
+	if called {
+		t.Error("request reached the loopback receiver")
+	}
+}
@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+)
+
+// Publisher queues events for delivery to the endpoints of a company
+type Publisher interface {
+	Publish(ctx context.Context, companyID uint, event model.WebhookEvent, data interface{}) error
+}
+
+// Envelope is the JSON body POSTed to webhook endpoints
+type Envelope struct {
+	ID        string             `json:"id"`
+	Type      model.WebhookEvent `json:"type"`
+	CompanyID string             `json:"company_id"`
+	CreatedAt string             `json:"created_at"`
+	Data      interface{}        `json:"data"`
+}
+
+type Dispatcher struct {
+	webhookRepo repository.WebhookRepository
+}
+
+func NewDispatcher(webhookRepo repository.WebhookRepository) *Dispatcher {
+	return &Dispatcher{webhookRepo: webhookRepo}
+}
+
+// Publish stores one pending delivery per subscribed endpoint. Delivery itself
+// happens asynchronously in the Worker, so a slow or failing receiver never
+// affects the request that triggered the event.
+func (d *Dispatcher) Publish(ctx context.Context, companyID uint, event model.WebhookEvent, data interface{}) error {
+	endpoints, err := d.webhookRepo.FindSubscribedEndpoints(ctx, companyID, event)
+	if err != nil {
+		return fmt.Errorf("failed to find webhook endpoints: %w", err)
+	}
+
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	eventID, err := newEventID()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(Envelope{
+		ID:        eventID,
+		Type:      event,
+		CompanyID: strconv.FormatUint(uint64(companyID), 10),
+		CreatedAt: now.UTC().Format(time.RFC3339),
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	deliveries := make([]model.WebhookDelivery, len(endpoints))
+	for i, endpoint := range endpoints {
+		deliveries[i] = model.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			EventID:       eventID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        model.WebhookDeliveryPending,
+			NextAttemptAt: now,
+		}
+	}
+
+	return d.webhookRepo.CreateDeliveries(ctx, deliveries)
+}
+
+// Redeliver queues a copy of an earlier delivery for immediate sending. The
+// original delivery is kept unchanged in the log.
+func (d *Dispatcher) Redeliver(ctx context.Context, delivery *model.WebhookDelivery) (*model.WebhookDelivery, error) {
+	deliveries := []model.WebhookDelivery{{
+		EndpointID:    delivery.EndpointID,
+		EventID:       delivery.EventID,
+		Event:         delivery.Event,
+		Payload:       delivery.Payload,
+		Status:        model.WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+	}}
+
+	if err := d.webhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		return nil, err
+	}
+
+	return &deliveries[0], nil
+}
+
+// GenerateSecret returns a new endpoint signing secret
+func GenerateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+func newEventID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate event id: %w", err)
+	}
+	return "evt_" + hex.EncodeToString(buf), nil
+}
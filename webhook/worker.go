@@ -0,0 +1,240 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/notblessy/bikinota-core/config"
+	"github.com/notblessy/bikinota-core/logging"
+	"github.com/notblessy/bikinota-core/metrics"
+	"github.com/notblessy/bikinota-core/model"
+	"github.com/notblessy/bikinota-core/repository"
+	"github.com/notblessy/bikinota-core/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	// MaxAttempts is how often a delivery is tried before it is marked failed
+	MaxAttempts = 10
+	// DisableAfterFailures disables an endpoint after this many consecutive
+	// failed attempts across all of its deliveries
+	DisableAfterFailures = 20
+
+	baseBackoff     = 30 * time.Second
+	maxBackoff      = 6 * time.Hour
+	claimLease      = 2 * time.Minute
+	batchSize       = 20
+	requestTimeout  = 10 * time.Second
+	maxResponseBody = 1024
+
+	// metricsJob names the worker in the job metrics
+	metricsJob = "webhook_delivery"
+)
+
+// Worker sends pending deliveries and schedules retries with exponential
+// backoff. Several workers may run against the same database.
+type Worker struct {
+	webhookRepo  repository.WebhookRepository
+	client       *http.Client
+	pollInterval time.Duration
+}
+
+// NewWorker creates a worker that refuses to connect to loopback, private and
+// link-local addresses unless cfg.AllowPrivateNetworks is set
+func NewWorker(webhookRepo repository.WebhookRepository, cfg config.WebhookConfig) *Worker {
+	dialer := &net.Dialer{Timeout: requestTimeout}
+	if !cfg.AllowPrivateNetworks {
+		// Checked on the resolved address of every connection, so a host
+		// that passed CheckHost cannot be rebound to an internal address
+		dialer.Control = dialControl
+	}
+
+	// Proxies from the environment are not used, they would be dialed
+	// instead of the endpoint
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: requestTimeout,
+	}
+
+	return &Worker{
+		webhookRepo: webhookRepo,
+		client: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: &tracing.Transport{Base: &logging.Transport{Base: transport}},
+		},
+		pollInterval: cfg.PollInterval,
+	}
+}
+
+// Run processes deliveries until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	logrus.Info("Webhook worker started")
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep draining while full batches come back
+		for w.ProcessBatch(ctx) == batchSize {
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			logrus.Info("Webhook worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch claims and sends one batch of due deliveries and returns how
+// many were processed
+func (w *Worker) ProcessBatch(ctx context.Context) int {
+	deliveries, err := w.webhookRepo.ClaimDueDeliveries(ctx, batchSize, claimLease)
+	if err != nil {
+		logrus.Errorf("Error claiming webhook deliveries: %v", err)
+		metrics.Job(metricsJob, "error")
+		return 0
+	}
+
+	for i := range deliveries {
+		w.deliver(ctx, &deliveries[i])
+	}
+
+	return len(deliveries)
+}
+
+func (w *Worker) deliver(ctx context.Context, delivery *model.WebhookDelivery) {
+	// Each attempt is a trace of its own and gets its own request ID, both
+	// are sent along to the endpoint
+	ctx, span := tracing.StartJob(ctx, "job.webhook_delivery",
+		attribute.Int("webhook.delivery_id", int(delivery.ID)),
+		attribute.String("webhook.event", string(delivery.Event)),
+	)
+	defer span.End()
+	ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+	ctx = logging.WithFields(ctx, logrus.Fields{
+		"worker":      "webhook",
+		"delivery_id": delivery.ID,
+		"event":       delivery.Event,
+	})
+	logger := logging.FromContext(ctx)
+
+	endpoint := delivery.Endpoint
+	if endpoint == nil || endpoint.DeletedAt.Valid || !endpoint.Enabled {
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.LastError = "endpoint disabled or deleted"
+		metrics.Job(metricsJob, "failed")
+		if err := w.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+			logger.Errorf("Error updating delivery: %v", err)
+		}
+		return
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+
+	status, body, err := w.send(ctx, endpoint, delivery)
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+
+	if err == nil {
+		delivery.Status = model.WebhookDeliverySucceeded
+		delivery.LastError = ""
+		metrics.Job(metricsJob, "succeeded")
+		if err := w.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+			logger.Errorf("Error updating delivery: %v", err)
+		}
+		if err := w.webhookRepo.RecordEndpointSuccess(ctx, endpoint.ID); err != nil {
+			logger.Errorf("Error resetting endpoint failures: %v", err)
+		}
+		return
+	}
+
+	logger.Warnf("Webhook delivery attempt %d failed: %v", delivery.Attempts, err)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= MaxAttempts {
+		delivery.Status = model.WebhookDeliveryFailed
+		metrics.Job(metricsJob, "failed")
+	} else {
+		delivery.NextAttemptAt = now.Add(Backoff(delivery.Attempts))
+		metrics.Job(metricsJob, "retrying")
+	}
+
+	if err := w.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		logger.Errorf("Error updating delivery: %v", err)
+	}
+
+	disabled, err := w.webhookRepo.RecordEndpointFailure(ctx, endpoint.ID, DisableAfterFailures)
+	if err != nil {
+		logger.Errorf("Error recording endpoint failure: %v", err)
+	}
+	if disabled {
+		logger.Warnf("Webhook endpoint %d disabled after %d consecutive failures", endpoint.ID, DisableAfterFailures)
+	}
+}
+
+// send POSTs the signed payload. Any non-2xx response counts as a failure.
+func (w *Worker) send(ctx context.Context, endpoint *model.WebhookEndpoint, delivery *model.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Bikinota-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, delivery.EventID)
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, time.Now(), body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	// Keep a short, text-safe excerpt of the response for the delivery log
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	respBody := strings.ToValidUTF8(strings.ReplaceAll(string(raw), "\x00", ""), "")
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, respBody, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, respBody, nil
+}
+
+// Backoff returns the delay before the next attempt after the given number of
+// attempts: 30s, 1m, 2m, 4m, ... capped at 6h
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return baseBackoff
+	}
+
+	delay := baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/notblessy/bikinota-core/config"
+	"github.com/notblessy/bikinota-core/model"
+)
+
+// testConfig allows the loopback addresses of httptest servers
+var testConfig = config.WebhookConfig{PollInterval: time.Second, AllowPrivateNetworks: true}
+
+func TestSendSignsPayload(t *testing.T) {
+	const secret = "whsec_test"
+	payload := `{"id":"evt_1","type":"invoice.created"}`
+
+	var verifyErr error
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verifyErr = Verify(secret, r.Header.Get(HeaderSignature), body, time.Now())
+		if r.Header.Get(HeaderEvent) != string(model.WebhookEventInvoiceCreated) {
+			t.Errorf("unexpected event header %q", r.Header.Get(HeaderEvent))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	w := NewWorker(nil, testConfig)
+	status, _, err := w.send(context.Background(),
+		&model.WebhookEndpoint{URL: receiver.URL, Secret: secret},
+		&model.WebhookDelivery{EventID: "evt_1", Event: model.WebhookEventInvoiceCreated, Payload: payload})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if status != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", status, http.StatusNoContent)
+	}
+	if verifyErr != nil {
+		t.Fatalf("receiver could not verify signature: %v", verifyErr)
+	}
+}
+
+func TestSendFailsOnNon2xx(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	w := NewWorker(nil, testConfig)
+	status, body, err := w.send(context.Background(),
+		&model.WebhookEndpoint{URL: receiver.URL, Secret: "s"},
+		&model.WebhookDelivery{Payload: "{}"})
+	if err == nil {
+		t.Fatal("expected an error for a 500 response")
+	}
+	if status != http.StatusInternalServerError || body != "boom\n" {
+		t.Fatalf("got status %d body %q", status, body)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	now := time.Now()
+	header := Sign("secret", now, []byte("body"))
+
+	if err := Verify("secret", header, []byte("body!"), now); err == nil {
+		t.Error("tampered body was accepted")
+	}
+	if err := Verify("other", header, []byte("body"), now); err == nil {
+		t.Error("wrong secret was accepted")
+	}
+	if err := Verify("secret", header, []byte("body"), now.Add(10*time.Minute)); err == nil {
+		t.Error("stale signature was accepted")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		20: maxBackoff,
+	}
+	for attempts, want := range cases {
+		if got := Backoff(attempts); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}